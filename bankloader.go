@@ -0,0 +1,276 @@
+package sndfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/almerlucke/sndfile/dsp/resample"
+	"github.com/almerlucke/sndfile/float"
+)
+
+// SoundFileExtensions are the file extensions LoadDirectory picks up
+var SoundFileExtensions = []string{".wav", ".aif", ".aiff", ".aifc", ".flac", ".ogg", ".caf", ".au", ".snd"}
+
+// ManifestEntry describes a file to load into a SoundBank
+type ManifestEntry struct {
+	// Name in the SoundBank, defaults to the path without extension
+	Name string `json:"name"`
+	// Path of the file, relative paths in a manifest are relative to the manifest file
+	Path string `json:"path"`
+	// MipMapDepth loads the file as MipMapSoundFile when larger than 0
+	MipMapDepth int `json:"mipmapDepth"`
+	// Channels converts the file to a number of channels when larger than 0
+	Channels int `json:"channels"`
+	// SampleRate resamples the file when larger than 0
+	SampleRate float64 `json:"sampleRate"`
+}
+
+// LoadOptions for the SoundBank loaders
+type LoadOptions struct {
+	// Workers is the number of files decoded in parallel, 0 means the number of CPUs
+	Workers int
+	// MipMapDepth for directory and glob loading
+	MipMapDepth int
+	// Quality of the sample rate conversion
+	Quality resample.Quality
+	// Progress is called after each file, calls are serialized
+	Progress func(done int, total int, entry ManifestEntry, err error)
+}
+
+// ErrDuplicateName is the LoadError cause of an entry with the name of an earlier entry
+var ErrDuplicateName = errors.New("duplicate entry name")
+
+// LoadError is the error of a single file
+type LoadError struct {
+	Entry ManifestEntry
+	Err   error
+}
+
+func (e *LoadError) Error() string {
+	return fmt.Sprintf("%s (%s): %v", e.Entry.Name, e.Entry.Path, e.Err)
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// ConvertChannels returns a sound file with numChannels channels, channels are mixed down to
+// mono, a mono file is copied to all channels, otherwise channels are taken in order and wrapped
+func ConvertChannels[T float.Float](sf SoundFiler[T], numChannels int) *SoundFile[T] {
+	channels := make([][]T, numChannels)
+	srcChannels := sf.NumChannels()
+
+	if numChannels == 1 && srcChannels > 1 {
+		mix := make([]T, sf.NumFrames())
+		scale := 1.0 / T(srcChannels)
+
+		for c := 0; c < srcChannels; c++ {
+			for i, v := range sf.Buffer(c, 0) {
+				mix[i] += v * scale
+			}
+		}
+
+		channels[0] = mix
+	} else {
+		for c := range channels {
+			channels[c] = slices.Clone(sf.Buffer(c%srcChannels, 0))
+		}
+	}
+
+	return NewSoundFileFromBuffers(channels, sf.SampleRate())
+}
+
+// LoadEntry loads a single manifest entry
+func LoadEntry[T float.Float](entry ManifestEntry, quality resample.Quality) (SoundFiler[T], error) {
+	sf, err := NewSoundFile[T](entry.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.Channels > 0 && entry.Channels != sf.NumChannels() {
+		sf = ConvertChannels[T](sf, entry.Channels)
+	}
+
+	if entry.SampleRate > 0 && entry.SampleRate != sf.SampleRate() {
+		sf, err = ResampleSoundFile[T](sf, entry.SampleRate, quality)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if entry.MipMapDepth > 0 {
+		return NewMipMapSoundFileFromSoundFile(sf, entry.MipMapDepth)
+	}
+
+	return sf, nil
+}
+
+// LoadEntries decodes entries in parallel into a new SoundBank. Files that fail to load are
+// skipped, their errors are joined as LoadError in the returned error. Entries with the name of
+// an earlier entry are skipped with ErrDuplicateName.
+func LoadEntries[T float.Float](entries []ManifestEntry, opt LoadOptions) (SoundBank[T], error) {
+	workers := opt.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []error
+		done int
+	)
+
+	bank := SoundBank[T]{}
+	jobs := make(chan ManifestEntry)
+	names := map[string]bool{}
+	unique := make([]ManifestEntry, 0, len(entries))
+
+	// Duplicates are rejected before decoding so the first entry with a name always wins
+	for _, entry := range entries {
+		if entry.Name == "" {
+			entry.Name = entryName(".", entry.Path)
+		}
+
+		if !names[entry.Name] {
+			names[entry.Name] = true
+			unique = append(unique, entry)
+			continue
+		}
+
+		err := &LoadError{Entry: entry, Err: ErrDuplicateName}
+		errs = append(errs, err)
+		done++
+
+		if opt.Progress != nil {
+			opt.Progress(done, len(entries), entry, err)
+		}
+	}
+
+	for range min(workers, max(len(unique), 1)) {
+		wg.Go(func() {
+			for entry := range jobs {
+				sf, err := LoadEntry[T](entry, opt.Quality)
+
+				mu.Lock()
+
+				if err != nil {
+					err = &LoadError{Entry: entry, Err: err}
+					errs = append(errs, err)
+				} else {
+					bank[entry.Name] = sf
+				}
+
+				done++
+
+				if opt.Progress != nil {
+					opt.Progress(done, len(entries), entry, err)
+				}
+
+				mu.Unlock()
+			}
+		})
+	}
+
+	for _, entry := range unique {
+		jobs <- entry
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	return bank, errors.Join(errs...)
+}
+
+// entryName returns the SoundBank name of a file relative to dir
+func entryName(dir string, path string) string {
+	if rel, err := filepath.Rel(dir, path); err == nil {
+		path = rel
+	}
+
+	return strings.TrimSuffix(filepath.ToSlash(path), filepath.Ext(path))
+}
+
+// LoadDirectory loads all sound files in dir and its subdirectories, files are named by their
+// path relative to dir without extension
+func LoadDirectory[T float.Float](dir string, opt LoadOptions) (SoundBank[T], error) {
+	var entries []ManifestEntry
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || !slices.Contains(SoundFileExtensions, strings.ToLower(filepath.Ext(path))) {
+			return nil
+		}
+
+		entries = append(entries, ManifestEntry{
+			Name:        entryName(dir, path),
+			Path:        path,
+			MipMapDepth: opt.MipMapDepth,
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadEntries[T](entries, opt)
+}
+
+// LoadGlob loads all files matching pattern, files are named by their path without extension
+func LoadGlob[T float.Float](pattern string, opt LoadOptions) (SoundBank[T], error) {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]ManifestEntry, len(paths))
+	for i, path := range paths {
+		entries[i] = ManifestEntry{
+			Name:        entryName(".", path),
+			Path:        path,
+			MipMapDepth: opt.MipMapDepth,
+		}
+	}
+
+	return LoadEntries[T](entries, opt)
+}
+
+// LoadManifest loads the entries of a JSON manifest, the manifest is an array of entries
+func LoadManifest[T float.Float](manifestPath string, opt LoadOptions) (SoundBank[T], error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ManifestEntry
+
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(manifestPath)
+
+	for i := range entries {
+		if !filepath.IsAbs(entries[i].Path) {
+			entries[i].Path = filepath.Join(dir, entries[i].Path)
+		}
+
+		if entries[i].Name == "" {
+			entries[i].Name = entryName(dir, entries[i].Path)
+		}
+	}
+
+	return LoadEntries[T](entries, opt)
+}
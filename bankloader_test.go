@@ -0,0 +1,229 @@
+package sndfile
+
+import (
+	"cmp"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestConvertChannels(t *testing.T) {
+	stereo := NewSoundFileFromBuffers([][]float64{{1, 2}, {3, 4}}, 44100)
+	mono := NewSoundFileFromBuffers([][]float64{{1, 2}}, 44100)
+
+	tests := []struct {
+		name        string
+		sf          *SoundFile[float64]
+		numChannels int
+		want        [][]float64
+	}{
+		{"stereo to mono", stereo, 1, [][]float64{{2, 3}}},
+		{"mono to stereo", mono, 2, [][]float64{{1, 2}, {1, 2}}},
+		{"stereo to four", stereo, 4, [][]float64{{1, 2}, {3, 4}, {1, 2}, {3, 4}}},
+		{"stereo to stereo", stereo, 2, [][]float64{{1, 2}, {3, 4}}},
+	}
+
+	for _, tt := range tests {
+		out := ConvertChannels[float64](tt.sf, tt.numChannels)
+
+		if out.NumChannels() != tt.numChannels || out.SampleRate() != 44100 {
+			t.Fatalf("%s: %d channels at %v Hz", tt.name, out.NumChannels(), out.SampleRate())
+		}
+
+		for c, want := range tt.want {
+			if !slices.Equal(out.Buffer(c, 0), want) {
+				t.Errorf("%s: channel %d is %v, want %v", tt.name, c, out.Buffer(c, 0), want)
+			}
+		}
+	}
+
+	// The result does not share buffers with the source
+	ConvertChannels[float64](mono, 2).Buffer(0, 0)[0] = 10
+	if mono.Buffer(0, 0)[0] != 1 {
+		t.Fatal("source modified")
+	}
+}
+
+// writeFiles creates files with invalid sound data, so every load fails with a LoadError
+func writeFiles(t *testing.T, dir string, names ...string) {
+	for _, name := range names {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte("not a sound file"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// loadErrors returns the entries of the LoadErrors joined in err
+func loadErrors(t *testing.T, err error) []ManifestEntry {
+	var entries []ManifestEntry
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("error %v is not joined", err)
+	}
+
+	for _, e := range joined.Unwrap() {
+		var le *LoadError
+		if !errors.As(e, &le) {
+			t.Fatalf("error %v is not a LoadError", e)
+		}
+		entries = append(entries, le.Entry)
+	}
+
+	slices.SortFunc(entries, func(a, b ManifestEntry) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return entries
+}
+
+func TestLoadDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "kick.wav", "sub/snare.AIFF", "sub/deeper/hat.flac", "notes.txt", "sub/readme.md")
+
+	var (
+		mu    sync.Mutex
+		calls []int
+	)
+
+	bank, err := LoadDirectory[float64](dir, LoadOptions{Workers: 2, MipMapDepth: 3, Progress: func(done int, total int, entry ManifestEntry, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if total != 3 || err == nil {
+			t.Errorf("progress %d/%d for %s: %v", done, total, entry.Name, err)
+		}
+		calls = append(calls, done)
+	}})
+
+	if len(bank) != 0 {
+		t.Fatalf("bank has %d entries", len(bank))
+	}
+
+	entries := loadErrors(t, err)
+	names := []string{"kick", "sub/deeper/hat", "sub/snare"}
+
+	if len(entries) != len(names) {
+		t.Fatalf("%d errors, want %d", len(entries), len(names))
+	}
+
+	for i, e := range entries {
+		if e.Name != names[i] || e.MipMapDepth != 3 || !filepath.IsAbs(e.Path) {
+			t.Errorf("entry %+v", e)
+		}
+	}
+
+	if !slices.Equal(calls, []int{1, 2, 3}) {
+		t.Fatalf("progress calls %v", calls)
+	}
+
+	if _, err := LoadDirectory[float64](filepath.Join(dir, "missing"), LoadOptions{}); err == nil {
+		t.Fatal("expected error for a missing directory")
+	}
+}
+
+func TestLoadDuplicateNames(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "x.wav", "x.aif", "y.wav")
+
+	tests := []struct {
+		name    string
+		load    func() (SoundBank[float64], error)
+		paths   []string
+		skipped []string
+	}{
+		{
+			"directory",
+			func() (SoundBank[float64], error) { return LoadDirectory[float64](dir, LoadOptions{}) },
+			[]string{"x.aif", "x.wav", "y.wav"},
+			[]string{"x.wav"},
+		},
+		{
+			"entries",
+			func() (SoundBank[float64], error) {
+				return LoadEntries[float64]([]ManifestEntry{
+					{Name: "a", Path: filepath.Join(dir, "y.wav")},
+					{Name: "a", Path: filepath.Join(dir, "x.wav")},
+					{Name: "a", Path: filepath.Join(dir, "x.aif")},
+				}, LoadOptions{Workers: 3})
+			},
+			[]string{"x.aif", "x.wav", "y.wav"},
+			[]string{"x.aif", "x.wav"},
+		},
+	}
+
+	for _, tt := range tests {
+		_, err := tt.load()
+
+		var paths, skipped []string
+
+		for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+			var le *LoadError
+			if !errors.As(e, &le) {
+				t.Fatalf("%s: error %v is not a LoadError", tt.name, e)
+			}
+
+			paths = append(paths, filepath.Base(le.Entry.Path))
+
+			if errors.Is(e, ErrDuplicateName) {
+				skipped = append(skipped, filepath.Base(le.Entry.Path))
+			}
+		}
+
+		slices.Sort(paths)
+		slices.Sort(skipped)
+
+		if !slices.Equal(paths, tt.paths) || !slices.Equal(skipped, tt.skipped) {
+			t.Errorf("%s: errors for %v, duplicates %v", tt.name, paths, skipped)
+		}
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "samples/a.wav")
+
+	manifest := filepath.Join(dir, "bank.json")
+	os.WriteFile(manifest, []byte(`[
+		{"path": "samples/a.wav"},
+		{"name": "lead", "path": "samples/b.wav", "mipmapDepth": 4, "channels": 2, "sampleRate": 48000}
+	]`), 0o644)
+
+	_, err := LoadManifest[float64](manifest, LoadOptions{})
+	entries := loadErrors(t, err)
+
+	want := []ManifestEntry{
+		{Name: "lead", Path: filepath.Join(dir, "samples", "b.wav"), MipMapDepth: 4, Channels: 2, SampleRate: 48000},
+		{Name: "samples/a", Path: filepath.Join(dir, "samples", "a.wav")},
+	}
+
+	if !slices.Equal(entries, want) {
+		t.Fatalf("entries %+v, want %+v", entries, want)
+	}
+
+	os.WriteFile(manifest, []byte(`{"path": "a.wav"}`), 0o644)
+
+	if _, err := LoadManifest[float64](manifest, LoadOptions{}); err == nil {
+		t.Fatal("expected error for an invalid manifest")
+	}
+
+	if _, err := LoadManifest[float64](filepath.Join(dir, "missing.json"), LoadOptions{}); err == nil {
+		t.Fatal("expected error for a missing manifest")
+	}
+}
+
+func TestLoadEntriesEmpty(t *testing.T) {
+	bank, err := LoadEntries[float64](nil, LoadOptions{})
+	if err != nil || bank == nil || len(bank) != 0 {
+		t.Fatalf("bank %v, error %v", bank, err)
+	}
+}
@@ -0,0 +1,333 @@
+// Package cache stores decoded and mipmapped sounds in a binary cache file that is loaded with
+// memory mapping
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"github.com/almerlucke/sndfile"
+	"github.com/almerlucke/sndfile/float"
+)
+
+// Version of the cache format, files with another version are rejected
+const Version = 1
+
+const (
+	magic        = "SNDCACHE"
+	headerSize   = 128
+	dataAlign    = 64
+	crossingSize = 20
+)
+
+var (
+	ErrInvalid = errors.New("not a sound cache file")
+	ErrVersion = errors.New("unsupported sound cache version")
+)
+
+// SaveError is returned by Load together with the decoded sound when the cache file could not
+// be written, the sound is usable but is not cached
+type SaveError struct {
+	Path string
+	Err  error
+}
+
+func (e *SaveError) Error() string {
+	return fmt.Sprintf("saving sound cache %s: %v", e.Path, e.Err)
+}
+
+func (e *SaveError) Unwrap() error {
+	return e.Err
+}
+
+// Hash is the SHA-256 hash of a source file
+type Hash [sha256.Size]byte
+
+// HashFile returns the SHA-256 hash of a file
+func HashFile(filePath string) (Hash, error) {
+	var hash Hash
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return hash, err
+	}
+
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return hash, err
+	}
+
+	copy(hash[:], h.Sum(nil))
+
+	return hash, nil
+}
+
+type header struct {
+	version         uint32
+	sampleSize      uint32
+	numChannels     uint32
+	depth           uint32
+	numFrames       uint64
+	sampleRate      float64
+	sourceHash      Hash
+	metadataOffset  uint64
+	metadataLen     uint64
+	crossingsOffset uint64
+	crossingsLen    uint64
+	dataOffset      uint64
+}
+
+func (h *header) encode() []byte {
+	b := make([]byte, headerSize)
+	le := binary.LittleEndian
+
+	copy(b, magic)
+	le.PutUint32(b[8:], h.version)
+	le.PutUint32(b[12:], h.sampleSize)
+	le.PutUint32(b[16:], h.numChannels)
+	le.PutUint32(b[20:], h.depth)
+	le.PutUint64(b[24:], h.numFrames)
+	le.PutUint64(b[32:], math.Float64bits(h.sampleRate))
+	copy(b[40:72], h.sourceHash[:])
+	le.PutUint64(b[72:], h.metadataOffset)
+	le.PutUint64(b[80:], h.metadataLen)
+	le.PutUint64(b[88:], h.crossingsOffset)
+	le.PutUint64(b[96:], h.crossingsLen)
+	le.PutUint64(b[104:], h.dataOffset)
+
+	return b
+}
+
+func decodeHeader(b []byte) (*header, error) {
+	if len(b) < headerSize || string(b[:8]) != magic {
+		return nil, ErrInvalid
+	}
+
+	le := binary.LittleEndian
+	h := &header{
+		version:         le.Uint32(b[8:]),
+		sampleSize:      le.Uint32(b[12:]),
+		numChannels:     le.Uint32(b[16:]),
+		depth:           le.Uint32(b[20:]),
+		numFrames:       le.Uint64(b[24:]),
+		sampleRate:      math.Float64frombits(le.Uint64(b[32:])),
+		metadataOffset:  le.Uint64(b[72:]),
+		metadataLen:     le.Uint64(b[80:]),
+		crossingsOffset: le.Uint64(b[88:]),
+		crossingsLen:    le.Uint64(b[96:]),
+		dataOffset:      le.Uint64(b[104:]),
+	}
+
+	copy(h.sourceHash[:], b[40:72])
+
+	if h.version != Version {
+		return nil, ErrVersion
+	}
+
+	if h.sampleSize != 4 && h.sampleSize != 8 {
+		return nil, ErrInvalid
+	}
+
+	return h, nil
+}
+
+// Write writes sf to w in the cache format with the hash of its source file and metadata
+func Write[T float.Float](w io.Writer, sf sndfile.SoundFiler[T], sourceHash Hash, metadata map[string]string) error {
+	meta, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	var crossings bytes.Buffer
+	le := binary.LittleEndian
+
+	for c := 0; c < sf.NumChannels(); c++ {
+		zcs := sf.ZeroCrossings(c)
+		crossings.Write(le.AppendUint64(nil, uint64(len(zcs))))
+
+		for _, zc := range zcs {
+			rec := le.AppendUint64(nil, uint64(zc.PositionFrames))
+			rec = le.AppendUint64(rec, math.Float64bits(zc.Position))
+			rec = le.AppendUint32(rec, uint32(int32(zc.Direction)))
+			crossings.Write(rec)
+		}
+	}
+
+	var zero T
+
+	h := header{
+		version:         Version,
+		sampleSize:      uint32(unsafe.Sizeof(zero)),
+		numChannels:     uint32(sf.NumChannels()),
+		depth:           uint32(sf.Depth()),
+		numFrames:       uint64(sf.NumFrames()),
+		sampleRate:      sf.SampleRate(),
+		sourceHash:      sourceHash,
+		metadataOffset:  headerSize,
+		metadataLen:     uint64(len(meta)),
+		crossingsOffset: headerSize + uint64(len(meta)),
+		crossingsLen:    uint64(crossings.Len()),
+	}
+
+	end := h.crossingsOffset + h.crossingsLen
+	h.dataOffset = (end + dataAlign - 1) / dataAlign * dataAlign
+
+	bw := bufio.NewWriter(w)
+
+	bw.Write(h.encode())
+	bw.Write(meta)
+	bw.Write(crossings.Bytes())
+	bw.Write(make([]byte, h.dataOffset-end))
+
+	sample := make([]byte, h.sampleSize)
+
+	for c := 0; c < sf.NumChannels(); c++ {
+		for d := 0; d < sf.Depth(); d++ {
+			buf := sf.Buffer(c, d)
+			if uint64(len(buf)) != h.numFrames {
+				return errors.New("buffer length does not match number of frames")
+			}
+
+			for _, v := range buf {
+				if h.sampleSize == 4 {
+					le.PutUint32(sample, math.Float32bits(float32(v)))
+				} else {
+					le.PutUint64(sample, math.Float64bits(float64(v)))
+				}
+
+				bw.Write(sample)
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// Save writes sf to a cache file. The file is written to a temporary file in the same directory
+// that replaces filePath when complete, so processes that have the old file mapped keep a valid
+// mapping.
+func Save[T float.Float](filePath string, sf sndfile.SoundFiler[T], sourceHash Hash, metadata map[string]string) error {
+	f, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := f.Name()
+
+	err = Write(f, sf, sourceHash, metadata)
+	if err == nil {
+		err = f.Chmod(0o644)
+	}
+
+	if err == nil {
+		err = f.Sync()
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmpPath, filePath)
+	}
+
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+
+	return err
+}
+
+// readHeader reads the header of a cache file
+func readHeader(filePath string) (*header, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	b := make([]byte, headerSize)
+	if _, err := io.ReadFull(f, b); err != nil {
+		return nil, ErrInvalid
+	}
+
+	return decodeHeader(b)
+}
+
+// ReadSourceHash returns the source hash stored in a cache file
+func ReadSourceHash(filePath string) (Hash, error) {
+	h, err := readHeader(filePath)
+	if err != nil {
+		return Hash{}, err
+	}
+
+	return h.sourceHash, nil
+}
+
+// upToDate returns true if the cache file was built from a source with hash at depth with the
+// sample size of T
+func upToDate[T float.Float](cachePath string, hash Hash, depth int) bool {
+	h, err := readHeader(cachePath)
+	if err != nil {
+		return false
+	}
+
+	var zero T
+
+	return h.sourceHash == hash && h.depth == uint32(max(depth, 1)) && h.sampleSize == uint32(unsafe.Sizeof(zero))
+}
+
+// Load returns the cached sound of sourcePath when the cache file matches the source hash, depth
+// and sample size, otherwise the source is decoded, mipmapped when depth is larger than 1, and
+// the cache file is rewritten. When the cache file can not be written the decoded sound is
+// returned with a SaveError.
+func Load[T float.Float](sourcePath string, cachePath string, depth int) (*SoundFile[T], error) {
+	hash, err := HashFile(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if upToDate[T](cachePath, hash, depth) {
+		if sf, err := Open[T](cachePath); err == nil {
+			return sf, nil
+		}
+	}
+
+	var sf sndfile.SoundFiler[T]
+	if depth > 1 {
+		sf, err = sndfile.NewMipMapSoundFile[T](sourcePath, depth)
+	} else {
+		sf, err = sndfile.NewSoundFile[T](sourcePath)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return saveAndOpen(sf, hash, sourcePath, cachePath)
+}
+
+// saveAndOpen saves a decoded sound and opens the saved file, the decoded sound is returned
+// with a SaveError when saving fails
+func saveAndOpen[T float.Float](sf sndfile.SoundFiler[T], hash Hash, sourcePath string, cachePath string) (*SoundFile[T], error) {
+	metadata := map[string]string{"source": sourcePath}
+
+	if err := Save(cachePath, sf, hash, metadata); err != nil {
+		return fromSoundFiler(sf, hash, metadata), &SaveError{Path: cachePath, Err: err}
+	}
+
+	return Open[T](cachePath)
+}
@@ -0,0 +1,320 @@
+package cache
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+
+	"github.com/almerlucke/sndfile"
+)
+
+// testCache writes a source file and a cache file of a stereo sound mipmapped at depth 3, the
+// source is not a decodable sound file
+func testCache(t *testing.T) (*sndfile.MipMapSoundFile[float64], string, string, Hash) {
+	dir := t.TempDir()
+	n := 2000
+	left, right := make([]float64, n), make([]float64, n)
+
+	for i := range left {
+		left[i] = math.Sin(float64(i) * 0.3)
+		right[i] = math.Cos(float64(i) * 0.01)
+	}
+
+	mm, err := sndfile.NewMipMapSoundFileFromSoundFile(sndfile.NewSoundFileFromBuffers([][]float64{left, right}, 48000), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sourcePath := filepath.Join(dir, "source.wav")
+	if err := os.WriteFile(sourcePath, []byte("source"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := HashFile(sourcePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cachePath := filepath.Join(dir, "source.sndcache")
+	if err := Save[float64](cachePath, mm, hash, map[string]string{"k": "v"}); err != nil {
+		t.Fatal(err)
+	}
+
+	return mm, sourcePath, cachePath, hash
+}
+
+func TestRoundTrip(t *testing.T) {
+	mm, _, cachePath, hash := testCache(t)
+
+	sf, err := Open[float64](cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer sf.Close()
+
+	if sf.NumChannels() != 2 || sf.Depth() != 3 || sf.NumFrames() != mm.NumFrames() ||
+		sf.SampleRate() != 48000 || sf.Duration() != mm.Duration() {
+		t.Fatal("properties differ")
+	}
+
+	if sf.Metadata()["k"] != "v" || sf.SourceHash() != hash {
+		t.Fatal("metadata differs")
+	}
+
+	for c := range 2 {
+		for d := range 3 {
+			for i, v := range mm.Buffer(c, d) {
+				if sf.Buffer(c, d)[i] != v {
+					t.Fatalf("channel %d depth %d sample %d differs", c, d, i)
+				}
+			}
+		}
+
+		zcs := mm.ZeroCrossings(c)
+		if len(sf.ZeroCrossings(c)) != len(zcs) {
+			t.Fatalf("channel %d: %d zero crossings, want %d", c, len(sf.ZeroCrossings(c)), len(zcs))
+		}
+
+		for i, zc := range zcs {
+			if sf.ZeroCrossings(c)[i] != zc {
+				t.Fatalf("channel %d zero crossing %d differs", c, i)
+			}
+		}
+	}
+
+	// Buffers are copy on write, a write does not reach the file
+	sf.Buffer(0, 0)[0] = 42
+
+	other, err := Open[float64](cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer other.Close()
+
+	if other.Buffer(0, 0)[0] != mm.Buffer(0, 0)[0] {
+		t.Fatal("buffer write reached the cache file")
+	}
+
+	// Converted sample size
+	sf32, err := Open[float32](cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer sf32.Close()
+
+	if math.Abs(float64(sf32.LookupAll(10.5, 2, false)[1])-mm.LookupAll(10.5, 2, false)[1]) > 1e-6 {
+		t.Fatal("float32 lookup differs")
+	}
+}
+
+func TestFootprint(t *testing.T) {
+	mm, _, cachePath, _ := testCache(t)
+
+	var crossings int64
+	for c := range 2 {
+		crossings += int64(len(mm.ZeroCrossings(c))) * int64(unsafe.Sizeof(sndfile.ZeroCrossing{}))
+	}
+
+	// Metadata holds k and v
+	heap := crossings + 2
+
+	sf64, err := Open[float64](cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer sf64.Close()
+
+	sf32, err := Open[float32](cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer sf32.Close()
+
+	tests := []struct {
+		name string
+		got  int64
+		want int64
+	}{
+		{"mapped", sndfile.Footprint[float64](sf64), heap},
+		{"converted", sndfile.Footprint[float32](sf32), heap + 2*3*mm.NumFrames()*4},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: %d bytes, want %d", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestSaveReplaces(t *testing.T) {
+	mm, _, cachePath, hash := testCache(t)
+
+	sf, err := Open[float64](cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer sf.Close()
+
+	mono := sndfile.NewSoundFileFromBuffers([][]float64{{1, 2, 3}}, 44100)
+	if err := Save[float64](cachePath, mono, hash, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The open mapping still holds the old file
+	if sf.Buffer(1, 2)[10] != mm.Buffer(1, 2)[10] {
+		t.Fatal("mapping changed by Save")
+	}
+
+	replaced, err := Open[float64](cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer replaced.Close()
+
+	if replaced.NumChannels() != 1 || replaced.NumFrames() != 3 {
+		t.Fatal("cache file not replaced")
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(cachePath))
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("temporary files left: %v", entries)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	_, sourcePath, cachePath, _ := testCache(t)
+
+	// The source is not a sound file, so a stale cache fails to rebuild
+	tests := []struct {
+		name  string
+		depth int
+		stale bool
+	}{
+		{"hit", 3, false},
+		{"depth mismatch", 1, true},
+		{"sample size mismatch", 3, true},
+		{"source changed", 3, true},
+	}
+
+	for _, tt := range tests {
+		var err error
+
+		switch tt.name {
+		case "sample size mismatch":
+			var sf *SoundFile[float32]
+			if sf, err = Load[float32](sourcePath, cachePath, tt.depth); err == nil {
+				sf.Close()
+			}
+		case "source changed":
+			if err := os.WriteFile(sourcePath, []byte("changed"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			fallthrough
+		default:
+			var sf *SoundFile[float64]
+			if sf, err = Load[float64](sourcePath, cachePath, tt.depth); err == nil {
+				sf.Close()
+			}
+		}
+
+		if (err != nil) != tt.stale {
+			t.Errorf("%s: error %v", tt.name, err)
+		}
+	}
+}
+
+func TestCacheUnwritable(t *testing.T) {
+	mm, sourcePath, cachePath, hash := testCache(t)
+
+	tests := []struct {
+		name      string
+		cachePath string
+		saveError bool
+	}{
+		{"writable", filepath.Join(filepath.Dir(cachePath), "other.sndcache"), false},
+		{"unwritable", filepath.Join(filepath.Dir(cachePath), "missing", "other.sndcache"), true},
+	}
+
+	for _, tt := range tests {
+		sf, err := saveAndOpen[float64](mm, hash, sourcePath, tt.cachePath)
+
+		var se *SaveError
+		if errors.As(err, &se) != tt.saveError || (err != nil && !tt.saveError) {
+			t.Fatalf("%s: error %v", tt.name, err)
+		}
+
+		if sf.NumChannels() != 2 || sf.Depth() != 3 || sf.SourceHash() != hash || sf.Metadata()["source"] != sourcePath {
+			t.Fatalf("%s: properties differ", tt.name)
+		}
+
+		if sf.Buffer(1, 2)[10] != mm.Buffer(1, 2)[10] || sf.ZeroCrossings(0)[1] != mm.ZeroCrossings(0)[1] {
+			t.Fatalf("%s: samples differ", tt.name)
+		}
+
+		if err := sf.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	_, _, cachePath, _ := testCache(t)
+
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	corrupt := func(offset int, value uint64) []byte {
+		b := append([]byte(nil), data...)
+		for i := range 8 {
+			b[offset+i] = byte(value >> (8 * i))
+		}
+
+		return b
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", nil, ErrInvalid},
+		{"magic", append([]byte("NOTCACHE"), data[8:]...), ErrInvalid},
+		{"version", corrupt(8, 9|4<<32), ErrVersion},
+		{"sample size", corrupt(8, 1|3<<32), ErrInvalid},
+		{"truncated header", data[:headerSize-1], ErrInvalid},
+		{"truncated data", data[:len(data)-8], ErrInvalid},
+		{"frames overflow", corrupt(24, math.MaxUint64/2), ErrInvalid},
+		{"buffers overflow", corrupt(16, 0xffffffff|0xffffffff<<32), ErrInvalid},
+		{"metadata overflow", corrupt(72, math.MaxUint64), ErrInvalid},
+		{"crossings overflow", corrupt(88, math.MaxUint64-8), ErrInvalid},
+		{"data overflow", corrupt(104, math.MaxUint64-63), ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "invalid")
+		if err := os.WriteFile(path, tt.data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if sf, err := Open[float64](path); !errors.Is(err, tt.err) {
+			if err == nil {
+				sf.Close()
+			}
+
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !unix
+
+package cache
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile reads the file into memory on platforms without mmap support
+func mapFile(f *os.File, size int) ([]byte, error) {
+	data := make([]byte, size)
+
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func unmapFile(_ []byte) error {
+	return nil
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package cache
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps a file copy on write into memory, pages are shared until they are written
+func mapFile(f *os.File, size int) ([]byte, error) {
+	if size == 0 {
+		return nil, nil
+	}
+
+	return syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE)
+}
+
+func unmapFile(data []byte) error {
+	if data == nil {
+		return nil
+	}
+
+	return syscall.Munmap(data)
+}
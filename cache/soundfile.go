@@ -0,0 +1,294 @@
+package cache
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"os"
+	"unsafe"
+
+	"github.com/almerlucke/sndfile"
+	"github.com/almerlucke/sndfile/float"
+)
+
+// SoundFile is a SoundFiler backed by a memory mapped cache file. When the stored sample size
+// matches T on a little endian host the buffers point directly into the mapping, so pages are
+// loaded lazily and shared between processes. The mapping is copy on write, writes to a buffer
+// are private to the process and never reach the cache file. Buffers must not be used after
+// Close.
+type SoundFile[T float.Float] struct {
+	mapping       []byte
+	channels      [][][]T
+	sampleRate    float64
+	numFrames     int64
+	duration      float64
+	depth         int
+	out           []T
+	zeroCrossings []sndfile.ZeroCrossings
+	metadata      map[string]string
+	sourceHash    Hash
+	direct        bool
+}
+
+// Open memory maps a cache file as SoundFile
+func Open[T float.Float](filePath string) (*SoundFile[T], error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Size() < headerSize {
+		return nil, ErrInvalid
+	}
+
+	mapping, err := mapFile(f, int(info.Size()))
+	if err != nil {
+		return nil, err
+	}
+
+	sf, err := newSoundFile[T](mapping)
+	if err != nil {
+		unmapFile(mapping)
+		return nil, err
+	}
+
+	return sf, nil
+}
+
+// Must opens a cache file and panics on error
+func Must[T float.Float](filePath string) *SoundFile[T] {
+	sf, err := Open[T](filePath)
+	if err != nil {
+		panic(err)
+	}
+
+	return sf
+}
+
+func newSoundFile[T float.Float](mapping []byte) (*SoundFile[T], error) {
+	h, err := decodeHeader(mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	size := uint64(len(mapping))
+
+	if h.numChannels == 0 || h.depth == 0 || h.numFrames > size/uint64(h.sampleSize) ||
+		!inRange(h.metadataOffset, h.metadataLen, size) ||
+		!inRange(h.crossingsOffset, h.crossingsLen, size) ||
+		h.dataOffset%dataAlign != 0 {
+		return nil, ErrInvalid
+	}
+
+	bufLen := h.numFrames * uint64(h.sampleSize)
+	numBuffers := uint64(h.numChannels) * uint64(h.depth)
+
+	if bufLen > 0 && numBuffers > size/bufLen || !inRange(h.dataOffset, numBuffers*bufLen, size) {
+		return nil, ErrInvalid
+	}
+
+	sf := &SoundFile[T]{
+		mapping:       mapping,
+		channels:      make([][][]T, h.numChannels),
+		sampleRate:    h.sampleRate,
+		numFrames:     int64(h.numFrames),
+		duration:      float64(h.numFrames) / h.sampleRate,
+		depth:         int(h.depth),
+		out:           make([]T, h.numChannels),
+		zeroCrossings: make([]sndfile.ZeroCrossings, h.numChannels),
+		sourceHash:    h.sourceHash,
+	}
+
+	if err := json.Unmarshal(mapping[h.metadataOffset:h.metadataOffset+h.metadataLen], &sf.metadata); err != nil {
+		return nil, ErrInvalid
+	}
+
+	le := binary.LittleEndian
+	crossings := mapping[h.crossingsOffset : h.crossingsOffset+h.crossingsLen]
+
+	for c := range sf.zeroCrossings {
+		if len(crossings) < 8 {
+			return nil, ErrInvalid
+		}
+
+		count := le.Uint64(crossings)
+		crossings = crossings[8:]
+
+		if count > uint64(len(crossings))/crossingSize {
+			return nil, ErrInvalid
+		}
+
+		zcs := make(sndfile.ZeroCrossings, count)
+		for i := range zcs {
+			zcs[i] = sndfile.ZeroCrossing{
+				PositionFrames: int64(le.Uint64(crossings)),
+				Position:       math.Float64frombits(le.Uint64(crossings[8:])),
+				Direction:      int(int32(le.Uint32(crossings[16:]))),
+			}
+			crossings = crossings[crossingSize:]
+		}
+
+		sf.zeroCrossings[c] = zcs
+	}
+
+	var zero T
+
+	direct := uint32(unsafe.Sizeof(zero)) == h.sampleSize && binary.NativeEndian.Uint16([]byte{1, 0}) == 1
+	sf.direct = direct
+	offset := h.dataOffset
+	n := int(h.numFrames)
+
+	for c := range sf.channels {
+		sf.channels[c] = make([][]T, h.depth)
+
+		for d := range sf.channels[c] {
+			data := mapping[offset : offset+bufLen]
+			offset += uint64(len(data))
+
+			if direct {
+				if n > 0 {
+					sf.channels[c][d] = unsafe.Slice((*T)(unsafe.Pointer(&data[0])), n)
+				} else {
+					sf.channels[c][d] = []T{}
+				}
+				continue
+			}
+
+			buf := make([]T, n)
+			for i := range buf {
+				if h.sampleSize == 4 {
+					buf[i] = T(math.Float32frombits(le.Uint32(data[i*4:])))
+				} else {
+					buf[i] = T(math.Float64frombits(le.Uint64(data[i*8:])))
+				}
+			}
+
+			sf.channels[c][d] = buf
+		}
+	}
+
+	return sf, nil
+}
+
+// inRange returns true if length bytes at offset fit in size bytes, without overflowing
+func inRange(offset uint64, length uint64, size uint64) bool {
+	return length <= size && offset <= size-length
+}
+
+// fromSoundFiler returns a SoundFile that holds the buffers of sf without a mapping
+func fromSoundFiler[T float.Float](sf sndfile.SoundFiler[T], hash Hash, metadata map[string]string) *SoundFile[T] {
+	csf := &SoundFile[T]{
+		channels:      make([][][]T, sf.NumChannels()),
+		sampleRate:    sf.SampleRate(),
+		numFrames:     sf.NumFrames(),
+		duration:      sf.Duration(),
+		depth:         sf.Depth(),
+		out:           make([]T, sf.NumChannels()),
+		zeroCrossings: make([]sndfile.ZeroCrossings, sf.NumChannels()),
+		metadata:      metadata,
+		sourceHash:    hash,
+	}
+
+	for c := range csf.channels {
+		csf.channels[c] = make([][]T, csf.depth)
+
+		for d := range csf.channels[c] {
+			csf.channels[c][d] = sf.Buffer(c, d)
+		}
+
+		csf.zeroCrossings[c] = sf.ZeroCrossings(c)
+	}
+
+	return csf
+}
+
+// Close unmaps the cache file
+func (sf *SoundFile[T]) Close() error {
+	mapping := sf.mapping
+	sf.mapping = nil
+	sf.channels = nil
+
+	return unmapFile(mapping)
+}
+
+// Footprint returns the number of heap bytes held by the sound, buffers that point into the
+// mapping are not counted because their pages are shared and loaded on demand
+func (sf *SoundFile[T]) Footprint() int64 {
+	var size int64
+
+	for _, zcs := range sf.zeroCrossings {
+		size += int64(len(zcs)) * int64(unsafe.Sizeof(sndfile.ZeroCrossing{}))
+	}
+
+	for key, value := range sf.metadata {
+		size += int64(len(key) + len(value))
+	}
+
+	if !sf.direct {
+		var zero T
+		size += int64(len(sf.channels)*sf.depth) * sf.numFrames * int64(unsafe.Sizeof(zero))
+	}
+
+	return size
+}
+
+// Metadata returns the metadata stored in the cache file
+func (sf *SoundFile[T]) Metadata() map[string]string {
+	return sf.metadata
+}
+
+// SourceHash returns the hash of the source file the cache was built from
+func (sf *SoundFile[T]) SourceHash() Hash {
+	return sf.sourceHash
+}
+
+func (sf *SoundFile[T]) NumChannels() int {
+	return len(sf.out)
+}
+
+func (sf *SoundFile[T]) SampleRate() float64 {
+	return sf.sampleRate
+}
+
+func (sf *SoundFile[T]) NumFrames() int64 {
+	return sf.numFrames
+}
+
+func (sf *SoundFile[T]) Duration() float64 {
+	return sf.duration
+}
+
+func (sf *SoundFile[T]) Depth() int {
+	return sf.depth
+}
+
+func (sf *SoundFile[T]) Buffer(channel int, depth int) []T {
+	return sf.channels[channel][depth]
+}
+
+func (sf *SoundFile[T]) Lookup(pos float64, channel int, depth int, wrap bool) T {
+	lp := sndfile.NewLookupParam[T](pos, sf.numFrames, wrap)
+	return lp.Lookup(sf.channels[channel][depth])
+}
+
+func (sf *SoundFile[T]) LookupAll(pos float64, depth int, wrap bool) []T {
+	lp := sndfile.NewLookupParam[T](pos, sf.numFrames, wrap)
+	out := sf.out
+
+	for c := 0; c < len(sf.channels); c++ {
+		out[c] = lp.Lookup(sf.channels[c][depth])
+	}
+
+	return out
+}
+
+func (sf *SoundFile[T]) ZeroCrossings(channel int) sndfile.ZeroCrossings {
+	return sf.zeroCrossings[channel]
+}
@@ -0,0 +1,352 @@
+package sndfile
+
+import (
+	"encoding/binary"
+	"math"
+	"unsafe"
+
+	"github.com/almerlucke/sndfile/float"
+)
+
+// SampleFormat is the storage format of a CompactBuffer
+type SampleFormat int
+
+const (
+	// Int16Samples stores samples as 16 bit integers
+	Int16Samples SampleFormat = iota
+	// Int24Samples stores samples as packed 24 bit integers
+	Int24Samples
+	// Float16Samples stores samples as IEEE 754 half precision floats
+	Float16Samples
+)
+
+// BytesPerSample returns the storage size of a sample
+func (f SampleFormat) BytesPerSample() int {
+	switch f {
+	case Int24Samples:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// CompactBuffer stores samples in a compact format and converts them on access
+type CompactBuffer struct {
+	format SampleFormat
+	data   []byte
+}
+
+// quantize scales and clips a sample to a signed integer range
+func quantize(v float64, scale float64) int32 {
+	q := math.Round(v * scale)
+
+	return int32(min(max(q, -scale), scale-1))
+}
+
+// NewCompactBuffer converts buf to format, integer formats clip samples outside -1 to 1
+func NewCompactBuffer[T float.Float](buf []T, format SampleFormat) *CompactBuffer {
+	cb := &CompactBuffer{
+		format: format,
+		data:   make([]byte, len(buf)*format.BytesPerSample()),
+	}
+
+	for i, v := range buf {
+		switch format {
+		case Int16Samples:
+			binary.LittleEndian.PutUint16(cb.data[i*2:], uint16(quantize(float64(v), 32768.0)))
+		case Int24Samples:
+			q := quantize(float64(v), 8388608.0)
+			cb.data[i*3] = byte(q)
+			cb.data[i*3+1] = byte(q >> 8)
+			cb.data[i*3+2] = byte(q >> 16)
+		case Float16Samples:
+			binary.LittleEndian.PutUint16(cb.data[i*2:], Float32ToFloat16(float32(v)))
+		}
+	}
+
+	return cb
+}
+
+// Format returns the storage format
+func (cb *CompactBuffer) Format() SampleFormat {
+	return cb.format
+}
+
+// Len returns the number of samples
+func (cb *CompactBuffer) Len() int {
+	return len(cb.data) / cb.format.BytesPerSample()
+}
+
+// Bytes returns the number of bytes used to store the samples
+func (cb *CompactBuffer) Bytes() int {
+	return len(cb.data)
+}
+
+// At returns sample i
+func (cb *CompactBuffer) At(i int64) float64 {
+	switch cb.format {
+	case Int24Samples:
+		b := cb.data[i*3:]
+		// Shift into the top bytes of an int32 to sign extend
+		return float64(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24)>>8) / 8388608.0
+	case Float16Samples:
+		return float64(Float16ToFloat32(binary.LittleEndian.Uint16(cb.data[i*2:])))
+	default:
+		return float64(int16(binary.LittleEndian.Uint16(cb.data[i*2:]))) / 32768.0
+	}
+}
+
+// DecodeCompactBuffer decodes all samples of cb into dst, dst is grown when needed
+func DecodeCompactBuffer[T float.Float](cb *CompactBuffer, dst []T) []T {
+	n := cb.Len()
+
+	if cap(dst) < n {
+		dst = make([]T, n)
+	}
+
+	dst = dst[:n]
+
+	for i := range dst {
+		dst[i] = T(cb.At(int64(i)))
+	}
+
+	return dst
+}
+
+// lookupCompact interpolates a compact buffer like LookupParam.Lookup
+func lookupCompact[T float.Float](lp *LookupParam[T], cb *CompactBuffer) T {
+	s1 := cb.At(lp.Index1)
+	return T(s1 + lp.Fraction*(cb.At(lp.Index2)-s1))
+}
+
+// Float32ToFloat16 converts a float32 to IEEE 754 half precision with round to nearest even
+func Float32ToFloat16(f float32) uint16 {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int32(bits>>23) & 0xff
+	mant := bits & 0x7fffff
+
+	switch {
+	case exp == 0xff:
+		// Inf or NaN
+		if mant != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	case exp-127 > 15:
+		// Overflow to inf
+		return sign | 0x7c00
+	case exp-127 >= -14:
+		// Normal, round the 13 dropped mantissa bits to nearest even
+		half := uint32(exp-127+15)<<10 | mant>>13
+		rest := mant & 0x1fff
+		if rest > 0x1000 || (rest == 0x1000 && half&1 == 1) {
+			half++
+		}
+		return sign | uint16(half)
+	case exp-127 >= -25:
+		// Subnormal
+		mant |= 0x800000
+		shift := uint32(-(exp - 127) - 14 + 13)
+		half := mant >> shift
+		rest := mant & (1<<shift - 1)
+		halfway := uint32(1) << (shift - 1)
+		if rest > halfway || (rest == halfway && half&1 == 1) {
+			half++
+		}
+		return sign | uint16(half)
+	default:
+		return sign
+	}
+}
+
+// Float16ToFloat32 converts an IEEE 754 half precision float to float32
+func Float16ToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+
+	switch {
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	case exp == 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+
+		// Subnormal, normalize the mantissa
+		e := uint32(127 - 15 + 1)
+		for mant&0x400 == 0 {
+			mant <<= 1
+			e--
+		}
+
+		return math.Float32frombits(sign | e<<23 | (mant&0x3ff)<<13)
+	default:
+		return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+	}
+}
+
+// CompactMipMap is a MipMap with compact sample storage
+type CompactMipMap[T float.Float] struct {
+	buffers []*CompactBuffer
+}
+
+// NewCompactMipMap converts all depths of a MipMap to format
+func NewCompactMipMap[T float.Float](mm *MipMap[T], format SampleFormat) *CompactMipMap[T] {
+	cmm := &CompactMipMap[T]{
+		buffers: make([]*CompactBuffer, mm.Depth()),
+	}
+
+	for d := range cmm.buffers {
+		cmm.buffers[d] = NewCompactBuffer(mm.Buffer(d), format)
+	}
+
+	return cmm
+}
+
+func (mm *CompactMipMap[T]) Length() int {
+	return mm.buffers[0].Len()
+}
+
+func (mm *CompactMipMap[T]) Depth() int {
+	return len(mm.buffers)
+}
+
+func (mm *CompactMipMap[T]) Lookup(pos float64, depth int, wrap bool) T {
+	lp := NewLookupParam[T](pos, int64(mm.Length()), wrap)
+	return lookupCompact(lp, mm.buffers[depth])
+}
+
+// Buffer decodes a depth, this allocates a new buffer on each call
+func (mm *CompactMipMap[T]) Buffer(depth int) []T {
+	return DecodeCompactBuffer[T](mm.buffers[depth], nil)
+}
+
+// CompactSoundFile is a SoundFiler that stores its samples in a compact format and converts
+// them on the fly in lookups. Buffer decodes on demand and allocates, lookups do not.
+type CompactSoundFile[T float.Float] struct {
+	channels      [][]*CompactBuffer
+	format        SampleFormat
+	sampleRate    float64
+	numFrames     int64
+	duration      float64
+	depth         int
+	out           []T
+	zeroCrossings []ZeroCrossings
+}
+
+// NewCompactSoundFile converts a SoundFile, MipMapSoundFile or any other SoundFiler to compact
+// storage, all depths are kept
+func NewCompactSoundFile[T float.Float](sf SoundFiler[T], format SampleFormat) *CompactSoundFile[T] {
+	csf := &CompactSoundFile[T]{
+		channels:      make([][]*CompactBuffer, sf.NumChannels()),
+		format:        format,
+		sampleRate:    sf.SampleRate(),
+		numFrames:     sf.NumFrames(),
+		duration:      sf.Duration(),
+		depth:         sf.Depth(),
+		out:           make([]T, sf.NumChannels()),
+		zeroCrossings: make([]ZeroCrossings, sf.NumChannels()),
+	}
+
+	for c := range csf.channels {
+		csf.channels[c] = make([]*CompactBuffer, csf.depth)
+
+		for d := range csf.channels[c] {
+			csf.channels[c][d] = NewCompactBuffer(sf.Buffer(c, d), format)
+		}
+
+		csf.zeroCrossings[c] = sf.ZeroCrossings(c)
+	}
+
+	return csf
+}
+
+// Format returns the storage format
+func (sf *CompactSoundFile[T]) Format() SampleFormat {
+	return sf.format
+}
+
+func (sf *CompactSoundFile[T]) NumChannels() int {
+	return len(sf.channels)
+}
+
+func (sf *CompactSoundFile[T]) SampleRate() float64 {
+	return sf.sampleRate
+}
+
+func (sf *CompactSoundFile[T]) NumFrames() int64 {
+	return sf.numFrames
+}
+
+func (sf *CompactSoundFile[T]) Duration() float64 {
+	return sf.duration
+}
+
+func (sf *CompactSoundFile[T]) Depth() int {
+	return sf.depth
+}
+
+// Buffer decodes a channel at depth, this allocates a new buffer on each call
+func (sf *CompactSoundFile[T]) Buffer(channel int, depth int) []T {
+	return DecodeCompactBuffer[T](sf.channels[channel][depth], nil)
+}
+
+func (sf *CompactSoundFile[T]) Lookup(pos float64, channel int, depth int, wrap bool) T {
+	lp := NewLookupParam[T](pos, sf.numFrames, wrap)
+	return lookupCompact(lp, sf.channels[channel][depth])
+}
+
+func (sf *CompactSoundFile[T]) LookupAll(pos float64, depth int, wrap bool) []T {
+	lp := NewLookupParam[T](pos, sf.numFrames, wrap)
+	out := sf.out
+
+	for c := 0; c < len(sf.channels); c++ {
+		out[c] = lookupCompact(lp, sf.channels[c][depth])
+	}
+
+	return out
+}
+
+// LookupBlock fills dst with interpolated samples of a channel starting at pos and advancing by
+// speed per sample, the position after the block is returned
+func (sf *CompactSoundFile[T]) LookupBlock(dst []T, pos float64, speed float64, channel int, depth int, wrap bool) float64 {
+	cb := sf.channels[channel][depth]
+	n := float64(sf.numFrames)
+
+	for i := range dst {
+		if wrap {
+			pos = math.Mod(pos, n)
+			if pos < 0 {
+				pos += n
+			}
+		} else {
+			pos = min(max(pos, 0), n-1)
+		}
+
+		dst[i] = lookupCompact(NewLookupParam[T](pos, sf.numFrames, wrap), cb)
+		pos += speed
+	}
+
+	return pos
+}
+
+// Footprint returns the number of bytes held by the compact buffers and zero crossings
+func (sf *CompactSoundFile[T]) Footprint() int64 {
+	var size int64
+
+	for c, depths := range sf.channels {
+		for _, cb := range depths {
+			size += int64(cb.Bytes())
+		}
+
+		size += int64(len(sf.zeroCrossings[c])) * int64(unsafe.Sizeof(ZeroCrossing{}))
+	}
+
+	return size
+}
+
+func (sf *CompactSoundFile[T]) ZeroCrossings(channel int) ZeroCrossings {
+	return sf.zeroCrossings[channel]
+}
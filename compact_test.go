@@ -0,0 +1,136 @@
+package sndfile
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFloat16(t *testing.T) {
+	// Every half precision value except NaN survives a round trip through float32
+	for h := range 0x10000 {
+		f := Float16ToFloat32(uint16(h))
+		if f != f {
+			continue
+		}
+
+		if back := Float32ToFloat16(f); back != uint16(h) {
+			t.Fatalf("%04x -> %v -> %04x", h, f, back)
+		}
+	}
+
+	tests := []struct {
+		name string
+		f    float32
+		want uint16
+	}{
+		{"one", 1, 0x3c00},
+		{"negative", -2, 0xc000},
+		{"max", 65504, 0x7bff},
+		{"overflow", 70000, 0x7c00},
+		{"smallest subnormal", 5.96e-8, 0x0001},
+		{"underflow", 1e-9, 0x0000},
+		{"tie to even down", 1 + 1.0/2048, 0x3c00},
+		{"tie to even up", 1 + 3.0/2048, 0x3c02},
+		{"inf", float32(math.Inf(-1)), 0xfc00},
+		{"nan", float32(math.NaN()), 0x7e00},
+	}
+
+	for _, tt := range tests {
+		if got := Float32ToFloat16(tt.f); got != tt.want {
+			t.Errorf("%s: %04x, want %04x", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompactBuffer(t *testing.T) {
+	buf := []float64{0, 0.5, -0.5, 1.5, -1.5}
+
+	tests := []struct {
+		format SampleFormat
+		bytes  int
+		want   []float64
+	}{
+		{Int16Samples, 10, []float64{0, 0.5, -0.5, 32767.0 / 32768.0, -1}},
+		{Int24Samples, 15, []float64{0, 0.5, -0.5, 8388607.0 / 8388608.0, -1}},
+		{Float16Samples, 10, []float64{0, 0.5, -0.5, 1.5, -1.5}},
+	}
+
+	for _, tt := range tests {
+		cb := NewCompactBuffer(buf, tt.format)
+
+		if cb.Format() != tt.format || cb.Len() != len(buf) || cb.Bytes() != tt.bytes {
+			t.Errorf("format %d: %d samples in %d bytes", tt.format, cb.Len(), cb.Bytes())
+		}
+
+		for i, v := range DecodeCompactBuffer[float64](cb, nil) {
+			if v != tt.want[i] {
+				t.Errorf("format %d: sample %d is %v, want %v", tt.format, i, v, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestCompactSoundFile(t *testing.T) {
+	n := 1000
+	left := make([]float64, n)
+	right := make([]float64, n)
+
+	for i := range left {
+		left[i] = 0.9 * math.Sin(float64(i)*0.05)
+		right[i] = -left[i]
+	}
+
+	sf := NewSoundFileFromBuffers([][]float64{left, right}, 44100)
+
+	tests := []struct {
+		format SampleFormat
+		tol    float64
+	}{
+		{Int16Samples, 1.0 / 32768},
+		{Int24Samples, 1.0 / 8388608},
+		{Float16Samples, 1.0 / 2048},
+	}
+
+	for _, tt := range tests {
+		csf := NewCompactSoundFile[float64](sf, tt.format)
+
+		if csf.NumChannels() != 2 || csf.NumFrames() != int64(n) || csf.Depth() != 1 || csf.Duration() != sf.Duration() {
+			t.Fatalf("format %d: properties differ", tt.format)
+		}
+
+		maxErr := 0.0
+
+		for pos := 10.0; pos < float64(n-1); pos += 0.37 {
+			a := sf.LookupAll(pos, 0, false)
+			b := csf.LookupAll(pos, 0, false)
+
+			for c := range 2 {
+				maxErr = max(maxErr, math.Abs(a[c]-b[c]))
+			}
+		}
+
+		if maxErr > tt.tol {
+			t.Errorf("format %d: lookup error %v", tt.format, maxErr)
+		}
+
+		dst := make([]float64, 5)
+		end := csf.LookupBlock(dst, 10, 0.5, 1, 0, false)
+
+		if end != 12.5 || math.Abs(dst[1]-sf.Lookup(10.5, 1, 0, false)) > tt.tol {
+			t.Errorf("format %d: block ends at %v", tt.format, end)
+		}
+
+		bytes := int64(2 * n * tt.format.BytesPerSample())
+		if fp := Footprint[float64](csf); fp-bytes != Footprint[float64](sf)-int64(2*n*8) {
+			t.Errorf("format %d: footprint %d", tt.format, fp)
+		}
+	}
+}
+
+func TestCompactSoundFileNoChannels(t *testing.T) {
+	csf := NewCompactSoundFile[float64](NewSoundFileFromBuffers([][]float64{}, 44100), Int16Samples)
+
+	if csf.NumChannels() != 0 || csf.Depth() != 1 || len(csf.LookupAll(0, 0, false)) != 0 {
+		t.Fatal("empty sound file")
+	}
+}
@@ -0,0 +1,224 @@
+// Package fft implements mixed radix fast Fourier transforms, the short-time Fourier transform
+// and its inverse.
+package fft
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+)
+
+// FFT is a complex fast Fourier transform of a fixed size. Any size is supported, the size is
+// factored in radix 4, 2, 3 and 5 butterflies with a generic butterfly for remaining prime factors.
+// An FFT holds scratch space and must not be used from multiple goroutines at the same time.
+type FFT struct {
+	n         int
+	factors   []int
+	twiddles  []complex128
+	scratch   []complex128
+	butterfly []complex128
+}
+
+// New creates an FFT of size n
+func New(n int) (*FFT, error) {
+	if n < 1 {
+		return nil, errors.New("fft size should be at least 1")
+	}
+
+	f := &FFT{
+		n:        n,
+		factors:  factorize(n),
+		twiddles: make([]complex128, n),
+		scratch:  make([]complex128, n),
+	}
+
+	for i := 0; i < n; i++ {
+		phase := -2.0 * math.Pi * float64(i) / float64(n)
+		f.twiddles[i] = complex(math.Cos(phase), math.Sin(phase))
+	}
+
+	maxFactor := 0
+	for _, p := range f.factors {
+		maxFactor = max(maxFactor, p)
+	}
+
+	f.butterfly = make([]complex128, maxFactor)
+
+	return f, nil
+}
+
+// MustNew creates an FFT of size n and panics on error
+func MustNew(n int) *FFT {
+	f, err := New(n)
+	if err != nil {
+		panic(err)
+	}
+
+	return f
+}
+
+// IsPowerOfTwo returns true if n is a power of two
+func IsPowerOfTwo(n int) bool {
+	return n > 0 && n&(n-1) == 0
+}
+
+// NextPowerOfTwo returns the smallest power of two greater or equal to n
+func NextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+
+	return p
+}
+
+func factorize(n int) []int {
+	var factors []int
+
+	for _, p := range []int{4, 2, 3, 5} {
+		for n%p == 0 {
+			factors = append(factors, p)
+			n /= p
+		}
+	}
+
+	for p := 7; n > 1; p += 2 {
+		if p*p > n {
+			p = n
+		}
+		for n%p == 0 {
+			factors = append(factors, p)
+			n /= p
+		}
+	}
+
+	return factors
+}
+
+// Size returns the size of the transform
+func (f *FFT) Size() int {
+	return f.n
+}
+
+// Forward computes the forward transform of src into dst, both should have length Size().
+// dst and src may be the same slice.
+func (f *FFT) Forward(dst []complex128, src []complex128) {
+	f.transform(dst, src, false)
+}
+
+// Inverse computes the inverse transform of src into dst, both should have length Size().
+// The output is scaled by 1/Size() so Inverse(Forward(x)) returns x. dst and src may be the same slice.
+func (f *FFT) Inverse(dst []complex128, src []complex128) {
+	f.transform(dst, src, true)
+
+	scale := complex(1.0/float64(f.n), 0)
+	for i := range dst[:f.n] {
+		dst[i] *= scale
+	}
+}
+
+func (f *FFT) transform(dst []complex128, src []complex128, inverse bool) {
+	if f.n == 1 {
+		dst[0] = src[0]
+		return
+	}
+
+	in := src[:f.n]
+
+	// The recursion can not work in place, use scratch when input and output overlap
+	if &dst[0] == &src[0] {
+		copy(f.scratch, in)
+		in = f.scratch
+	}
+
+	f.work(dst[:f.n], in, 1, f.factors, inverse)
+}
+
+func (f *FFT) twiddle(index int, inverse bool) complex128 {
+	w := f.twiddles[index%f.n]
+	if inverse {
+		return cmplx.Conj(w)
+	}
+
+	return w
+}
+
+// work performs a decimation in time step for the first factor and recurses on the rest
+func (f *FFT) work(out []complex128, in []complex128, stride int, factors []int, inverse bool) {
+	p := factors[0]
+	m := len(out) / p
+
+	if m == 1 {
+		for q := 0; q < p; q++ {
+			out[q] = in[q*stride]
+		}
+	} else {
+		for q := 0; q < p; q++ {
+			f.work(out[q*m:(q+1)*m], in[q*stride:], stride*p, factors[1:], inverse)
+		}
+	}
+
+	switch p {
+	case 2:
+		f.butterfly2(out, stride, m, inverse)
+	case 4:
+		f.butterfly4(out, stride, m, inverse)
+	default:
+		f.butterflyGeneric(out, stride, p, m, inverse)
+	}
+}
+
+func (f *FFT) butterfly2(out []complex128, stride int, m int, inverse bool) {
+	for k := 0; k < m; k++ {
+		t := out[k+m] * f.twiddle(k*stride, inverse)
+		out[k+m] = out[k] - t
+		out[k] += t
+	}
+}
+
+func (f *FFT) butterfly4(out []complex128, stride int, m int, inverse bool) {
+	for k := 0; k < m; k++ {
+		a0 := out[k]
+		a1 := out[k+m] * f.twiddle(k*stride, inverse)
+		a2 := out[k+2*m] * f.twiddle(2*k*stride, inverse)
+		a3 := out[k+3*m] * f.twiddle(3*k*stride, inverse)
+
+		s0 := a0 + a2
+		s1 := a0 - a2
+		s2 := a1 + a3
+		s3 := a1 - a3
+
+		// multiply by -i for the forward and by i for the inverse transform
+		if inverse {
+			s3 = complex(-imag(s3), real(s3))
+		} else {
+			s3 = complex(imag(s3), -real(s3))
+		}
+
+		out[k] = s0 + s2
+		out[k+m] = s1 + s3
+		out[k+2*m] = s0 - s2
+		out[k+3*m] = s1 - s3
+	}
+}
+
+func (f *FFT) butterflyGeneric(out []complex128, stride int, p int, m int, inverse bool) {
+	tmp := f.butterfly[:p]
+	base := f.n / p
+
+	for k := 0; k < m; k++ {
+		for q := 0; q < p; q++ {
+			tmp[q] = out[k+q*m] * f.twiddle(q*k*stride, inverse)
+		}
+
+		for q2 := 0; q2 < p; q2++ {
+			var sum complex128
+
+			for q := 0; q < p; q++ {
+				sum += tmp[q] * f.twiddle(((q*q2)%p)*base, inverse)
+			}
+
+			out[k+q2*m] = sum
+		}
+	}
+}
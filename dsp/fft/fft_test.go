@@ -0,0 +1,123 @@
+package fft
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand/v2"
+	"testing"
+)
+
+var testSizes = []int{1, 2, 3, 4, 5, 6, 7, 8, 12, 16, 30, 49, 64, 77, 96, 100, 121, 128, 997, 1000, 1024}
+
+// dft is a direct discrete Fourier transform used as reference
+func dft(x []complex128) []complex128 {
+	n := len(x)
+	out := make([]complex128, n)
+
+	for k := range out {
+		for j := range x {
+			out[k] += x[j] * cmplx.Exp(complex(0, -2*math.Pi*float64(j*k)/float64(n)))
+		}
+	}
+
+	return out
+}
+
+func randomComplex(rng *rand.Rand, n int) []complex128 {
+	x := make([]complex128, n)
+	for i := range x {
+		x[i] = complex(rng.Float64()*2-1, rng.Float64()*2-1)
+	}
+
+	return x
+}
+
+func maxError(a []complex128, b []complex128) float64 {
+	var e float64
+	for i := range a {
+		e = max(e, cmplx.Abs(a[i]-b[i]))
+	}
+
+	return e
+}
+
+func TestForwardMatchesDFT(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for _, n := range testSizes {
+		x := randomComplex(rng, n)
+		want := dft(x)
+
+		f, err := New(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := make([]complex128, n)
+		f.Forward(got, x)
+
+		if e := maxError(got, want); e > 1e-9*float64(n) {
+			t.Errorf("size %d: forward error %g", n, e)
+		}
+
+		// In place
+		inPlace := append([]complex128(nil), x...)
+		f.Forward(inPlace, inPlace)
+
+		if e := maxError(inPlace, want); e > 1e-9*float64(n) {
+			t.Errorf("size %d: in place forward error %g", n, e)
+		}
+	}
+}
+
+func TestInverseRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+
+	for _, n := range testSizes {
+		x := randomComplex(rng, n)
+		f := MustNew(n)
+
+		spectrum := make([]complex128, n)
+		f.Forward(spectrum, x)
+
+		back := make([]complex128, n)
+		f.Inverse(back, spectrum)
+
+		if e := maxError(back, x); e > 1e-12*float64(n) {
+			t.Errorf("size %d: round trip error %g", n, e)
+		}
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	for _, n := range []int{0, -1} {
+		if _, err := New(n); err == nil {
+			t.Errorf("size %d: expected error", n)
+		}
+	}
+}
+
+func TestPowerOfTwo(t *testing.T) {
+	tests := []struct {
+		n       int
+		isPower bool
+		next    int
+	}{
+		{1, true, 1},
+		{2, true, 2},
+		{3, false, 4},
+		{1000, false, 1024},
+		{1024, true, 1024},
+		{1025, false, 2048},
+	}
+
+	for _, tt := range tests {
+		if got := IsPowerOfTwo(tt.n); got != tt.isPower {
+			t.Errorf("IsPowerOfTwo(%d) = %v", tt.n, got)
+		}
+
+		if got := NextPowerOfTwo(tt.n); got != tt.next {
+			t.Errorf("NextPowerOfTwo(%d) = %d, want %d", tt.n, got, tt.next)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package fft
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+
+	"github.com/almerlucke/sndfile/float"
+)
+
+// Real is a fast Fourier transform of real valued input. The forward transform produces the
+// Size()/2+1 non-negative frequency bins, the negative frequencies follow from conjugate symmetry.
+// Even sizes are computed with a complex transform of half the size.
+type Real[T float.Float] struct {
+	n        int
+	half     *FFT
+	full     *FFT
+	twiddles []complex128
+	buffer   []complex128
+	packed   []complex128
+}
+
+// NewReal creates a real FFT of size n
+func NewReal[T float.Float](n int) (*Real[T], error) {
+	if n < 1 {
+		return nil, errors.New("fft size should be at least 1")
+	}
+
+	r := &Real[T]{
+		n: n,
+	}
+
+	var err error
+
+	if n%2 == 0 {
+		r.half, err = New(n / 2)
+		if err != nil {
+			return nil, err
+		}
+
+		r.twiddles = make([]complex128, n/2)
+		for k := range r.twiddles {
+			phase := -2.0 * math.Pi * float64(k) / float64(n)
+			r.twiddles[k] = complex(math.Cos(phase), math.Sin(phase))
+		}
+
+		r.buffer = make([]complex128, n/2)
+		r.packed = make([]complex128, n/2)
+	} else {
+		r.full, err = New(n)
+		if err != nil {
+			return nil, err
+		}
+
+		r.buffer = make([]complex128, n)
+	}
+
+	return r, nil
+}
+
+// MustNewReal creates a real FFT of size n and panics on error
+func MustNewReal[T float.Float](n int) *Real[T] {
+	r, err := NewReal[T](n)
+	if err != nil {
+		panic(err)
+	}
+
+	return r
+}
+
+// Size returns the size of the transform
+func (r *Real[T]) Size() int {
+	return r.n
+}
+
+// NumBins returns the number of bins produced by Forward
+func (r *Real[T]) NumBins() int {
+	return r.n/2 + 1
+}
+
+// Forward transforms Size() real samples from src to NumBins() complex bins in dst
+func (r *Real[T]) Forward(dst []complex128, src []T) {
+	if r.full != nil {
+		for i := 0; i < r.n; i++ {
+			r.buffer[i] = complex(float64(src[i]), 0)
+		}
+
+		r.full.Forward(r.buffer, r.buffer)
+		copy(dst[:r.NumBins()], r.buffer)
+
+		return
+	}
+
+	h := r.n / 2
+
+	// Pack even samples in the real and odd samples in the imaginary part
+	for i := 0; i < h; i++ {
+		r.packed[i] = complex(float64(src[2*i]), float64(src[2*i+1]))
+	}
+
+	r.half.Forward(r.buffer, r.packed)
+
+	z := r.buffer
+	dst[0] = complex(real(z[0])+imag(z[0]), 0)
+	dst[h] = complex(real(z[0])-imag(z[0]), 0)
+
+	for k := 1; k < h; k++ {
+		zk := z[k]
+		zc := cmplx.Conj(z[h-k])
+		even := (zk + zc) * 0.5
+		odd := (zk - zc) * complex(0, -0.5)
+		dst[k] = even + r.twiddles[k]*odd
+	}
+}
+
+// Inverse transforms NumBins() complex bins from src to Size() real samples in dst.
+// The output is scaled so Inverse(Forward(x)) returns x.
+func (r *Real[T]) Inverse(dst []T, src []complex128) {
+	if r.full != nil {
+		bins := r.NumBins()
+		copy(r.buffer, src[:bins])
+
+		for k := bins; k < r.n; k++ {
+			r.buffer[k] = cmplx.Conj(src[r.n-k])
+		}
+
+		r.full.Inverse(r.buffer, r.buffer)
+
+		for i := 0; i < r.n; i++ {
+			dst[i] = T(real(r.buffer[i]))
+		}
+
+		return
+	}
+
+	h := r.n / 2
+
+	for k := 0; k < h; k++ {
+		xk := src[k]
+		xc := cmplx.Conj(src[h-k])
+		even := (xk + xc) * 0.5
+		odd := (xk - xc) * 0.5 * cmplx.Conj(r.twiddles[k])
+		r.packed[k] = even + complex(0, 1)*odd
+	}
+
+	r.half.Inverse(r.buffer, r.packed)
+
+	for i := 0; i < h; i++ {
+		dst[2*i] = T(real(r.buffer[i]))
+		dst[2*i+1] = T(imag(r.buffer[i]))
+	}
+}
@@ -0,0 +1,75 @@
+package fft
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestRealMatchesDFT(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+
+	for _, n := range testSizes {
+		src := make([]float64, n)
+		ref := make([]complex128, n)
+
+		for i := range src {
+			src[i] = rng.Float64()*2 - 1
+			ref[i] = complex(src[i], 0)
+		}
+
+		want := dft(ref)
+
+		r, err := NewReal[float64](n)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if r.NumBins() != n/2+1 {
+			t.Fatalf("size %d: %d bins", n, r.NumBins())
+		}
+
+		bins := make([]complex128, r.NumBins())
+		r.Forward(bins, src)
+
+		if e := maxError(bins, want[:len(bins)]); e > 1e-9*float64(n) {
+			t.Errorf("size %d: forward error %g", n, e)
+		}
+
+		back := make([]float64, n)
+		r.Inverse(back, bins)
+
+		for i := range back {
+			if math.Abs(back[i]-src[i]) > 1e-12*float64(n) {
+				t.Errorf("size %d: round trip error at %d", n, i)
+				break
+			}
+		}
+	}
+}
+
+func TestRealFloat32(t *testing.T) {
+	n := 256
+	src := make([]float32, n)
+
+	for i := range src {
+		src[i] = float32(math.Sin(2 * math.Pi * 8 * float64(i) / float64(n)))
+	}
+
+	r := MustNewReal[float32](n)
+	bins := make([]complex128, r.NumBins())
+	r.Forward(bins, src)
+
+	// A sine with 8 periods has all its energy in bin 8 with magnitude n/2
+	for b, c := range bins {
+		mag := math.Hypot(real(c), imag(c))
+		want := 0.0
+		if b == 8 {
+			want = float64(n) / 2
+		}
+
+		if math.Abs(mag-want) > 1e-3 {
+			t.Fatalf("bin %d magnitude %f, want %f", b, mag, want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package fft
+
+import (
+	"errors"
+
+	"github.com/almerlucke/sndfile/dsp/windows"
+	"github.com/almerlucke/sndfile/float"
+)
+
+// STFT computes the short-time Fourier transform of a signal and its inverse. Frames of
+// FrameSize samples are taken every HopSize samples, multiplied with the window and zero
+// padded to FFTSize. Frame i is centered on sample i*HopSize.
+type STFT[T float.Float] struct {
+	frameSize int
+	hopSize   int
+	fftSize   int
+	window    []float64
+	fft       *Real[T]
+	frame     []T
+}
+
+// NewSTFT creates an STFT with an FFT size equal to the frame size
+func NewSTFT[T float.Float](frameSize int, hopSize int, window windows.Function) (*STFT[T], error) {
+	return NewSTFTWithSize[T](frameSize, hopSize, frameSize, window)
+}
+
+// NewSTFTWithSize creates an STFT with frames zero padded to fftSize
+func NewSTFTWithSize[T float.Float](frameSize int, hopSize int, fftSize int, window windows.Function) (*STFT[T], error) {
+	if frameSize < 1 || hopSize < 1 {
+		return nil, errors.New("frame size and hop size should be at least 1")
+	}
+
+	if fftSize < frameSize {
+		return nil, errors.New("fft size should not be smaller than the frame size")
+	}
+
+	rfft, err := NewReal[T](fftSize)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &STFT[T]{
+		frameSize: frameSize,
+		hopSize:   hopSize,
+		fftSize:   fftSize,
+		fft:       rfft,
+		frame:     make([]T, fftSize),
+	}
+
+	if window != nil {
+		s.window = window(frameSize)
+	} else {
+		s.window = make([]float64, frameSize)
+		for i := range s.window {
+			s.window[i] = 1.0
+		}
+	}
+
+	return s, nil
+}
+
+// FrameSize returns the number of samples in a frame
+func (s *STFT[T]) FrameSize() int {
+	return s.frameSize
+}
+
+// HopSize returns the number of samples between frames
+func (s *STFT[T]) HopSize() int {
+	return s.hopSize
+}
+
+// FFTSize returns the size of the FFT
+func (s *STFT[T]) FFTSize() int {
+	return s.fftSize
+}
+
+// NumBins returns the number of frequency bins per frame
+func (s *STFT[T]) NumBins() int {
+	return s.fft.NumBins()
+}
+
+// Window returns the analysis and synthesis window
+func (s *STFT[T]) Window() []float64 {
+	return s.window
+}
+
+// NumFrames returns the number of frames Forward produces for an input of length n
+func (s *STFT[T]) NumFrames(n int) int {
+	return n/s.hopSize + 1
+}
+
+// AnalyzeFrame windows and transforms exactly FrameSize samples to NumBins bins in dst
+func (s *STFT[T]) AnalyzeFrame(dst []complex128, frame []T) {
+	clear(s.frame)
+
+	for i, w := range s.window {
+		s.frame[i] = T(float64(frame[i]) * w)
+	}
+
+	s.fft.Forward(dst, s.frame)
+}
+
+// SynthesizeFrame transforms NumBins bins back and applies the synthesis window to the
+// first FrameSize samples which are written to dst
+func (s *STFT[T]) SynthesizeFrame(dst []T, bins []complex128) {
+	s.fft.Inverse(s.frame, bins)
+
+	for i, w := range s.window {
+		dst[i] = T(float64(s.frame[i]) * w)
+	}
+}
+
+// Forward computes all frames of input
+func (s *STFT[T]) Forward(input []T) [][]complex128 {
+	numFrames := s.NumFrames(len(input))
+	frames := make([][]complex128, numFrames)
+	frame := make([]T, s.frameSize)
+	offset := s.frameSize / 2
+
+	for f := range frames {
+		start := f*s.hopSize - offset
+
+		clear(frame)
+
+		for i := range frame {
+			j := start + i
+			if j >= 0 && j < len(input) {
+				frame[i] = input[j]
+			}
+		}
+
+		frames[f] = make([]complex128, s.NumBins())
+		s.AnalyzeFrame(frames[f], frame)
+	}
+
+	return frames
+}
+
+// Inverse reconstructs a signal of the given length from frames with weighted overlap-add.
+// Frames produced by Forward are reconstructed exactly as long as the windows overlap.
+func (s *STFT[T]) Inverse(frames [][]complex128, length int) []T {
+	output := make([]T, length)
+	norm := make([]float64, length)
+	frame := make([]T, s.frameSize)
+	offset := s.frameSize / 2
+
+	for f, bins := range frames {
+		s.SynthesizeFrame(frame, bins)
+
+		start := f*s.hopSize - offset
+
+		for i, w := range s.window {
+			j := start + i
+			if j >= 0 && j < length {
+				output[j] += frame[i]
+				norm[j] += w * w
+			}
+		}
+	}
+
+	for i := range output {
+		if norm[i] > 1e-10 {
+			output[i] = T(float64(output[i]) / norm[i])
+		}
+	}
+
+	return output
+}
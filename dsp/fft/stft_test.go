@@ -0,0 +1,74 @@
+package fft
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/almerlucke/sndfile/dsp/windows"
+)
+
+func TestSTFTRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		frameSize int
+		hopSize   int
+		fftSize   int
+		window    windows.Function
+	}{
+		{"hamming quarter hop", 256, 64, 256, windows.Hamming},
+		{"hann half hop", 512, 256, 512, windows.Hann},
+		{"rectangular", 128, 128, 128, nil},
+		{"zero padded", 200, 50, 512, windows.Hann},
+		{"odd size", 255, 60, 255, windows.Blackman},
+	}
+
+	rng := rand.New(rand.NewPCG(7, 8))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSTFTWithSize[float64](tt.frameSize, tt.hopSize, tt.fftSize, tt.window)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			x := make([]float64, 3000)
+			for i := range x {
+				x[i] = rng.Float64()*2 - 1
+			}
+
+			frames := s.Forward(x)
+			if len(frames) != s.NumFrames(len(x)) {
+				t.Fatalf("%d frames, want %d", len(frames), s.NumFrames(len(x)))
+			}
+
+			y := s.Inverse(frames, len(x))
+			if len(y) != len(x) {
+				t.Fatalf("length %d, want %d", len(y), len(x))
+			}
+
+			for i := range x {
+				if math.Abs(x[i]-y[i]) > 1e-9 {
+					t.Fatalf("sample %d: %f, want %f", i, y[i], x[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSTFTErrors(t *testing.T) {
+	tests := []struct {
+		name                        string
+		frameSize, hopSize, fftSize int
+	}{
+		{"zero frame size", 0, 1, 0},
+		{"zero hop size", 16, 0, 16},
+		{"fft smaller than frame", 16, 4, 8},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewSTFTWithSize[float64](tt.frameSize, tt.hopSize, tt.fftSize, nil); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
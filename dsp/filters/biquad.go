@@ -0,0 +1,280 @@
+package filters
+
+import (
+	"errors"
+	"math"
+
+	"github.com/almerlucke/sndfile/float"
+)
+
+// BiquadType is the response type of a biquad section
+type BiquadType int
+
+const (
+	BiquadLowPass BiquadType = iota
+	BiquadHighPass
+	// BiquadBandPass has a constant 0dB peak gain
+	BiquadBandPass
+	BiquadNotch
+	BiquadPeak
+	BiquadLowShelf
+	BiquadHighShelf
+	BiquadAllPass
+)
+
+// BiquadCoefs holds the coefficients of a second order section normalized so a0 is 1
+// H(z) = (B0 + B1*z^-1 + B2*z^-2) / (1 + A1*z^-1 + A2*z^-2)
+type BiquadCoefs struct {
+	B0, B1, B2 float64
+	A1, A2     float64
+}
+
+// NewBiquadCoefs calculates biquad coefficients following the RBJ audio EQ cookbook.
+// gainDB is only used by the peak and shelf types.
+// https://www.w3.org/TR/audio-eq-cookbook/
+func NewBiquadCoefs(typ BiquadType, freq float64, sampleRate float64, q float64, gainDB float64) BiquadCoefs {
+	a := math.Pow(10.0, gainDB/40.0)
+	w0 := 2.0 * math.Pi * freq / sampleRate
+	cosW0 := math.Cos(w0)
+	alpha := math.Sin(w0) / (2.0 * q)
+
+	var b0, b1, b2, a0, a1, a2 float64
+
+	switch typ {
+	case BiquadLowPass:
+		b0 = (1.0 - cosW0) / 2.0
+		b1 = 1.0 - cosW0
+		b2 = (1.0 - cosW0) / 2.0
+		a0 = 1.0 + alpha
+		a1 = -2.0 * cosW0
+		a2 = 1.0 - alpha
+	case BiquadHighPass:
+		b0 = (1.0 + cosW0) / 2.0
+		b1 = -(1.0 + cosW0)
+		b2 = (1.0 + cosW0) / 2.0
+		a0 = 1.0 + alpha
+		a1 = -2.0 * cosW0
+		a2 = 1.0 - alpha
+	case BiquadBandPass:
+		b0 = alpha
+		b1 = 0.0
+		b2 = -alpha
+		a0 = 1.0 + alpha
+		a1 = -2.0 * cosW0
+		a2 = 1.0 - alpha
+	case BiquadNotch:
+		b0 = 1.0
+		b1 = -2.0 * cosW0
+		b2 = 1.0
+		a0 = 1.0 + alpha
+		a1 = -2.0 * cosW0
+		a2 = 1.0 - alpha
+	case BiquadPeak:
+		b0 = 1.0 + alpha*a
+		b1 = -2.0 * cosW0
+		b2 = 1.0 - alpha*a
+		a0 = 1.0 + alpha/a
+		a1 = -2.0 * cosW0
+		a2 = 1.0 - alpha/a
+	case BiquadLowShelf:
+		sq := 2.0 * math.Sqrt(a) * alpha
+		b0 = a * ((a + 1.0) - (a-1.0)*cosW0 + sq)
+		b1 = 2.0 * a * ((a - 1.0) - (a+1.0)*cosW0)
+		b2 = a * ((a + 1.0) - (a-1.0)*cosW0 - sq)
+		a0 = (a + 1.0) + (a-1.0)*cosW0 + sq
+		a1 = -2.0 * ((a - 1.0) + (a+1.0)*cosW0)
+		a2 = (a + 1.0) + (a-1.0)*cosW0 - sq
+	case BiquadHighShelf:
+		sq := 2.0 * math.Sqrt(a) * alpha
+		b0 = a * ((a + 1.0) + (a-1.0)*cosW0 + sq)
+		b1 = -2.0 * a * ((a - 1.0) + (a+1.0)*cosW0)
+		b2 = a * ((a + 1.0) + (a-1.0)*cosW0 - sq)
+		a0 = (a + 1.0) - (a-1.0)*cosW0 + sq
+		a1 = 2.0 * ((a - 1.0) - (a+1.0)*cosW0)
+		a2 = (a + 1.0) - (a-1.0)*cosW0 - sq
+	case BiquadAllPass:
+		b0 = 1.0 - alpha
+		b1 = -2.0 * cosW0
+		b2 = 1.0 + alpha
+		a0 = 1.0 + alpha
+		a1 = -2.0 * cosW0
+		a2 = 1.0 - alpha
+	}
+
+	return BiquadCoefs{
+		B0: b0 / a0,
+		B1: b1 / a0,
+		B2: b2 / a0,
+		A1: a1 / a0,
+		A2: a2 / a0,
+	}
+}
+
+// firstOrderCoefs returns a bilinear transformed first order low or high pass as a biquad section
+func firstOrderCoefs(highPass bool, freq float64, sampleRate float64) BiquadCoefs {
+	k := math.Tan(math.Pi * freq / sampleRate)
+	a1 := (k - 1.0) / (k + 1.0)
+
+	if highPass {
+		b0 := 1.0 / (k + 1.0)
+		return BiquadCoefs{B0: b0, B1: -b0, A1: a1}
+	}
+
+	b0 := k / (k + 1.0)
+
+	return BiquadCoefs{B0: b0, B1: b0, A1: a1}
+}
+
+func butterworth(highPass bool, order int, freq float64, sampleRate float64) []BiquadCoefs {
+	typ := BiquadLowPass
+	if highPass {
+		typ = BiquadHighPass
+	}
+
+	sections := make([]BiquadCoefs, 0, (order+1)/2)
+
+	for k := 0; k < order/2; k++ {
+		q := 1.0 / (2.0 * math.Sin(math.Pi*float64(2*k+1)/float64(2*order)))
+		sections = append(sections, NewBiquadCoefs(typ, freq, sampleRate, q, 0))
+	}
+
+	if order%2 == 1 {
+		sections = append(sections, firstOrderCoefs(highPass, freq, sampleRate))
+	}
+
+	return sections
+}
+
+// ButterworthLowPass designs a Butterworth low pass of the given order as cascaded sections
+func ButterworthLowPass(order int, freq float64, sampleRate float64) []BiquadCoefs {
+	return butterworth(false, order, freq, sampleRate)
+}
+
+// ButterworthHighPass designs a Butterworth high pass of the given order as cascaded sections
+func ButterworthHighPass(order int, freq float64, sampleRate float64) []BiquadCoefs {
+	return butterworth(true, order, freq, sampleRate)
+}
+
+func linkwitzRiley(highPass bool, order int, freq float64, sampleRate float64) ([]BiquadCoefs, error) {
+	if order < 2 || order%2 != 0 {
+		return nil, errors.New("linkwitz-riley order should be even")
+	}
+
+	sections := butterworth(highPass, order/2, freq, sampleRate)
+
+	return append(sections, sections...), nil
+}
+
+// LinkwitzRileyLowPass designs a Linkwitz-Riley low pass of an even order as two cascaded Butterworth filters
+func LinkwitzRileyLowPass(order int, freq float64, sampleRate float64) ([]BiquadCoefs, error) {
+	return linkwitzRiley(false, order, freq, sampleRate)
+}
+
+// LinkwitzRileyHighPass designs a Linkwitz-Riley high pass of an even order as two cascaded Butterworth filters
+func LinkwitzRileyHighPass(order int, freq float64, sampleRate float64) ([]BiquadCoefs, error) {
+	return linkwitzRiley(true, order, freq, sampleRate)
+}
+
+// biquadState is the transposed direct form II state of a section
+type biquadState struct {
+	z1, z2 float64
+}
+
+// IIRProcessor is a stateful cascade of biquad sections for block based processing
+type IIRProcessor[T float.Float] struct {
+	sections    []BiquadCoefs
+	numChannels int
+	states      [][]biquadState
+}
+
+// NewIIRProcessor creates a streaming processor for a cascade of sections and numChannels channels
+func NewIIRProcessor[T float.Float](sections []BiquadCoefs, numChannels int) (*IIRProcessor[T], error) {
+	if len(sections) == 0 {
+		return nil, errors.New("sections should not be empty")
+	}
+
+	if numChannels < 1 {
+		return nil, errors.New("number of channels should be at least 1")
+	}
+
+	p := &IIRProcessor[T]{
+		sections:    append([]BiquadCoefs(nil), sections...),
+		numChannels: numChannels,
+		states:      make([][]biquadState, numChannels),
+	}
+
+	for c := range p.states {
+		p.states[c] = make([]biquadState, len(sections))
+	}
+
+	return p, nil
+}
+
+// NewBiquadProcessor creates a streaming processor for a single RBJ biquad
+func NewBiquadProcessor[T float.Float](typ BiquadType, freq float64, sampleRate float64, q float64, gainDB float64, numChannels int) (*IIRProcessor[T], error) {
+	return NewIIRProcessor[T]([]BiquadCoefs{NewBiquadCoefs(typ, freq, sampleRate, q, gainDB)}, numChannels)
+}
+
+// NumChannels returns the number of channels
+func (p *IIRProcessor[T]) NumChannels() int {
+	return p.numChannels
+}
+
+// Sections returns the biquad sections
+func (p *IIRProcessor[T]) Sections() []BiquadCoefs {
+	return p.sections
+}
+
+// SetSection replaces the coefficients of a section while keeping its state, so filters can be
+// changed while streaming
+func (p *IIRProcessor[T]) SetSection(index int, coefs BiquadCoefs) {
+	p.sections[index] = coefs
+}
+
+// Reset clears the filter state
+func (p *IIRProcessor[T]) Reset() {
+	for c := range p.states {
+		clear(p.states[c])
+	}
+}
+
+func (p *IIRProcessor[T]) tick(channel int, x float64) float64 {
+	states := p.states[channel]
+
+	for i := range p.sections {
+		s := &p.sections[i]
+		st := &states[i]
+		y := s.B0*x + st.z1
+		st.z1 = s.B1*x - s.A1*y + st.z2
+		st.z2 = s.B2*x - s.A2*y
+		x = y
+	}
+
+	return x
+}
+
+// ProcessChannel filters a block of a single channel in place
+func (p *IIRProcessor[T]) ProcessChannel(channel int, block []T) {
+	for i, x := range block {
+		block[i] = T(p.tick(channel, float64(x)))
+	}
+}
+
+// Process filters a block of deinterleaved channels in place
+func (p *IIRProcessor[T]) Process(channels [][]T) {
+	for c, block := range channels[:p.numChannels] {
+		p.ProcessChannel(c, block)
+	}
+}
+
+// ProcessInterleaved filters a block of interleaved frames in place
+func (p *IIRProcessor[T]) ProcessInterleaved(block []T) {
+	numFrames := len(block) / p.numChannels
+
+	for i := 0; i < numFrames; i++ {
+		for c := 0; c < p.numChannels; c++ {
+			idx := i*p.numChannels + c
+			block[idx] = T(p.tick(c, float64(block[idx])))
+		}
+	}
+}
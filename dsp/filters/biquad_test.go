@@ -0,0 +1,225 @@
+package filters
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// sectionsGainDB evaluates the magnitude response of cascaded sections at freq
+func sectionsGainDB(sections []BiquadCoefs, freq float64, sampleRate float64) float64 {
+	z := cmplx.Exp(complex(0, -2*math.Pi*freq/sampleRate))
+	h := complex(1, 0)
+
+	for _, s := range sections {
+		h *= (complex(s.B0, 0) + complex(s.B1, 0)*z + complex(s.B2, 0)*z*z) /
+			(1 + complex(s.A1, 0)*z + complex(s.A2, 0)*z*z)
+	}
+
+	return 20 * math.Log10(cmplx.Abs(h))
+}
+
+// sineGainDB filters a sine and returns the steady state gain in dB
+func sineGainDB(p *IIRProcessor[float64], freq float64, sampleRate float64) float64 {
+	n := int(sampleRate)
+	buf := make([]float64, n)
+
+	for i := range buf {
+		buf[i] = math.Sin(2 * math.Pi * freq * float64(i) / sampleRate)
+	}
+
+	p.Reset()
+	p.ProcessChannel(0, buf)
+
+	var peak float64
+	for _, v := range buf[n/2:] {
+		peak = max(peak, math.Abs(v))
+	}
+
+	return 20 * math.Log10(peak)
+}
+
+func TestBiquadGains(t *testing.T) {
+	sampleRate := 48000.0
+
+	tests := []struct {
+		name   string
+		typ    BiquadType
+		freq   float64
+		q      float64
+		gainDB float64
+		at     float64
+		want   float64
+	}{
+		{"low pass passband", BiquadLowPass, 1000, math.Sqrt2 / 2, 0, 50, 0},
+		{"low pass cutoff", BiquadLowPass, 1000, math.Sqrt2 / 2, 0, 1000, -3.01},
+		{"high pass cutoff", BiquadHighPass, 1000, math.Sqrt2 / 2, 0, 1000, -3.01},
+		{"high pass passband", BiquadHighPass, 1000, math.Sqrt2 / 2, 0, 20000, 0},
+		{"band pass center", BiquadBandPass, 1000, 2, 0, 1000, 0},
+		{"notch far", BiquadNotch, 1000, 2, 0, 100, 0},
+		{"peak center", BiquadPeak, 1000, 1, 6, 1000, 6},
+		{"peak cut center", BiquadPeak, 1000, 1, -12, 1000, -12},
+		{"low shelf", BiquadLowShelf, 1000, math.Sqrt2 / 2, 6, 10, 6},
+		{"high shelf", BiquadHighShelf, 1000, math.Sqrt2 / 2, 6, 20000, 6},
+		{"all pass", BiquadAllPass, 1000, 1, 0, 3000, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coefs := NewBiquadCoefs(tt.typ, tt.freq, sampleRate, tt.q, tt.gainDB)
+
+			if got := sectionsGainDB([]BiquadCoefs{coefs}, tt.at, sampleRate); math.Abs(got-tt.want) > 0.05 {
+				t.Fatalf("response %.3f dB, want %.3f", got, tt.want)
+			}
+
+			p, err := NewBiquadProcessor[float64](tt.typ, tt.freq, sampleRate, tt.q, tt.gainDB, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := sineGainDB(p, tt.at, sampleRate); math.Abs(got-tt.want) > 0.1 {
+				t.Fatalf("filtered sine %.3f dB, want %.3f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotchRejects(t *testing.T) {
+	coefs := NewBiquadCoefs(BiquadNotch, 1000, 48000, 2, 0)
+
+	if got := sectionsGainDB([]BiquadCoefs{coefs}, 1000, 48000); got > -60 {
+		t.Fatalf("notch center %.2f dB", got)
+	}
+}
+
+func TestButterworth(t *testing.T) {
+	sampleRate := 48000.0
+
+	for _, order := range []int{1, 2, 3, 4, 5, 8} {
+		lp := ButterworthLowPass(order, 1000, sampleRate)
+		hp := ButterworthHighPass(order, 1000, sampleRate)
+
+		if len(lp) != (order+1)/2 {
+			t.Fatalf("order %d: %d sections", order, len(lp))
+		}
+
+		if got := sectionsGainDB(lp, 1000, sampleRate); math.Abs(got+3.01) > 0.02 {
+			t.Errorf("order %d low pass cutoff %.3f dB", order, got)
+		}
+
+		if got := sectionsGainDB(hp, 1000, sampleRate); math.Abs(got+3.01) > 0.02 {
+			t.Errorf("order %d high pass cutoff %.3f dB", order, got)
+		}
+
+		// Roll off of 6 dB per octave per order, measured two decades out
+		if got := sectionsGainDB(lp, 10000, sampleRate); got > -20*float64(order)+3 {
+			t.Errorf("order %d low pass stopband %.2f dB", order, got)
+		}
+	}
+}
+
+func TestLinkwitzRiley(t *testing.T) {
+	sampleRate := 48000.0
+
+	for _, order := range []int{2, 4, 8} {
+		lp, err := LinkwitzRileyLowPass(order, 1000, sampleRate)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hp, err := LinkwitzRileyHighPass(order, 1000, sampleRate)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := sectionsGainDB(lp, 1000, sampleRate); math.Abs(got+6.02) > 0.02 {
+			t.Errorf("order %d low pass crossover %.3f dB", order, got)
+		}
+
+		if got := sectionsGainDB(hp, 1000, sampleRate); math.Abs(got+6.02) > 0.02 {
+			t.Errorf("order %d high pass crossover %.3f dB", order, got)
+		}
+	}
+
+	if _, err := LinkwitzRileyLowPass(3, 1000, sampleRate); err == nil {
+		t.Fatal("expected error for odd order")
+	}
+}
+
+func TestIIRProcessorInterleaved(t *testing.T) {
+	sections := ButterworthLowPass(4, 2000, 48000)
+
+	p, err := NewIIRProcessor[float64](sections, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x := make([]float64, 500)
+	for i := range x {
+		x[i] = math.Sin(float64(i) * 0.3)
+	}
+
+	mono := append([]float64(nil), x...)
+	p.ProcessChannel(0, mono)
+
+	p.Reset()
+
+	inter := make([]float64, 2*len(x))
+	for i, v := range x {
+		inter[2*i] = v
+		inter[2*i+1] = -v
+	}
+
+	for s := 0; s < len(inter); s += 34 {
+		p.ProcessInterleaved(inter[s:min(s+34, len(inter))])
+	}
+
+	for i := range mono {
+		if math.Abs(inter[2*i]-mono[i]) > 1e-12 || math.Abs(inter[2*i+1]+mono[i]) > 1e-12 {
+			t.Fatalf("sample %d differs", i)
+		}
+	}
+
+	if _, err := NewIIRProcessor[float64](sections, 0); err == nil {
+		t.Fatal("expected error for zero channels")
+	}
+}
+
+func TestParametricEQ(t *testing.T) {
+	sampleRate := 48000.0
+	bands := []EQBand{
+		{Type: BiquadLowShelf, Frequency: 100, Q: math.Sqrt2 / 2, GainDB: 6},
+		{Type: BiquadPeak, Frequency: 1000, Q: 4, GainDB: -6},
+		{Type: BiquadHighShelf, Frequency: 10000, Q: math.Sqrt2 / 2, GainDB: 3},
+	}
+
+	eq, err := NewParametricEQ[float64](bands, sampleRate, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if eq.NumBands() != 3 || eq.Band(1) != bands[1] {
+		t.Fatal("bands not stored")
+	}
+
+	tests := []struct {
+		freq float64
+		want float64
+	}{
+		{20, 6},
+		{1000, -6},
+		{22000, 3},
+	}
+
+	for _, tt := range tests {
+		if got := sineGainDB(eq.IIRProcessor, tt.freq, sampleRate); math.Abs(got-tt.want) > 0.3 {
+			t.Errorf("%.0f Hz: %.2f dB, want %.2f", tt.freq, got, tt.want)
+		}
+	}
+
+	eq.SetBand(1, EQBand{Type: BiquadPeak, Frequency: 1000, Q: 4, GainDB: 0})
+
+	if got := sineGainDB(eq.IIRProcessor, 1000, sampleRate); math.Abs(got) > 0.3 {
+		t.Fatalf("flattened band: %.2f dB", got)
+	}
+}
@@ -0,0 +1,153 @@
+package filters
+
+import (
+	"math"
+
+	"github.com/almerlucke/sndfile/dsp/fft"
+	"github.com/almerlucke/sndfile/float"
+)
+
+// ConvolutionMode determines which part of the full convolution is returned
+type ConvolutionMode int
+
+const (
+	// Same returns the first len(input) samples of the full convolution, like a causal filter would
+	Same ConvolutionMode = iota
+	// Full returns all len(input)+len(kernel)-1 samples
+	Full
+	// Centered returns len(input) samples compensated for the delay of a linear phase kernel
+	Centered
+)
+
+// ConvolutionMethod selects the convolution algorithm
+type ConvolutionMethod int
+
+const (
+	// Auto picks direct or FFT convolution based on the estimated cost
+	Auto ConvolutionMethod = iota
+	Direct
+	FFT
+)
+
+// directKernelLimit is the kernel length below which direct convolution always wins
+const directKernelLimit = 32
+
+// convolutionRange returns the offset in and length of the full convolution for mode
+func convolutionRange(mode ConvolutionMode, inputLen int, kernelLen int) (int, int) {
+	switch mode {
+	case Full:
+		return 0, inputLen + kernelLen - 1
+	case Centered:
+		return (kernelLen - 1) / 2, inputLen
+	default:
+		return 0, inputLen
+	}
+}
+
+// fftConvolutionSize returns the best FFT size for overlap-add and its estimated cost
+func fftConvolutionSize(inputLen int, kernelLen int) (int, float64) {
+	bestSize := 0
+	bestCost := math.MaxFloat64
+
+	for size := fft.NextPowerOfTwo(2 * kernelLen); size <= max(fft.NextPowerOfTwo(8*kernelLen), 64); size *= 2 {
+		blockLen := size - kernelLen + 1
+		numBlocks := (inputLen + blockLen - 1) / blockLen
+		logSize := math.Log2(float64(size))
+		// forward and inverse real transform plus the spectral multiply
+		cost := float64(numBlocks) * (float64(size)*logSize*2.5 + float64(size)*2)
+
+		if cost < bestCost {
+			bestCost = cost
+			bestSize = size
+		}
+
+		// No need to try bigger blocks than the input
+		if blockLen >= inputLen {
+			break
+		}
+	}
+
+	return bestSize, bestCost
+}
+
+func chooseMethod(inputLen int, kernelLen int) ConvolutionMethod {
+	if kernelLen < directKernelLimit || inputLen < directKernelLimit {
+		return Direct
+	}
+
+	_, fftCost := fftConvolutionSize(inputLen, kernelLen)
+	directCost := float64(inputLen) * float64(kernelLen)
+
+	if fftCost < directCost {
+		return FFT
+	}
+
+	return Direct
+}
+
+// convolveDirect computes output[i] = full[offset+i] in the time domain
+func convolveDirect[T float.Float](output []T, input []T, kernel []float64, offset int) {
+	for i := range output {
+		n := offset + i
+		jStart := max(0, n-len(input)+1)
+		jEnd := min(len(kernel)-1, n)
+
+		var sum float64
+
+		for j := jStart; j <= jEnd; j++ {
+			sum += float64(input[n-j]) * kernel[j]
+		}
+
+		output[i] = T(sum)
+	}
+}
+
+// convolveFFT computes output[i] = full[offset+i] with overlap-add FFT convolution
+func convolveFFT[T float.Float](output []T, input []T, kernel []float64, offset int) {
+	size, _ := fftConvolutionSize(len(input), len(kernel))
+	blockLen := size - len(kernel) + 1
+
+	rfft := fft.MustNewReal[float64](size)
+	bins := rfft.NumBins()
+
+	block := make([]float64, size)
+	copy(block, kernel)
+
+	kernelSpectrum := make([]complex128, bins)
+	rfft.Forward(kernelSpectrum, block)
+
+	spectrum := make([]complex128, bins)
+	full := make([]float64, len(output))
+	outEnd := offset + len(output)
+
+	for start := 0; start < len(input) && start < outEnd; start += blockLen {
+		clear(block)
+
+		end := min(start+blockLen, len(input))
+		for i := start; i < end; i++ {
+			block[i-start] = float64(input[i])
+		}
+
+		rfft.Forward(spectrum, block)
+
+		for k := range spectrum {
+			spectrum[k] *= kernelSpectrum[k]
+		}
+
+		rfft.Inverse(block, spectrum)
+
+		for i, v := range block {
+			j := start + i - offset
+			if j >= len(full) {
+				break
+			}
+			if j >= 0 {
+				full[j] += v
+			}
+		}
+	}
+
+	for i, v := range full {
+		output[i] = T(v)
+	}
+}
@@ -0,0 +1,101 @@
+package filters
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// fullConvolution is a direct full convolution used as reference
+func fullConvolution(x []float64, k []float64) []float64 {
+	out := make([]float64, len(x)+len(k)-1)
+
+	for i, xv := range x {
+		for j, kv := range k {
+			out[i+j] += xv * kv
+		}
+	}
+
+	return out
+}
+
+func randomSignal(rng *rand.Rand, n int) []float64 {
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = rng.Float64()*2 - 1
+	}
+
+	return x
+}
+
+func TestConvolveMatchesReference(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 1))
+
+	modes := []struct {
+		name  string
+		mode  ConvolutionMode
+		slice func(full []float64, inputLen int, kernelLen int) []float64
+	}{
+		{"same", Same, func(full []float64, n int, _ int) []float64 { return full[:n] }},
+		{"full", Full, func(full []float64, _ int, _ int) []float64 { return full }},
+		{"centered", Centered, func(full []float64, n int, m int) []float64 { return full[(m-1)/2 : (m-1)/2+n] }},
+	}
+
+	methods := []struct {
+		name   string
+		method ConvolutionMethod
+	}{
+		{"auto", Auto},
+		{"direct", Direct},
+		{"fft", FFT},
+	}
+
+	for _, inputLen := range []int{1, 5, 40, 1000, 5000} {
+		for _, kernelLen := range []int{1, 3, 33, 201, 700} {
+			x := randomSignal(rng, inputLen)
+			k := randomSignal(rng, kernelLen)
+			full := fullConvolution(x, k)
+
+			for _, mode := range modes {
+				want := mode.slice(full, inputLen, kernelLen)
+
+				for _, method := range methods {
+					got, err := (&FIR[float64]{Method: method.method}).ConvolveWithMode(x, k, mode.mode)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					if len(got) != len(want) {
+						t.Fatalf("input %d kernel %d %s %s: length %d, want %d",
+							inputLen, kernelLen, mode.name, method.name, len(got), len(want))
+					}
+
+					for i := range want {
+						if math.Abs(got[i]-want[i]) > 1e-9 {
+							t.Fatalf("input %d kernel %d %s %s: sample %d is %f, want %f",
+								inputLen, kernelLen, mode.name, method.name, i, got[i], want[i])
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestConvolveEdgeCases(t *testing.T) {
+	fir := &FIR[float64]{}
+
+	if _, err := fir.Convolve([]float64{1, 2}, nil); err == nil {
+		t.Fatal("expected error for empty kernel")
+	}
+
+	out, err := fir.Convolve(nil, []float64{1})
+	if err != nil || len(out) != 0 {
+		t.Fatalf("empty input gave %v, %v", out, err)
+	}
+
+	var nilFIR *FIR[float64]
+	if out, err := nilFIR.Convolve([]float64{1}, []float64{1}); out != nil || err != nil {
+		t.Fatal("nil FIR should return nil")
+	}
+}
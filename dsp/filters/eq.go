@@ -0,0 +1,66 @@
+package filters
+
+import (
+	"errors"
+
+	"github.com/almerlucke/sndfile/float"
+)
+
+// EQBand describes one band of a parametric EQ
+type EQBand struct {
+	Type      BiquadType
+	Frequency float64
+	Q         float64
+	GainDB    float64
+}
+
+// Coefs returns the biquad coefficients of the band
+func (b EQBand) Coefs(sampleRate float64) BiquadCoefs {
+	return NewBiquadCoefs(b.Type, b.Frequency, sampleRate, b.Q, b.GainDB)
+}
+
+// ParametricEQ is a multi-band EQ built from a cascade of biquads, one per band
+type ParametricEQ[T float.Float] struct {
+	*IIRProcessor[T]
+	bands      []EQBand
+	sampleRate float64
+}
+
+// NewParametricEQ creates a parametric EQ for numChannels channels
+func NewParametricEQ[T float.Float](bands []EQBand, sampleRate float64, numChannels int) (*ParametricEQ[T], error) {
+	if len(bands) == 0 {
+		return nil, errors.New("eq should have at least one band")
+	}
+
+	sections := make([]BiquadCoefs, len(bands))
+	for i, band := range bands {
+		sections[i] = band.Coefs(sampleRate)
+	}
+
+	p, err := NewIIRProcessor[T](sections, numChannels)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ParametricEQ[T]{
+		IIRProcessor: p,
+		bands:        append([]EQBand(nil), bands...),
+		sampleRate:   sampleRate,
+	}, nil
+}
+
+// NumBands returns the number of bands
+func (eq *ParametricEQ[T]) NumBands() int {
+	return len(eq.bands)
+}
+
+// Band returns the settings of a band
+func (eq *ParametricEQ[T]) Band(index int) EQBand {
+	return eq.bands[index]
+}
+
+// SetBand changes the settings of a band without resetting the filter state
+func (eq *ParametricEQ[T]) SetBand(index int, band EQBand) {
+	eq.bands[index] = band
+	eq.SetSection(index, band.Coefs(eq.sampleRate))
+}
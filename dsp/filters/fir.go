@@ -9,7 +9,8 @@ import (
 // FIR represents a Finite Impulse Response filter taking a sinc.
 // https://en.wikipedia.org/wiki/Finite_impulse_response
 type FIR[T float.Float] struct {
-	Sinc *Sinc
+	Sinc   *Sinc
+	Method ConvolutionMethod
 }
 
 // LowPass applies a low pass filter using the FIR
@@ -21,38 +22,51 @@ func (f *FIR[T]) HighPass(input []T) ([]T, error) {
 	return f.Convolve(input, f.Sinc.HighPassCoefs())
 }
 
+// BandPass applies a band pass filter using the FIR
+func (f *FIR[T]) BandPass(input []T) ([]T, error) {
+	return f.Convolve(input, f.Sinc.BandPassCoefs())
+}
+
+// BandStop applies a band stop filter using the FIR
+func (f *FIR[T]) BandStop(input []T) ([]T, error) {
+	return f.Convolve(input, f.Sinc.BandStopCoefs())
+}
+
 // Convolve "mixes" two signals together
 // kernels is the imput that is not part of our signal, it might be shorter
-// than the origin signal.
+// than the origin signal. The output has the same length as the input.
 func (f *FIR[T]) Convolve(input []T, kernels []float64) ([]T, error) {
+	return f.ConvolveWithMode(input, kernels, Same)
+}
+
+// ConvolveWithMode convolves input with kernels and returns the part of the
+// convolution selected by mode. Depending on f.Method and the input and kernel
+// sizes the convolution is done directly or with overlap-add FFT convolution.
+func (f *FIR[T]) ConvolveWithMode(input []T, kernels []float64, mode ConvolutionMode) ([]T, error) {
 	if f == nil {
 		return nil, nil
 	}
 
-	if !(len(input) > len(kernels)) {
-		return nil, fmt.Errorf("provided data set is not greater than the filter weights")
+	if len(kernels) == 0 {
+		return nil, fmt.Errorf("filter weights should not be empty")
 	}
 
-	output := make([]T, len(input))
-
-	for i := 0; i < len(kernels); i++ {
-		var sum float64
-
-		for j := 0; j < i; j++ {
-			sum += float64(input[j]) * kernels[len(kernels)-(1+i-j)]
-		}
-
-		output[i] = T(sum)
+	if len(input) == 0 {
+		return []T{}, nil
 	}
 
-	for i := len(kernels); i < len(input); i++ {
-		var sum float64
+	offset, length := convolutionRange(mode, len(input), len(kernels))
+	output := make([]T, length)
 
-		for j := 0; j < len(kernels); j++ {
-			sum += float64(input[i-j]) * kernels[j]
-		}
+	method := f.Method
+	if method == Auto {
+		method = chooseMethod(len(input), len(kernels))
+	}
 
-		output[i] = T(sum)
+	if method == FFT {
+		convolveFFT(output, input, kernels, offset)
+	} else {
+		convolveDirect(output, input, kernels, offset)
 	}
 
 	return output, nil
@@ -0,0 +1,71 @@
+package filters
+
+import (
+	"errors"
+	"math"
+
+	"github.com/almerlucke/sndfile/dsp/windows"
+)
+
+// KaiserBeta returns the Kaiser window beta needed for a stopband attenuation in dB
+func KaiserBeta(attenuation float64) float64 {
+	switch {
+	case attenuation > 50:
+		return 0.1102 * (attenuation - 8.7)
+	case attenuation >= 21:
+		return 0.5842*math.Pow(attenuation-21, 0.4) + 0.07886*(attenuation-21)
+	default:
+		return 0
+	}
+}
+
+// KaiserTaps returns the number of taps needed for a stopband attenuation in dB and a
+// transition width as a ratio of the sampling frequency. The result is always even so
+// the kernel has a whole sample center and can be used for high pass and band stop filters.
+func KaiserTaps(attenuation float64, transitionWidth float64) int {
+	taps := int(math.Ceil((attenuation - 7.95) / (14.36 * transitionWidth)))
+	taps = max(taps, 2)
+	if taps%2 == 1 {
+		taps++
+	}
+
+	return taps
+}
+
+// NewKaiserSinc designs a Kaiser windowed sinc from a passband edge, a stopband edge in Hz and the
+// required stopband attenuation in dB. The cut off lies halfway between the edges, for a low pass the
+// passband edge is below the stopband edge and for a high pass it is above.
+func NewKaiserSinc(passbandEdge float64, stopbandEdge float64, attenuation float64, samplingFreq int) (*Sinc, error) {
+	width := math.Abs(stopbandEdge-passbandEdge) / float64(samplingFreq)
+	if width == 0 {
+		return nil, errors.New("passband and stopband edge should differ")
+	}
+
+	return &Sinc{
+		CutOffFreq:   (passbandEdge + stopbandEdge) / 2,
+		SamplingFreq: samplingFreq,
+		Taps:         KaiserTaps(attenuation, width),
+		Window:       windows.Kaiser(KaiserBeta(attenuation)),
+	}, nil
+}
+
+// NewKaiserBandSinc designs a Kaiser windowed sinc for a band pass or band stop filter from four
+// ascending band edges in Hz. For a band pass these are stop, pass, pass, stop and for a band
+// stop pass, stop, stop, pass. The narrowest transition determines the number of taps.
+func NewKaiserBandSinc(edges [4]float64, attenuation float64, samplingFreq int) (*Sinc, error) {
+	for i := 1; i < len(edges); i++ {
+		if edges[i] <= edges[i-1] {
+			return nil, errors.New("band edges should be ascending")
+		}
+	}
+
+	width := min(edges[1]-edges[0], edges[3]-edges[2]) / float64(samplingFreq)
+
+	return &Sinc{
+		CutOffFreq:     (edges[0] + edges[1]) / 2,
+		HighCutOffFreq: (edges[2] + edges[3]) / 2,
+		SamplingFreq:   samplingFreq,
+		Taps:           KaiserTaps(attenuation, width),
+		Window:         windows.Kaiser(KaiserBeta(attenuation)),
+	}, nil
+}
@@ -0,0 +1,122 @@
+package filters
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// kernelGainDB evaluates the magnitude response of an FIR kernel at freq
+func kernelGainDB(kernel []float64, freq float64, sampleRate float64) float64 {
+	var h complex128
+
+	for n, c := range kernel {
+		h += complex(c, 0) * cmplx.Exp(complex(0, -2*math.Pi*freq*float64(n)/sampleRate))
+	}
+
+	return 20 * math.Log10(cmplx.Abs(h))
+}
+
+func TestKaiserTaps(t *testing.T) {
+	tests := []struct {
+		attenuation float64
+		width       float64
+	}{
+		{40, 0.01},
+		{60, 0.02},
+		{80, 500.0 / 48000},
+		{20, 0.1},
+	}
+
+	for _, tt := range tests {
+		taps := KaiserTaps(tt.attenuation, tt.width)
+		if taps%2 != 0 || taps < 2 {
+			t.Errorf("%v dB, width %v: %d taps", tt.attenuation, tt.width, taps)
+		}
+	}
+
+	if KaiserBeta(10) != 0 {
+		t.Fatal("beta should be 0 below 21 dB")
+	}
+}
+
+func TestKaiserSinc(t *testing.T) {
+	sampleRate := 48000
+
+	tests := []struct {
+		name        string
+		pass, stop  float64
+		attenuation float64
+		highPass    bool
+	}{
+		{"low pass 60 dB", 1000, 1500, 60, false},
+		{"low pass 80 dB", 4000, 5000, 80, false},
+		{"high pass 60 dB", 1500, 1000, 60, true},
+		{"high pass 80 dB", 5000, 4000, 80, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewKaiserSinc(tt.pass, tt.stop, tt.attenuation, sampleRate)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			kernel := s.LowPassCoefs()
+			if tt.highPass {
+				kernel = s.HighPassCoefs()
+			}
+
+			if got := kernelGainDB(kernel, tt.pass, float64(sampleRate)); math.Abs(got) > 0.1 {
+				t.Errorf("passband edge %.3f dB", got)
+			}
+
+			if got := kernelGainDB(kernel, tt.stop, float64(sampleRate)); got > -tt.attenuation+1 {
+				t.Errorf("stopband edge %.1f dB, want below %.0f", got, -tt.attenuation)
+			}
+		})
+	}
+
+	if _, err := NewKaiserSinc(1000, 1000, 60, sampleRate); err == nil {
+		t.Fatal("expected error for equal edges")
+	}
+}
+
+func TestKaiserBandSinc(t *testing.T) {
+	sampleRate := 48000.0
+
+	s, err := NewKaiserBandSinc([4]float64{500, 1000, 3000, 3500}, 60, int(sampleRate))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		kernel []float64
+		freq   float64
+		pass   bool
+	}{
+		{"band pass below", s.BandPassCoefs(), 400, false},
+		{"band pass center", s.BandPassCoefs(), 2000, true},
+		{"band pass above", s.BandPassCoefs(), 3600, false},
+		{"band stop below", s.BandStopCoefs(), 400, true},
+		{"band stop center", s.BandStopCoefs(), 2000, false},
+		{"band stop above", s.BandStopCoefs(), 3600, true},
+	}
+
+	for _, tt := range tests {
+		got := kernelGainDB(tt.kernel, tt.freq, sampleRate)
+
+		if tt.pass && math.Abs(got) > 0.1 {
+			t.Errorf("%s: %.3f dB, want 0", tt.name, got)
+		}
+
+		if !tt.pass && got > -59 {
+			t.Errorf("%s: %.1f dB, want below -60", tt.name, got)
+		}
+	}
+
+	if _, err := NewKaiserBandSinc([4]float64{500, 400, 3000, 3500}, 60, int(sampleRate)); err == nil {
+		t.Fatal("expected error for descending edges")
+	}
+}
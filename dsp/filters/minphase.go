@@ -0,0 +1,75 @@
+package filters
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+
+	"github.com/almerlucke/sndfile/dsp/fft"
+)
+
+// MinimumPhase converts a (linear phase) kernel to a minimum phase kernel of the same length
+// with approximately the same magnitude response using the homomorphic (cepstral) method.
+// Most of the energy of the result is at the start of the kernel, so it has a much lower latency
+// at the cost of a non linear phase response.
+// See https://en.wikipedia.org/wiki/Minimum_phase
+func MinimumPhase(kernel []float64) ([]float64, error) {
+	if len(kernel) == 0 {
+		return nil, errors.New("kernel should not be empty")
+	}
+
+	// A large FFT size keeps time aliasing of the cepstrum low
+	n := max(fft.NextPowerOfTwo(len(kernel)*16), 1024)
+	f := fft.MustNew(n)
+
+	spectrum := make([]complex128, n)
+	for i, k := range kernel {
+		spectrum[i] = complex(k, 0)
+	}
+
+	f.Forward(spectrum, spectrum)
+
+	maxMag := 0.0
+	for _, c := range spectrum {
+		maxMag = max(maxMag, cmplx.Abs(c))
+	}
+
+	if maxMag == 0 {
+		return make([]float64, len(kernel)), nil
+	}
+
+	// Real cepstrum of the log magnitude, the floor avoids log(0) in the stopband
+	floor := maxMag * 1e-10
+	for i, c := range spectrum {
+		spectrum[i] = complex(math.Log(max(cmplx.Abs(c), floor)), 0)
+	}
+
+	f.Inverse(spectrum, spectrum)
+
+	// Fold the anti causal part of the cepstrum onto the causal part
+	for i := 1; i < n/2; i++ {
+		spectrum[i] = complex(2*real(spectrum[i]), 0)
+	}
+
+	spectrum[0] = complex(real(spectrum[0]), 0)
+	spectrum[n/2] = complex(real(spectrum[n/2]), 0)
+
+	for i := n/2 + 1; i < n; i++ {
+		spectrum[i] = 0
+	}
+
+	f.Forward(spectrum, spectrum)
+
+	for i, c := range spectrum {
+		spectrum[i] = cmplx.Exp(c)
+	}
+
+	f.Inverse(spectrum, spectrum)
+
+	result := make([]float64, len(kernel))
+	for i := range result {
+		result[i] = real(spectrum[i])
+	}
+
+	return result, nil
+}
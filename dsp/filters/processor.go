@@ -0,0 +1,128 @@
+package filters
+
+import (
+	"errors"
+
+	"github.com/almerlucke/sndfile/float"
+)
+
+// FIRProcessor is a stateful FIR filter for block based processing. The delay line of each
+// channel is kept between calls so a signal can be filtered in blocks of any size with the same
+// result as filtering the whole signal at once.
+type FIRProcessor[T float.Float] struct {
+	kernel      []float64
+	numChannels int
+	// Per channel delay lines of twice the kernel length so a contiguous window is always available
+	delays [][]float64
+	pos    []int
+}
+
+// NewFIRProcessor creates a streaming FIR processor for numChannels channels
+func NewFIRProcessor[T float.Float](kernel []float64, numChannels int) (*FIRProcessor[T], error) {
+	if len(kernel) == 0 {
+		return nil, errors.New("filter weights should not be empty")
+	}
+
+	if numChannels < 1 {
+		return nil, errors.New("number of channels should be at least 1")
+	}
+
+	p := &FIRProcessor[T]{
+		kernel:      append([]float64(nil), kernel...),
+		numChannels: numChannels,
+		delays:      make([][]float64, numChannels),
+		pos:         make([]int, numChannels),
+	}
+
+	for c := range p.delays {
+		p.delays[c] = make([]float64, 2*len(kernel))
+	}
+
+	return p, nil
+}
+
+// NewLowPassProcessor creates a streaming low pass processor from a Sinc
+func NewLowPassProcessor[T float.Float](s *Sinc, numChannels int) (*FIRProcessor[T], error) {
+	return NewFIRProcessor[T](s.LowPassCoefs(), numChannels)
+}
+
+// NewHighPassProcessor creates a streaming high pass processor from a Sinc
+func NewHighPassProcessor[T float.Float](s *Sinc, numChannels int) (*FIRProcessor[T], error) {
+	return NewFIRProcessor[T](s.HighPassCoefs(), numChannels)
+}
+
+// NumChannels returns the number of channels
+func (p *FIRProcessor[T]) NumChannels() int {
+	return p.numChannels
+}
+
+// Kernel returns the filter weights
+func (p *FIRProcessor[T]) Kernel() []float64 {
+	return p.kernel
+}
+
+// Latency returns the delay in samples introduced by the filter, assuming a linear phase kernel
+func (p *FIRProcessor[T]) Latency() int {
+	return (len(p.kernel) - 1) / 2
+}
+
+// Reset clears the delay lines
+func (p *FIRProcessor[T]) Reset() {
+	for c := range p.delays {
+		clear(p.delays[c])
+		p.pos[c] = 0
+	}
+}
+
+func (p *FIRProcessor[T]) tick(channel int, x float64) float64 {
+	m := len(p.kernel)
+	delay := p.delays[channel]
+	pos := p.pos[channel]
+
+	delay[pos] = x
+	delay[pos+m] = x
+
+	// delay[pos+j] holds the sample j steps back when walking the line backwards
+	var sum float64
+
+	window := delay[pos : pos+m]
+	for j, k := range p.kernel {
+		sum += window[j] * k
+	}
+
+	pos--
+	if pos < 0 {
+		pos = m - 1
+	}
+
+	p.pos[channel] = pos
+
+	return sum
+}
+
+// ProcessChannel filters a block of a single channel in place
+func (p *FIRProcessor[T]) ProcessChannel(channel int, block []T) {
+	for i, x := range block {
+		block[i] = T(p.tick(channel, float64(x)))
+	}
+}
+
+// Process filters a block of deinterleaved channels in place
+func (p *FIRProcessor[T]) Process(channels [][]T) {
+	for c, block := range channels[:p.numChannels] {
+		p.ProcessChannel(c, block)
+	}
+}
+
+// ProcessInterleaved filters a block of interleaved frames in place, this implements writer.Processor
+// for FIRProcessor[float32]
+func (p *FIRProcessor[T]) ProcessInterleaved(block []T) {
+	numFrames := len(block) / p.numChannels
+
+	for i := 0; i < numFrames; i++ {
+		for c := 0; c < p.numChannels; c++ {
+			idx := i*p.numChannels + c
+			block[idx] = T(p.tick(c, float64(block[idx])))
+		}
+	}
+}
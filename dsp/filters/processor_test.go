@@ -0,0 +1,93 @@
+package filters
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestFIRProcessorMatchesConvolve(t *testing.T) {
+	rng := rand.New(rand.NewPCG(2, 2))
+
+	tests := []struct {
+		name      string
+		kernelLen int
+		blockSize int
+	}{
+		{"single tap", 1, 64},
+		{"short kernel", 7, 1},
+		{"odd blocks", 37, 70},
+		{"long kernel small blocks", 301, 13},
+		{"block larger than input", 37, 5000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := randomSignal(rng, tt.kernelLen)
+			x := randomSignal(rng, 1000)
+
+			want, err := (&FIR[float64]{}).Convolve(x, k)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p, err := NewFIRProcessor[float64](k, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Deinterleaved, each channel processed in blocks
+			left := append([]float64(nil), x...)
+			right := append([]float64(nil), x...)
+
+			for s := 0; s < len(x); s += tt.blockSize {
+				e := min(s+tt.blockSize, len(x))
+				p.Process([][]float64{left[s:e], right[s:e]})
+			}
+
+			// Interleaved
+			p.Reset()
+
+			inter := make([]float64, 2*len(x))
+			for i, v := range x {
+				inter[2*i] = v
+				inter[2*i+1] = v
+			}
+
+			for s := 0; s < len(inter); s += 2 * tt.blockSize {
+				p.ProcessInterleaved(inter[s:min(s+2*tt.blockSize, len(inter))])
+			}
+
+			for i := range want {
+				if math.Abs(left[i]-want[i]) > 1e-9 || right[i] != left[i] {
+					t.Fatalf("sample %d: %f, want %f", i, left[i], want[i])
+				}
+
+				if math.Abs(inter[2*i]-want[i]) > 1e-9 || inter[2*i+1] != inter[2*i] {
+					t.Fatalf("interleaved sample %d: %f, want %f", i, inter[2*i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFIRProcessorErrors(t *testing.T) {
+	if _, err := NewFIRProcessor[float64](nil, 1); err == nil {
+		t.Fatal("expected error for empty kernel")
+	}
+
+	if _, err := NewFIRProcessor[float64]([]float64{1}, 0); err == nil {
+		t.Fatal("expected error for zero channels")
+	}
+}
+
+func TestFIRProcessorLatency(t *testing.T) {
+	p, err := NewFIRProcessor[float64](make([]float64, 101), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Latency() != 50 {
+		t.Fatalf("latency %d, want 50", p.Latency())
+	}
+}
@@ -0,0 +1,321 @@
+package filters
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	remezGridDensity   = 16
+	remezMaxIterations = 40
+)
+
+// RemezBand describes one band of an equiripple design. Low and High are the band edges in Hz,
+// Gain is the desired amplitude in the band and Weight the relative importance of the error in
+// the band (a weight of 0 is treated as 1).
+type RemezBand struct {
+	Low    float64
+	High   float64
+	Gain   float64
+	Weight float64
+}
+
+// Remez designs a linear phase equiripple FIR filter with numTaps coefficients using the
+// Parks-McClellan algorithm. Bands should be ascending, not overlapping and lie between 0 and
+// samplingFreq/2. The gaps between bands are the transition regions.
+// See https://en.wikipedia.org/wiki/Parks%E2%80%93McClellan_filter_design_algorithm
+func Remez(numTaps int, bands []RemezBand, samplingFreq float64) ([]float64, error) {
+	if numTaps < 3 {
+		return nil, errors.New("number of taps should be at least 3")
+	}
+
+	if len(bands) == 0 {
+		return nil, errors.New("at least one band is needed")
+	}
+
+	nyquist := samplingFreq / 2
+	prev := 0.0
+
+	for i, band := range bands {
+		if band.Low < prev || band.High <= band.Low || band.High > nyquist || (i > 0 && band.Low == prev) {
+			return nil, errors.New("bands should be ascending, not overlapping and between 0 and nyquist")
+		}
+		prev = band.High
+	}
+
+	r := numTaps / 2
+	if numTaps%2 == 1 {
+		r++
+	}
+
+	grid, desired, weight := remezGrid(r, numTaps, bands, samplingFreq)
+	if len(grid) <= r {
+		return nil, errors.New("bands are too narrow for the number of taps")
+	}
+
+	// Type II filters (even number of taps) have a cos(pi f) factor in the amplitude response
+	if numTaps%2 == 0 {
+		for i, f := range grid {
+			c := math.Cos(math.Pi * f)
+			desired[i] /= c
+			weight[i] *= c
+		}
+	}
+
+	// Initial guess of extremal frequencies evenly spaced over the grid
+	ext := make([]int, r+1)
+	for i := range ext {
+		ext[i] = i * (len(grid) - 1) / r
+	}
+
+	rs := &remezState{
+		r:     r,
+		x:     make([]float64, r+1),
+		y:     make([]float64, r+1),
+		ad:    make([]float64, r+1),
+		grid:  grid,
+		err:   make([]float64, len(grid)),
+		found: make([]int, 0, 2*len(grid)),
+	}
+
+	converged := false
+
+	for iter := 0; iter < remezMaxIterations; iter++ {
+		rs.calcParams(ext, desired, weight)
+
+		for i, f := range grid {
+			rs.err[i] = weight[i] * (desired[i] - rs.computeA(f))
+		}
+
+		if !rs.search(ext) {
+			return nil, errors.New("remez could not find enough extremal frequencies")
+		}
+
+		minErr := math.Abs(rs.err[ext[0]])
+		maxErr := minErr
+
+		for _, e := range ext[1:] {
+			current := math.Abs(rs.err[e])
+			minErr = min(minErr, current)
+			maxErr = max(maxErr, current)
+		}
+
+		if maxErr == 0 || (maxErr-minErr)/maxErr < 0.0001 {
+			converged = true
+			break
+		}
+	}
+
+	if !converged {
+		return nil, errors.New("remez did not converge")
+	}
+
+	// Sample the amplitude response and convert it to an impulse response
+	amps := make([]float64, numTaps/2+1)
+	for i := range amps {
+		c := 1.0
+		if numTaps%2 == 0 {
+			c = math.Cos(math.Pi * float64(i) / float64(numTaps))
+		}
+		amps[i] = rs.computeA(float64(i)/float64(numTaps)) * c
+	}
+
+	return frequencySample(numTaps, amps), nil
+}
+
+// remezGrid creates the dense frequency grid with desired response and weights, frequencies are
+// normalized to the sampling frequency
+func remezGrid(r int, numTaps int, bands []RemezBand, samplingFreq float64) ([]float64, []float64, []float64) {
+	delf := 0.5 / float64(remezGridDensity*r)
+
+	var grid, desired, weight []float64
+
+	for _, band := range bands {
+		low := band.Low / samplingFreq
+		high := band.High / samplingFreq
+
+		// A type II filter is always zero at nyquist
+		if numTaps%2 == 0 && high > 0.5-delf {
+			high = 0.5 - delf
+		}
+
+		w := band.Weight
+		if w == 0 {
+			w = 1
+		}
+
+		k := max(int((high-low)/delf+0.5), 1)
+
+		for i := 0; i < k; i++ {
+			f := low + float64(i)*delf
+			if i == k-1 {
+				f = high
+			}
+			grid = append(grid, f)
+			desired = append(desired, band.Gain)
+			weight = append(weight, w)
+		}
+	}
+
+	return grid, desired, weight
+}
+
+type remezState struct {
+	r     int
+	x     []float64
+	y     []float64
+	ad    []float64
+	grid  []float64
+	err   []float64
+	found []int
+}
+
+// calcParams computes the barycentric Lagrange interpolation parameters for the current extremals
+func (rs *remezState) calcParams(ext []int, desired []float64, weight []float64) {
+	r := rs.r
+
+	for i := 0; i <= r; i++ {
+		rs.x[i] = math.Cos(2 * math.Pi * rs.grid[ext[i]])
+	}
+
+	// Skip around to avoid round off errors
+	ld := (r-1)/15 + 1
+
+	for i := 0; i <= r; i++ {
+		denom := 1.0
+		xi := rs.x[i]
+
+		for j := 0; j < ld; j++ {
+			for k := j; k <= r; k += ld {
+				if k != i {
+					denom *= 2.0 * (xi - rs.x[k])
+				}
+			}
+		}
+
+		if math.Abs(denom) < 0.00001 {
+			denom = 0.00001
+		}
+
+		rs.ad[i] = 1.0 / denom
+	}
+
+	numer := 0.0
+	denom := 0.0
+	sign := 1.0
+
+	for i := 0; i <= r; i++ {
+		numer += rs.ad[i] * desired[ext[i]]
+		denom += sign * rs.ad[i] / weight[ext[i]]
+		sign = -sign
+	}
+
+	delta := numer / denom
+	sign = 1.0
+
+	for i := 0; i <= r; i++ {
+		rs.y[i] = desired[ext[i]] - sign*delta/weight[ext[i]]
+		sign = -sign
+	}
+}
+
+// computeA evaluates the amplitude response at a normalized frequency
+func (rs *remezState) computeA(freq float64) float64 {
+	numer := 0.0
+	denom := 0.0
+	xc := math.Cos(2 * math.Pi * freq)
+
+	for i := 0; i <= rs.r; i++ {
+		c := xc - rs.x[i]
+		if math.Abs(c) < 1.0e-7 {
+			return rs.y[i]
+		}
+
+		c = rs.ad[i] / c
+		denom += c
+		numer += c * rs.y[i]
+	}
+
+	return numer / denom
+}
+
+// search finds the r+1 largest alternating extrema of the error function
+func (rs *remezState) search(ext []int) bool {
+	e := rs.err
+	n := len(e)
+	found := rs.found[:0]
+
+	if (e[0] > 0 && e[0] > e[1]) || (e[0] < 0 && e[0] < e[1]) {
+		found = append(found, 0)
+	}
+
+	for i := 1; i < n-1; i++ {
+		if (e[i] >= e[i-1] && e[i] > e[i+1] && e[i] > 0) || (e[i] <= e[i-1] && e[i] < e[i+1] && e[i] < 0) {
+			found = append(found, i)
+		}
+	}
+
+	j := n - 1
+	if (e[j] > 0 && e[j] > e[j-1]) || (e[j] < 0 && e[j] < e[j-1]) {
+		found = append(found, j)
+	}
+
+	for len(found) > rs.r+1 {
+		// Delete the smallest of the first two non alternating extrema, or if all
+		// alternate the smallest of the first and last
+		remove := -1
+
+		for k := 1; k < len(found); k++ {
+			if (e[found[k]] > 0) == (e[found[k-1]] > 0) {
+				remove = k
+				if math.Abs(e[found[k-1]]) < math.Abs(e[found[k]]) {
+					remove = k - 1
+				}
+				break
+			}
+		}
+
+		if remove < 0 {
+			remove = 0
+			if math.Abs(e[found[len(found)-1]]) < math.Abs(e[found[0]]) {
+				remove = len(found) - 1
+			}
+		}
+
+		found = append(found[:remove], found[remove+1:]...)
+	}
+
+	rs.found = found
+
+	if len(found) < rs.r+1 {
+		return false
+	}
+
+	copy(ext, found)
+
+	return true
+}
+
+// frequencySample computes a symmetric impulse response from amplitude samples at k/numTaps
+func frequencySample(numTaps int, amps []float64) []float64 {
+	h := make([]float64, numTaps)
+	m := float64(numTaps-1) / 2.0
+
+	last := numTaps / 2
+	if numTaps%2 == 0 {
+		last = numTaps/2 - 1
+	}
+
+	for n := 0; n < numTaps; n++ {
+		val := amps[0]
+		x := 2 * math.Pi * (float64(n) - m) / float64(numTaps)
+
+		for k := 1; k <= last; k++ {
+			val += 2.0 * amps[k] * math.Cos(x*float64(k))
+		}
+
+		h[n] = val / float64(numTaps)
+	}
+
+	return h
+}
@@ -0,0 +1,140 @@
+package filters
+
+import (
+	"math"
+	"testing"
+)
+
+// bandDeviation returns the largest linear deviation from gain between low and high
+func bandDeviation(kernel []float64, low float64, high float64, gain float64, sampleRate float64) float64 {
+	var dev float64
+
+	for f := low; f <= high; f += 25 {
+		dev = max(dev, math.Abs(math.Pow(10, kernelGainDB(kernel, f, sampleRate)/20)-gain))
+	}
+
+	return dev
+}
+
+func TestRemezLowPass(t *testing.T) {
+	sampleRate := 48000.0
+	bands := []RemezBand{{0, 4000, 1, 1}, {6000, 24000, 0, 10}}
+
+	tests := []struct {
+		numTaps  int
+		maxStopB float64
+	}{
+		{31, -35},
+		{32, -35},
+		{101, -80},
+	}
+
+	for _, tt := range tests {
+		h, err := Remez(tt.numTaps, bands, sampleRate)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(h) != tt.numTaps {
+			t.Fatalf("%d taps: got %d coefficients", tt.numTaps, len(h))
+		}
+
+		for i := range h {
+			if math.Abs(h[i]-h[len(h)-1-i]) > 1e-12 {
+				t.Fatalf("%d taps: kernel is not symmetric", tt.numTaps)
+			}
+		}
+
+		pass := bandDeviation(h, 0, 4000, 1, sampleRate)
+		stop := bandDeviation(h, 6000, 24000, 0, sampleRate)
+
+		if got := 20 * math.Log10(stop); got > tt.maxStopB {
+			t.Errorf("%d taps: stopband %.1f dB", tt.numTaps, got)
+		}
+
+		// Equiripple: the weighted errors of both bands are equal
+		if ratio := pass / (10 * stop); ratio < 0.9 || ratio > 1.1 {
+			t.Errorf("%d taps: weighted error ratio %.3f", tt.numTaps, ratio)
+		}
+	}
+}
+
+func TestRemezBandPass(t *testing.T) {
+	sampleRate := 48000.0
+
+	h, err := Remez(65, []RemezBand{{0, 2000, 0, 1}, {3000, 6000, 1, 1}, {7000, 24000, 0, 1}}, sampleRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := kernelGainDB(h, 4500, sampleRate); math.Abs(got) > 0.5 {
+		t.Errorf("passband %.2f dB", got)
+	}
+
+	for _, f := range []float64{1000, 10000} {
+		if got := kernelGainDB(h, f, sampleRate); got > -25 {
+			t.Errorf("%.0f Hz: %.1f dB", f, got)
+		}
+	}
+}
+
+func TestRemezErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		numTaps int
+		bands   []RemezBand
+	}{
+		{"too few taps", 2, []RemezBand{{0, 4000, 1, 1}}},
+		{"no bands", 31, nil},
+		{"empty band", 31, []RemezBand{{4000, 4000, 1, 1}}},
+		{"overlapping", 31, []RemezBand{{0, 4000, 1, 1}, {3000, 24000, 0, 1}}},
+		{"above nyquist", 31, []RemezBand{{0, 30000, 1, 1}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := Remez(tt.numTaps, tt.bands, 48000); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestMinimumPhase(t *testing.T) {
+	sampleRate := 48000.0
+
+	h, err := Remez(101, []RemezBand{{0, 4000, 1, 1}, {6000, 24000, 0, 10}}, sampleRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := MinimumPhase(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m) != len(h) {
+		t.Fatalf("length %d, want %d", len(m), len(h))
+	}
+
+	for f := 0.0; f < 4000; f += 50 {
+		if d := math.Abs(kernelGainDB(m, f, sampleRate) - kernelGainDB(h, f, sampleRate)); d > 0.01 {
+			t.Fatalf("%.0f Hz: magnitude differs by %.4f dB", f, d)
+		}
+	}
+
+	// The energy is concentrated at the start instead of the center
+	var head, total float64
+	for i, v := range m {
+		if i < len(m)/4 {
+			head += v * v
+		}
+		total += v * v
+	}
+
+	if head/total < 0.9 {
+		t.Fatalf("only %.2f of the energy in the first quarter", head/total)
+	}
+
+	if _, err := MinimumPhase(nil); err == nil {
+		t.Fatal("expected error for empty kernel")
+	}
+}
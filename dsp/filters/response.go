@@ -0,0 +1,204 @@
+package filters
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// Response is the frequency response of a filter evaluated on a grid of frequencies
+type Response struct {
+	SamplingFreq float64
+	// Frequencies in Hz
+	Frequencies []float64
+	// Magnitude in dB
+	Magnitude []float64
+	// Unwrapped phase in radians
+	Phase []float64
+	// GroupDelay in samples
+	GroupDelay []float64
+}
+
+// LinearFrequencies returns numPoints frequencies linearly spaced from low to high inclusive
+func LinearFrequencies(numPoints int, low float64, high float64) []float64 {
+	freqs := make([]float64, numPoints)
+	if numPoints == 1 {
+		freqs[0] = low
+		return freqs
+	}
+
+	step := (high - low) / float64(numPoints-1)
+	for i := range freqs {
+		freqs[i] = low + float64(i)*step
+	}
+
+	return freqs
+}
+
+// LogFrequencies returns numPoints frequencies logarithmically spaced from low to high inclusive,
+// low should be greater than 0
+func LogFrequencies(numPoints int, low float64, high float64) []float64 {
+	freqs := LinearFrequencies(numPoints, math.Log(low), math.Log(high))
+	for i, f := range freqs {
+		freqs[i] = math.Exp(f)
+	}
+
+	return freqs
+}
+
+// polyResponse evaluates a polynomial in z^-1 and its group delay at normalized angular frequency w
+func polyResponse(coefs []float64, w float64) (complex128, float64) {
+	var h, nh complex128
+
+	for n, c := range coefs {
+		e := cmplx.Exp(complex(0, -w*float64(n)))
+		h += complex(c, 0) * e
+		nh += complex(c*float64(n), 0) * e
+	}
+
+	if h == 0 {
+		return h, 0
+	}
+
+	return h, real(nh / h)
+}
+
+// FIRResponse computes the frequency response of an FIR kernel
+func FIRResponse(kernel []float64, freqs []float64, samplingFreq float64) *Response {
+	r := newResponse(freqs, samplingFreq)
+	values := make([]complex128, len(freqs))
+
+	for i, f := range freqs {
+		values[i], r.GroupDelay[i] = polyResponse(kernel, 2*math.Pi*f/samplingFreq)
+	}
+
+	r.fill(values)
+
+	return r
+}
+
+// IIRResponse computes the frequency response of a cascade of biquad sections
+func IIRResponse(sections []BiquadCoefs, freqs []float64, samplingFreq float64) *Response {
+	r := newResponse(freqs, samplingFreq)
+	values := make([]complex128, len(freqs))
+
+	for i, f := range freqs {
+		w := 2 * math.Pi * f / samplingFreq
+		h := complex(1, 0)
+
+		for _, s := range sections {
+			num, numDelay := polyResponse([]float64{s.B0, s.B1, s.B2}, w)
+			den, denDelay := polyResponse([]float64{1, s.A1, s.A2}, w)
+			h *= num / den
+			r.GroupDelay[i] += numDelay - denDelay
+		}
+
+		values[i] = h
+	}
+
+	r.fill(values)
+
+	return r
+}
+
+func newResponse(freqs []float64, samplingFreq float64) *Response {
+	return &Response{
+		SamplingFreq: samplingFreq,
+		Frequencies:  append([]float64(nil), freqs...),
+		Magnitude:    make([]float64, len(freqs)),
+		Phase:        make([]float64, len(freqs)),
+		GroupDelay:   make([]float64, len(freqs)),
+	}
+}
+
+// fill sets magnitude and unwrapped phase from complex response values
+func (r *Response) fill(values []complex128) {
+	prev := 0.0
+	offset := 0.0
+
+	for i, h := range values {
+		r.Magnitude[i] = 20 * math.Log10(max(cmplx.Abs(h), 1e-300))
+
+		phase := cmplx.Phase(h)
+		if i > 0 {
+			for phase+offset-prev > math.Pi {
+				offset -= 2 * math.Pi
+			}
+			for phase+offset-prev < -math.Pi {
+				offset += 2 * math.Pi
+			}
+		}
+
+		prev = phase + offset
+		r.Phase[i] = prev
+	}
+}
+
+// PeakMagnitude returns the highest magnitude in dB
+func (r *Response) PeakMagnitude() float64 {
+	peak := math.Inf(-1)
+	for _, m := range r.Magnitude {
+		peak = max(peak, m)
+	}
+
+	return peak
+}
+
+// Crossings returns the interpolated frequencies where the magnitude crosses level dB
+func (r *Response) Crossings(level float64) []float64 {
+	var crossings []float64
+
+	for i := 1; i < len(r.Magnitude); i++ {
+		m1 := r.Magnitude[i-1] - level
+		m2 := r.Magnitude[i] - level
+
+		if m1 == 0 {
+			crossings = append(crossings, r.Frequencies[i-1])
+		} else if m1*m2 < 0 {
+			t := m1 / (m1 - m2)
+			crossings = append(crossings, r.Frequencies[i-1]+t*(r.Frequencies[i]-r.Frequencies[i-1]))
+		}
+	}
+
+	return crossings
+}
+
+// CutOff returns the first frequency where the magnitude crosses 3dB below its peak, or -1
+// if there is no such point on the grid
+func (r *Response) CutOff() float64 {
+	crossings := r.Crossings(r.PeakMagnitude() - 3.0103)
+	if len(crossings) == 0 {
+		return -1
+	}
+
+	return crossings[0]
+}
+
+// StopbandAttenuation returns how far in dB the highest magnitude between low and high Hz lies
+// below the peak magnitude
+func (r *Response) StopbandAttenuation(low float64, high float64) float64 {
+	highest := math.Inf(-1)
+
+	for i, f := range r.Frequencies {
+		if f >= low && f <= high {
+			highest = max(highest, r.Magnitude[i])
+		}
+	}
+
+	return r.PeakMagnitude() - highest
+}
+
+// PassbandRipple returns the difference in dB between the highest and lowest magnitude
+// between low and high Hz
+func (r *Response) PassbandRipple(low float64, high float64) float64 {
+	lowest := math.Inf(1)
+	highest := math.Inf(-1)
+
+	for i, f := range r.Frequencies {
+		if f >= low && f <= high {
+			lowest = min(lowest, r.Magnitude[i])
+			highest = max(highest, r.Magnitude[i])
+		}
+	}
+
+	return highest - lowest
+}
@@ -0,0 +1,121 @@
+package filters
+
+import (
+	"math"
+	"testing"
+
+	"github.com/almerlucke/sndfile/dsp/windows"
+)
+
+func TestFrequencyGrids(t *testing.T) {
+	tests := []struct {
+		name  string
+		freqs []float64
+		low   float64
+		high  float64
+	}{
+		{"linear", LinearFrequencies(11, 0, 1000), 0, 1000},
+		{"log", LogFrequencies(31, 20, 20000), 20, 20000},
+		{"single", LinearFrequencies(1, 440, 880), 440, 440},
+	}
+
+	for _, tt := range tests {
+		if math.Abs(tt.freqs[0]-tt.low) > 1e-9 || math.Abs(tt.freqs[len(tt.freqs)-1]-tt.high) > 1e-9 {
+			t.Errorf("%s: range %v..%v", tt.name, tt.freqs[0], tt.freqs[len(tt.freqs)-1])
+		}
+
+		for i := 1; i < len(tt.freqs); i++ {
+			if tt.freqs[i] <= tt.freqs[i-1] {
+				t.Fatalf("%s: not ascending at %d", tt.name, i)
+			}
+		}
+	}
+
+	// Log spaced points have a constant ratio
+	log := LogFrequencies(4, 10, 10000)
+	if math.Abs(log[1]-100) > 1e-9 || math.Abs(log[2]-1000) > 1e-9 {
+		t.Fatalf("log grid %v", log)
+	}
+}
+
+func TestFIRResponse(t *testing.T) {
+	s := &Sinc{CutOffFreq: 1000, SamplingFreq: 48000, Taps: 200, Window: windows.Hamming}
+	kernel := s.LowPassCoefs()
+	r := FIRResponse(kernel, LinearFrequencies(2401, 0, 24000), 48000)
+
+	// A symmetric kernel has a constant group delay of half its length
+	delay := float64(len(kernel)-1) / 2
+	for i, f := range r.Frequencies {
+		if f < 500 && math.Abs(r.GroupDelay[i]-delay) > 1e-6 {
+			t.Fatalf("%.0f Hz: group delay %f, want %f", f, r.GroupDelay[i], delay)
+		}
+	}
+
+	// The phase is linear in the passband
+	for i, f := range r.Frequencies {
+		want := -2 * math.Pi * f / 48000 * delay
+		if f < 500 && math.Abs(r.Phase[i]-want) > 1e-6 {
+			t.Fatalf("%.0f Hz: phase %f, want %f", f, r.Phase[i], want)
+		}
+	}
+
+	if got := r.CutOff(); got < 800 || got > 1000 {
+		t.Errorf("cut off %.1f Hz", got)
+	}
+
+	if got := r.StopbandAttenuation(2000, 24000); got < 50 {
+		t.Errorf("stopband attenuation %.1f dB", got)
+	}
+
+	if got := r.PassbandRipple(0, 500); got > 0.1 {
+		t.Errorf("passband ripple %.3f dB", got)
+	}
+}
+
+func TestIIRResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		sections []BiquadCoefs
+		cutOff   float64
+		endPhase float64
+	}{
+		{"butterworth 2", ButterworthLowPass(2, 1000, 48000), 1000, -math.Pi},
+		{"butterworth 4", ButterworthLowPass(4, 1000, 48000), 1000, -2 * math.Pi},
+		{"butterworth 3", ButterworthLowPass(3, 2000, 48000), 2000, -1.5 * math.Pi},
+	}
+
+	for _, tt := range tests {
+		r := IIRResponse(tt.sections, LogFrequencies(2000, 20, 23900), 48000)
+
+		if got := r.CutOff(); math.Abs(got-tt.cutOff) > 1 {
+			t.Errorf("%s: cut off %.2f Hz", tt.name, got)
+		}
+
+		// The unwrapped phase approaches -order*pi/2 towards nyquist
+		if got := r.Phase[len(r.Phase)-1]; math.Abs(got-tt.endPhase) > 0.1 {
+			t.Errorf("%s: phase at nyquist %.3f, want %.3f", tt.name, got, tt.endPhase)
+		}
+
+		if r.GroupDelay[0] <= 0 {
+			t.Errorf("%s: group delay %f", tt.name, r.GroupDelay[0])
+		}
+	}
+}
+
+func TestCrossings(t *testing.T) {
+	// A peak at 1 kHz crosses 3 dB once on each side
+	r := IIRResponse([]BiquadCoefs{NewBiquadCoefs(BiquadPeak, 1000, 48000, 2, 6)}, LogFrequencies(1000, 20, 20000), 48000)
+
+	crossings := r.Crossings(3)
+	if len(crossings) != 2 || crossings[0] >= 1000 || crossings[1] <= 1000 {
+		t.Fatalf("crossings %v", crossings)
+	}
+
+	if got := r.PeakMagnitude(); math.Abs(got-6) > 0.01 {
+		t.Fatalf("peak %.3f dB", got)
+	}
+
+	if got := r.Crossings(12); len(got) != 0 {
+		t.Fatalf("unexpected crossings %v", got)
+	}
+}
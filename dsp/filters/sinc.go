@@ -22,6 +22,11 @@ type Sinc struct {
 	Window         windows.Function
 	_lowPassCoefs  []float64
 	_highPassCoefs []float64
+	_bandPassCoefs []float64
+	_bandStopCoefs []float64
+	// HighCutOffFreq is the upper cut off frequency of band pass and band stop filters,
+	// CutOffFreq is used as the lower cut off frequency
+	HighCutOffFreq float64
 }
 
 // LowPassCoefs returns the coeficients to create a low pass filter
@@ -32,25 +37,7 @@ func (s *Sinc) LowPassCoefs() []float64 {
 	if s._lowPassCoefs != nil && len(s._lowPassCoefs) > 0 {
 		return s._lowPassCoefs
 	}
-	size := s.Taps + 1
-	// sample rate is 2 pi radians per second.
-	// we get the cutt off frequency in radians per second
-	b := (2 * math.Pi) * s.TransitionFreq()
-	s._lowPassCoefs = make([]float64, size)
-	// we use a window of size taps + 1
-	winData := s.Window(size)
-
-	// we only do half the taps because the coefs are symmetric
-	// but we fill up all the coefs
-	for i := 0; i < (s.Taps / 2); i++ {
-		c := float64(i) - float64(s.Taps)/2
-		y := math.Sin(c*b) / (math.Pi * c)
-		s._lowPassCoefs[i] = y * winData[i]
-		s._lowPassCoefs[size-1-i] = s._lowPassCoefs[i]
-	}
-
-	// then we do the ones we missed in case we have an odd number of taps
-	s._lowPassCoefs[s.Taps/2] = 2 * s.TransitionFreq() * winData[s.Taps/2]
+	s._lowPassCoefs = s.lowPassKernel(s.TransitionFreq())
 	return s._lowPassCoefs
 }
 
@@ -77,6 +64,83 @@ func (s *Sinc) HighPassCoefs() []float64 {
 	return s._highPassCoefs
 }
 
+// BandPassCoefs returns the coeficients to create a band pass filter passing
+// frequencies between CutOffFreq and HighCutOffFreq
+func (s *Sinc) BandPassCoefs() []float64 {
+	if s == nil {
+		return nil
+	}
+	if len(s._bandPassCoefs) > 0 {
+		return s._bandPassCoefs
+	}
+
+	// the difference of two low pass filters
+	size := s.Taps + 1
+	low := s.lowPassKernel(s.TransitionFreq())
+	high := s.lowPassKernel(s.HighTransitionFreq())
+	s._bandPassCoefs = make([]float64, size)
+
+	for i := 0; i < size; i++ {
+		s._bandPassCoefs[i] = high[i] - low[i]
+	}
+	return s._bandPassCoefs
+}
+
+// BandStopCoefs returns the coeficients to create a band stop filter rejecting
+// frequencies between CutOffFreq and HighCutOffFreq
+func (s *Sinc) BandStopCoefs() []float64 {
+	if s == nil {
+		return nil
+	}
+	if len(s._bandStopCoefs) > 0 {
+		return s._bandStopCoefs
+	}
+
+	// we take the band pass coefs and invert them
+	size := s.Taps + 1
+	bandPassCoefs := s.BandPassCoefs()
+	winData := s.Window(size)
+	s._bandStopCoefs = make([]float64, size)
+
+	for i := 0; i < size; i++ {
+		s._bandStopCoefs[i] = -bandPassCoefs[i]
+	}
+	s._bandStopCoefs[s.Taps/2] += winData[s.Taps/2]
+	return s._bandStopCoefs
+}
+
+// lowPassKernel returns windowed low pass coefs for a cut off frequency ratio
+func (s *Sinc) lowPassKernel(ratio float64) []float64 {
+	size := s.Taps + 1
+	// sample rate is 2 pi radians per second.
+	// we get the cutt off frequency in radians per second
+	b := (2 * math.Pi) * ratio
+	coefs := make([]float64, size)
+	// we use a window of size taps + 1
+	winData := s.Window(size)
+
+	// we only do half the taps because the coefs are symmetric
+	// but we fill up all the coefs
+	for i := 0; i < (s.Taps / 2); i++ {
+		c := float64(i) - float64(s.Taps)/2
+		y := math.Sin(c*b) / (math.Pi * c)
+		coefs[i] = y * winData[i]
+		coefs[size-1-i] = coefs[i]
+	}
+
+	// then we do the ones we missed in case we have an odd number of taps
+	coefs[s.Taps/2] = 2 * ratio * winData[s.Taps/2]
+	return coefs
+}
+
+// HighTransitionFreq returns a ratio of the high cutoff frequency and the sample rate.
+func (s *Sinc) HighTransitionFreq() float64 {
+	if s == nil {
+		return 0
+	}
+	return s.HighCutOffFreq / float64(s.SamplingFreq)
+}
+
 // TransitionFreq returns a ratio of the cutoff frequency and the sample rate.
 func (s *Sinc) TransitionFreq() float64 {
 	if s == nil {
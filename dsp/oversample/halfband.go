@@ -0,0 +1,172 @@
+package oversample
+
+import (
+	"errors"
+	"math"
+
+	"github.com/almerlucke/sndfile/dsp/filters"
+	"github.com/almerlucke/sndfile/dsp/windows"
+)
+
+// Halfband is a linear phase half band low pass with its cut off at a quarter of the sampling
+// rate. Every other coefficient apart from the center one is zero, so only the even taps are kept.
+// The full kernel has 4k+3 taps with the center tap at M = 2k+1.
+type Halfband struct {
+	// even holds the taps h[0], h[2], ..., h[4k+2]
+	even []float64
+	k    int
+}
+
+// NewHalfband designs a Kaiser windowed half band filter. The transition width is a ratio of the
+// sampling rate of the filter (the high rate) centered on a quarter of that rate, the attenuation is in dB.
+func NewHalfband(transitionWidth float64, attenuation float64) (*Halfband, error) {
+	if transitionWidth <= 0 || transitionWidth >= 0.5 {
+		return nil, errors.New("transition width should be between 0 and 0.5")
+	}
+
+	taps := filters.KaiserTaps(attenuation, transitionWidth) + 1
+
+	// Round up to a length of 4k+3 so the center tap is odd
+	k := taps / 4
+	n := 4*k + 3
+	m := 2*k + 1
+
+	win := windows.Kaiser(filters.KaiserBeta(attenuation))(n)
+
+	hb := &Halfband{
+		even: make([]float64, 2*k+2),
+		k:    k,
+	}
+
+	for i := range hb.even {
+		t := float64(2*i - m)
+		hb.even[i] = 0.5 * math.Sin(math.Pi*t/2) / (math.Pi * t / 2) * win[2*i]
+	}
+
+	return hb, nil
+}
+
+// NumTaps returns the length of the full kernel
+func (hb *Halfband) NumTaps() int {
+	return 4*hb.k + 3
+}
+
+// Delay returns the delay of the filter in samples at the high rate
+func (hb *Halfband) Delay() int {
+	return 2*hb.k + 1
+}
+
+// Kernel returns the full kernel including the zero taps
+func (hb *Halfband) Kernel() []float64 {
+	kernel := make([]float64, hb.NumTaps())
+	for i, c := range hb.even {
+		kernel[2*i] = c
+	}
+
+	kernel[hb.Delay()] = 0.5
+
+	return kernel
+}
+
+// delayLine keeps the last n samples, window returns them newest first
+type delayLine struct {
+	buf []float64
+	pos int
+	n   int
+}
+
+func newDelayLine(n int) *delayLine {
+	return &delayLine{
+		buf: make([]float64, 2*n),
+		n:   n,
+	}
+}
+
+func (d *delayLine) push(x float64) {
+	d.pos--
+	if d.pos < 0 {
+		d.pos = d.n - 1
+	}
+
+	d.buf[d.pos] = x
+	d.buf[d.pos+d.n] = x
+}
+
+func (d *delayLine) window() []float64 {
+	return d.buf[d.pos : d.pos+d.n]
+}
+
+// at returns the sample i steps back
+func (d *delayLine) at(i int) float64 {
+	return d.buf[d.pos+i]
+}
+
+func (d *delayLine) reset() {
+	clear(d.buf)
+	d.pos = 0
+}
+
+func dot(a []float64, b []float64) float64 {
+	var sum float64
+	for i, v := range a {
+		sum += v * b[i]
+	}
+
+	return sum
+}
+
+// upsampler2x doubles the sample rate of a stream
+type upsampler2x struct {
+	hb      *Halfband
+	history *delayLine
+}
+
+func newUpsampler2x(hb *Halfband) *upsampler2x {
+	return &upsampler2x{
+		hb:      hb,
+		history: newDelayLine(len(hb.even)),
+	}
+}
+
+// process writes 2*len(src) samples to dst
+func (u *upsampler2x) process(dst []float64, src []float64) {
+	for i, x := range src {
+		u.history.push(x)
+		// zero stuffing doubles the gain of the even branch, the odd branch is the center tap only
+		dst[2*i] = 2 * dot(u.hb.even, u.history.window())
+		dst[2*i+1] = u.history.at(u.hb.k)
+	}
+}
+
+func (u *upsampler2x) reset() {
+	u.history.reset()
+}
+
+// downsampler2x halves the sample rate of a stream
+type downsampler2x struct {
+	hb   *Halfband
+	even *delayLine
+	odd  *delayLine
+}
+
+func newDownsampler2x(hb *Halfband) *downsampler2x {
+	return &downsampler2x{
+		hb:   hb,
+		even: newDelayLine(len(hb.even)),
+		odd:  newDelayLine(hb.k + 2),
+	}
+}
+
+// process writes len(src)/2 samples to dst
+func (d *downsampler2x) process(dst []float64, src []float64) {
+	for i := range dst {
+		d.even.push(src[2*i])
+		dst[i] = dot(d.hb.even, d.even.window()) + 0.5*d.odd.at(d.hb.k)
+		d.odd.push(src[2*i+1])
+	}
+}
+
+func (d *downsampler2x) reset() {
+	d.even.reset()
+	d.odd.reset()
+}
@@ -0,0 +1,221 @@
+// Package oversample implements 2x, 4x and 8x oversampling with cascaded polyphase half band filters
+package oversample
+
+import (
+	"errors"
+
+	"github.com/almerlucke/sndfile/float"
+)
+
+const (
+	// DefaultTransitionWidth of the half band filters as a ratio of the high rate, at 44.1kHz
+	// the passband of the first stage extends to about 19.8kHz
+	DefaultTransitionWidth = 0.05
+	// DefaultAttenuation of the half band filters in dB
+	DefaultAttenuation = 100.0
+)
+
+// Oversampler raises the sample rate of a signal by 2, 4 or 8 for processing and brings it back down
+// to the original rate afterwards. Each channel keeps its own filter state so blocks can be streamed.
+type Oversampler[T float.Float] struct {
+	factor      int
+	numChannels int
+	halfbands   []*Halfband
+	ups         [][]*upsampler2x
+	downs       [][]*downsampler2x
+	stageBuffer [][]float64
+	upOut       []T
+	downOut     []T
+}
+
+// New creates an oversampler with the default filter design
+func New[T float.Float](factor int, numChannels int) (*Oversampler[T], error) {
+	return NewWithDesign[T](factor, numChannels, DefaultTransitionWidth, DefaultAttenuation)
+}
+
+// NewWithDesign creates an oversampler, the first stage uses the given transition width (as a ratio
+// of the 2x rate) and attenuation, later stages have relatively more room and use wider transitions.
+func NewWithDesign[T float.Float](factor int, numChannels int, transitionWidth float64, attenuation float64) (*Oversampler[T], error) {
+	var numStages int
+
+	switch factor {
+	case 2:
+		numStages = 1
+	case 4:
+		numStages = 2
+	case 8:
+		numStages = 3
+	default:
+		return nil, errors.New("oversampling factor should be 2, 4 or 8")
+	}
+
+	if numChannels < 1 {
+		return nil, errors.New("number of channels should be at least 1")
+	}
+
+	o := &Oversampler[T]{
+		factor:      factor,
+		numChannels: numChannels,
+		halfbands:   make([]*Halfband, numStages),
+		ups:         make([][]*upsampler2x, numChannels),
+		downs:       make([][]*downsampler2x, numChannels),
+		stageBuffer: make([][]float64, numStages+1),
+	}
+
+	for s := range o.halfbands {
+		// The signal of interest occupies a smaller part of the spectrum in each next stage
+		width := transitionWidth
+		if s > 0 {
+			width = min(0.5-(0.5-transitionWidth)/float64(int(1)<<s), 0.45)
+		}
+
+		hb, err := NewHalfband(width, attenuation)
+		if err != nil {
+			return nil, err
+		}
+
+		o.halfbands[s] = hb
+	}
+
+	for c := 0; c < numChannels; c++ {
+		o.ups[c] = make([]*upsampler2x, numStages)
+		o.downs[c] = make([]*downsampler2x, numStages)
+
+		for s, hb := range o.halfbands {
+			o.ups[c][s] = newUpsampler2x(hb)
+			o.downs[c][s] = newDownsampler2x(hb)
+		}
+	}
+
+	return o, nil
+}
+
+// Factor returns the oversampling factor
+func (o *Oversampler[T]) Factor() int {
+	return o.factor
+}
+
+// NumChannels returns the number of channels
+func (o *Oversampler[T]) NumChannels() int {
+	return o.numChannels
+}
+
+// UpsampleLatency returns the delay of Upsample in samples at the original rate
+func (o *Oversampler[T]) UpsampleLatency() float64 {
+	var latency float64
+
+	for s, hb := range o.halfbands {
+		latency += float64(hb.Delay()) / float64(int(2)<<s)
+	}
+
+	return latency
+}
+
+// Latency returns the delay of an Upsample followed by a Downsample in samples at the original rate
+func (o *Oversampler[T]) Latency() float64 {
+	return 2 * o.UpsampleLatency()
+}
+
+// Reset clears the filter state of all channels
+func (o *Oversampler[T]) Reset() {
+	for c := 0; c < o.numChannels; c++ {
+		for s := range o.halfbands {
+			o.ups[c][s].reset()
+			o.downs[c][s].reset()
+		}
+	}
+}
+
+func (o *Oversampler[T]) buffer(stage int, n int) []float64 {
+	if cap(o.stageBuffer[stage]) < n {
+		o.stageBuffer[stage] = make([]float64, n)
+	}
+
+	return o.stageBuffer[stage][:n]
+}
+
+// Upsample raises the rate of a block of a channel, the returned block has Factor() times the input
+// length and is reused by the next call
+func (o *Oversampler[T]) Upsample(channel int, input []T) []T {
+	buf := o.buffer(0, len(input))
+	for i, x := range input {
+		buf[i] = float64(x)
+	}
+
+	for s, up := range o.ups[channel] {
+		next := o.buffer(s+1, 2*len(buf))
+		up.process(next, buf)
+		buf = next
+	}
+
+	o.upOut = resize(o.upOut, len(buf))
+	for i, x := range buf {
+		o.upOut[i] = T(x)
+	}
+
+	return o.upOut
+}
+
+// Downsample lowers the rate of a block of a channel, the input length should be a multiple of
+// Factor(). The returned block is reused by the next call.
+func (o *Oversampler[T]) Downsample(channel int, input []T) []T {
+	numStages := len(o.halfbands)
+
+	buf := o.buffer(numStages, len(input))
+	for i, x := range input {
+		buf[i] = float64(x)
+	}
+
+	for s := numStages - 1; s >= 0; s-- {
+		next := o.buffer(s, len(buf)/2)
+		o.downs[channel][s].process(next, buf)
+		buf = next
+	}
+
+	o.downOut = resize(o.downOut, len(buf))
+	for i, x := range buf {
+		o.downOut[i] = T(x)
+	}
+
+	return o.downOut
+}
+
+// Process upsamples a block of a channel, calls fn with the oversampled block so it can be processed
+// in place and downsamples the result back into block
+func (o *Oversampler[T]) Process(channel int, block []T, fn func(oversampled []T)) {
+	up := o.Upsample(channel, block)
+	fn(up)
+	copy(block, o.Downsample(channel, up))
+}
+
+func resize[T float.Float](buf []T, n int) []T {
+	if cap(buf) < n {
+		return make([]T, n)
+	}
+
+	return buf[:n]
+}
+
+// Decimate lowers the sample rate of a whole buffer by 2, 4 or 8 with half band filters. The output
+// is delayed by the filter latency, see Oversampler.UpsampleLatency for the amount.
+func Decimate[T float.Float](input []T, factor int) ([]T, error) {
+	o, err := New[T](factor, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(input) - len(input)%factor
+
+	return append([]T(nil), o.Downsample(0, input[:n])...), nil
+}
+
+// Interpolate raises the sample rate of a whole buffer by 2, 4 or 8 with half band filters. The output
+// is delayed by the filter latency, see Oversampler.UpsampleLatency for the amount.
+func Interpolate[T float.Float](input []T, factor int) ([]T, error) {
+	o, err := New[T](factor, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]T(nil), o.Upsample(0, input)...), nil
+}
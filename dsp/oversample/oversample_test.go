@@ -0,0 +1,175 @@
+package oversample
+
+import (
+	"math"
+	"testing"
+)
+
+const testFreq = 0.05
+
+func sine(n int, freq float64, delay float64) []float32 {
+	buf := make([]float32, n)
+	for i := range buf {
+		buf[i] = float32(math.Sin(2 * math.Pi * freq * (float64(i) - delay)))
+	}
+
+	return buf
+}
+
+func TestHalfband(t *testing.T) {
+	tests := []struct {
+		transitionWidth float64
+		attenuation     float64
+	}{
+		{0.05, 60},
+		{0.1, 80},
+		{0.2, 100},
+	}
+
+	for _, tt := range tests {
+		hb, err := NewHalfband(tt.transitionWidth, tt.attenuation)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		kernel := hb.Kernel()
+		center := hb.Delay()
+
+		if len(kernel) != hb.NumTaps() || len(kernel)%4 != 3 || center != len(kernel)/2 {
+			t.Fatalf("%d taps with delay %d", len(kernel), center)
+		}
+
+		if kernel[center] != 0.5 {
+			t.Fatalf("center tap %f", kernel[center])
+		}
+
+		var sum float64
+		for i, v := range kernel {
+			sum += v
+
+			if math.Abs(v-kernel[len(kernel)-1-i]) > 1e-15 {
+				t.Fatalf("kernel is not symmetric at %d", i)
+			}
+
+			// Every second tap away from the center is zero
+			if i != center && (i-center)%2 == 0 && v != 0 {
+				t.Fatalf("tap %d is %g", i, v)
+			}
+		}
+
+		if math.Abs(sum-1) > math.Pow(10, -tt.attenuation/20) {
+			t.Fatalf("dc gain %f", sum)
+		}
+	}
+
+	for _, width := range []float64{0, 0.5, -1} {
+		if _, err := NewHalfband(width, 60); err == nil {
+			t.Errorf("width %v: expected error", width)
+		}
+	}
+}
+
+func TestOversamplerRoundTrip(t *testing.T) {
+	n := 4096
+	blockSize := 256
+
+	for _, factor := range []int{2, 4, 8} {
+		o, err := New[float32](factor, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		x := sine(n, testFreq, 0)
+		var out, up []float32
+
+		for s := 0; s < n; s += blockSize {
+			block := append([]float32(nil), x[s:s+blockSize]...)
+			o.Process(1, block, func(oversampled []float32) {
+				if len(oversampled) != factor*blockSize {
+					t.Fatalf("factor %d: oversampled block of %d", factor, len(oversampled))
+				}
+				up = append(up, oversampled...)
+			})
+			out = append(out, block...)
+		}
+
+		// Up and down sampling both delay a band limited signal by the reported latency
+		for i, v := range sine(n*factor, testFreq/float64(factor), o.UpsampleLatency()*float64(factor)) {
+			if i >= 500*factor && math.Abs(float64(up[i]-v)) > 1e-4 {
+				t.Fatalf("factor %d: upsampled frame %d is %f, want %f", factor, i, up[i], v)
+			}
+		}
+
+		for i, v := range sine(n, testFreq, o.Latency()) {
+			if i >= 500 && math.Abs(float64(out[i]-v)) > 1e-4 {
+				t.Fatalf("factor %d: frame %d is %f, want %f", factor, i, out[i], v)
+			}
+		}
+
+		// Reset clears the filter state so the same input gives the same output
+		o.Reset()
+		first := append([]float32(nil), o.Upsample(0, x[:blockSize])...)
+		o.Reset()
+		if again := o.Upsample(0, x[:blockSize]); again[len(again)-1] != first[len(first)-1] {
+			t.Fatalf("factor %d: reset did not clear state", factor)
+		}
+	}
+}
+
+func TestDecimateInterpolate(t *testing.T) {
+	tests := []struct {
+		factor int
+		length int
+	}{
+		{2, 1000},
+		{4, 1001},
+		{8, 1024},
+	}
+
+	for _, tt := range tests {
+		up, err := Interpolate(sine(tt.length, testFreq, 0), tt.factor)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(up) != tt.length*tt.factor {
+			t.Fatalf("factor %d: interpolated %d samples", tt.factor, len(up))
+		}
+
+		down, err := Decimate(up, tt.factor)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(down) != tt.length {
+			t.Fatalf("factor %d: decimated %d samples", tt.factor, len(down))
+		}
+
+		if odd, _ := Decimate(make([]float32, 2*tt.factor+1), tt.factor); len(odd) != 2 {
+			t.Fatalf("factor %d: decimated %d samples from a partial frame", tt.factor, len(odd))
+		}
+	}
+}
+
+func TestOversamplerErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		factor      int
+		numChannels int
+	}{
+		{"factor 3", 3, 1},
+		{"factor 16", 16, 1},
+		{"factor 1", 1, 1},
+		{"no channels", 2, 0},
+	}
+
+	for _, tt := range tests {
+		if _, err := New[float64](tt.factor, tt.numChannels); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+
+	if _, err := Decimate([]float64{1, 2, 3, 4}, 6); err == nil {
+		t.Error("expected error for decimate factor 6")
+	}
+}
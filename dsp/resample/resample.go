@@ -0,0 +1,264 @@
+// Package resample implements a band-limited polyphase sample rate converter for arbitrary and
+// time-varying conversion ratios.
+package resample
+
+import (
+	"errors"
+	"math"
+
+	"github.com/almerlucke/sndfile/dsp/windows"
+	"github.com/almerlucke/sndfile/float"
+)
+
+// Quality of the conversion, the order and meaning match the libsamplerate converter types
+type Quality int
+
+const (
+	Best Quality = iota
+	Medium
+	Fastest
+	ZeroOrderHold
+	Linear
+)
+
+const (
+	// Number of filter table entries per zero crossing of the sinc
+	tablePhases = 512
+	// Largest supported ratio change, same limit as libsamplerate
+	maxRatio = 256.0
+)
+
+type filterDesign struct {
+	zeroCrossings int
+	rolloff       float64
+	beta          float64
+}
+
+var designs = map[Quality]filterDesign{
+	Best:    {zeroCrossings: 32, rolloff: 0.97, beta: 10.0},
+	Medium:  {zeroCrossings: 16, rolloff: 0.94, beta: 8.0},
+	Fastest: {zeroCrossings: 8, rolloff: 0.9, beta: 6.0},
+}
+
+// IsValidRatio returns true if ratio (output rate / input rate) can be used
+func IsValidRatio(ratio float64) bool {
+	return ratio >= 1.0/maxRatio && ratio <= maxRatio
+}
+
+// Resampler converts the sample rate of interleaved multichannel streams. The ratio is the output
+// sample rate divided by the input sample rate and can be changed between calls to Process.
+// Output is aligned with the input, output frame n corresponds to input time n/ratio.
+type Resampler[T float.Float] struct {
+	numChannels int
+	ratio       float64
+	quality     Quality
+	design      filterDesign
+	// Half of the windowed sinc sampled at tablePhases entries per zero crossing
+	table []float64
+	// Per channel input, buffers[c][0] is input frame bufferStart
+	buffers     [][]float64
+	bufferStart int64
+	inputFrames int64
+	// Input time of the next output frame
+	time   float64
+	output []T
+}
+
+// New creates a resampler for numChannels channels
+func New[T float.Float](numChannels int, ratio float64, quality Quality) (*Resampler[T], error) {
+	if numChannels < 1 {
+		return nil, errors.New("number of channels should be at least 1")
+	}
+
+	if !IsValidRatio(ratio) {
+		return nil, errors.New("invalid sample rate conversion ratio")
+	}
+
+	if quality < Best || quality > Linear {
+		return nil, errors.New("unknown sample rate conversion quality")
+	}
+
+	r := &Resampler[T]{
+		numChannels: numChannels,
+		ratio:       ratio,
+		quality:     quality,
+		buffers:     make([][]float64, numChannels),
+	}
+
+	if design, ok := designs[quality]; ok {
+		r.design = design
+		r.table = sincTable(design)
+	}
+
+	return r, nil
+}
+
+func sincTable(design filterDesign) []float64 {
+	n := design.zeroCrossings * tablePhases
+	table := make([]float64, n+2)
+	win := windows.Kaiser(design.beta)(2*n + 1)
+
+	for i := 0; i <= n; i++ {
+		t := float64(i) / tablePhases * design.rolloff
+		s := 1.0
+		if t != 0 {
+			s = math.Sin(math.Pi*t) / (math.Pi * t)
+		}
+		table[i] = design.rolloff * s * win[n+i]
+	}
+
+	return table
+}
+
+// NumChannels returns the number of channels
+func (r *Resampler[T]) NumChannels() int {
+	return r.numChannels
+}
+
+// Ratio returns the current conversion ratio
+func (r *Resampler[T]) Ratio() float64 {
+	return r.ratio
+}
+
+// SetRatio changes the conversion ratio for the next output frames
+func (r *Resampler[T]) SetRatio(ratio float64) error {
+	if !IsValidRatio(ratio) {
+		return errors.New("invalid sample rate conversion ratio")
+	}
+
+	r.ratio = ratio
+
+	return nil
+}
+
+// Reset clears all buffered input and starts a new stream
+func (r *Resampler[T]) Reset() {
+	for c := range r.buffers {
+		r.buffers[c] = r.buffers[c][:0]
+	}
+
+	r.bufferStart = 0
+	r.inputFrames = 0
+	r.time = 0
+}
+
+// width returns the filter half width in input frames and the filter scale for the current ratio
+func (r *Resampler[T]) width() (float64, float64) {
+	switch r.quality {
+	case ZeroOrderHold:
+		return 0, 1
+	case Linear:
+		return 1, 1
+	}
+
+	scale := min(1.0, r.ratio)
+
+	return float64(r.design.zeroCrossings) / scale, scale
+}
+
+// Process converts a block of interleaved input frames and returns the interleaved output frames
+// that could be computed. Set endOfInput on the last block to flush the remaining output. The
+// returned slice is reused by the next call.
+func (r *Resampler[T]) Process(input []T, endOfInput bool) []T {
+	numFrames := len(input) / r.numChannels
+
+	for c := range r.buffers {
+		buf := r.buffers[c]
+		for i := 0; i < numFrames; i++ {
+			buf = append(buf, float64(input[i*r.numChannels+c]))
+		}
+		r.buffers[c] = buf
+	}
+
+	r.inputFrames += int64(numFrames)
+	r.output = r.output[:0]
+
+	for r.time < float64(r.inputFrames) {
+		width, scale := r.width()
+
+		// Without the end of input all frames under the filter should be available
+		if !endOfInput && int64(math.Floor(r.time+width))+1 >= r.inputFrames {
+			break
+		}
+
+		for c := range r.buffers {
+			r.output = append(r.output, T(r.interpolate(r.buffers[c], r.time-float64(r.bufferStart), width, scale)))
+		}
+
+		r.time += 1.0 / r.ratio
+	}
+
+	// Drop input that is no longer needed, keep some extra history in case the ratio is lowered
+	width, _ := r.width()
+	keepFrom := min(int64(math.Floor(r.time-2*width))-1, r.inputFrames)
+
+	if drop := keepFrom - r.bufferStart; drop > 0 {
+		for c := range r.buffers {
+			n := copy(r.buffers[c], r.buffers[c][drop:])
+			r.buffers[c] = r.buffers[c][:n]
+		}
+
+		r.bufferStart += drop
+	}
+
+	if endOfInput {
+		r.Reset()
+	}
+
+	return r.output
+}
+
+// interpolate computes the band-limited value of buf at fractional index t, samples outside buf
+// are zero
+func (r *Resampler[T]) interpolate(buf []float64, t float64, width float64, scale float64) float64 {
+	switch r.quality {
+	case ZeroOrderHold:
+		i := int(math.Floor(t))
+		if i >= 0 && i < len(buf) {
+			return buf[i]
+		}
+		return 0
+	case Linear:
+		i := int(math.Floor(t))
+		frac := t - float64(i)
+		var s1, s2 float64
+		if i >= 0 && i < len(buf) {
+			s1 = buf[i]
+		}
+		if i+1 >= 0 && i+1 < len(buf) {
+			s2 = buf[i+1]
+		}
+		return s1 + frac*(s2-s1)
+	}
+
+	first := max(int(math.Floor(t-width))+1, 0)
+	last := min(int(math.Floor(t+width)), len(buf)-1)
+	step := scale * tablePhases
+	tableEnd := float64(len(r.table) - 2)
+
+	var sum float64
+
+	for i := first; i <= last; i++ {
+		idx := math.Abs(t-float64(i)) * step
+		if idx >= tableEnd {
+			continue
+		}
+
+		j := int(idx)
+		frac := idx - float64(j)
+		h := r.table[j] + frac*(r.table[j+1]-r.table[j])
+		sum += buf[i] * h
+	}
+
+	return sum * scale
+}
+
+// Resample converts a single channel buffer with the given ratio
+func Resample[T float.Float](input []T, ratio float64, quality Quality) ([]T, error) {
+	r, err := New[T](1, ratio, quality)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]T(nil), r.Process(input, true)...), nil
+}
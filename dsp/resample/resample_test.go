@@ -0,0 +1,140 @@
+package resample
+
+import (
+	"math"
+	"testing"
+)
+
+func sine(n int, freq float64, sampleRate float64) []float32 {
+	buf := make([]float32, n)
+	for i := range buf {
+		buf[i] = float32(math.Sin(2 * math.Pi * freq * float64(i) / sampleRate))
+	}
+
+	return buf
+}
+
+var ratios = []float64{48000.0 / 44100, 0.5, 44100.0 / 48000, 3}
+
+func TestResampleSineAccuracy(t *testing.T) {
+	tests := []struct {
+		quality  Quality
+		maxError float64
+	}{
+		{Best, 1e-5},
+		{Medium, 1e-4},
+		{Fastest, 1e-3},
+		{Linear, 5e-3},
+		{ZeroOrderHold, 0.2},
+	}
+
+	for _, tt := range tests {
+		for _, ratio := range ratios {
+			out, err := Resample(sine(20000, 1000, 44100), ratio, tt.quality)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want := int(math.Round(20000 * ratio)); math.Abs(float64(len(out)-want)) > 1 {
+				t.Errorf("quality %d, ratio %.4f: %d frames, want %d", tt.quality, ratio, len(out), want)
+			}
+
+			var maxError float64
+			for i := 1000; i < len(out)-1000; i++ {
+				ideal := math.Sin(2 * math.Pi * 1000 * float64(i) / (44100 * ratio))
+				maxError = max(maxError, math.Abs(float64(out[i])-ideal))
+			}
+
+			if maxError > tt.maxError {
+				t.Errorf("quality %d, ratio %.4f: error %g", tt.quality, ratio, maxError)
+			}
+		}
+	}
+}
+
+func TestResamplerStreaming(t *testing.T) {
+	in := sine(20000, 1000, 44100)
+
+	for _, quality := range []Quality{Best, Medium, Fastest, ZeroOrderHold, Linear} {
+		for _, ratio := range ratios {
+			whole, err := Resample(in, ratio, quality)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := New[float32](1, ratio, quality)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var blocks []float32
+			for s := 0; s < len(in); s += 333 {
+				end := min(s+333, len(in))
+				blocks = append(blocks, r.Process(in[s:end], end == len(in))...)
+			}
+
+			if len(blocks) != len(whole) {
+				t.Fatalf("quality %d, ratio %.4f: %d frames streamed, want %d", quality, ratio, len(blocks), len(whole))
+			}
+
+			for i := range whole {
+				if blocks[i] != whole[i] {
+					t.Fatalf("quality %d, ratio %.4f: frame %d differs", quality, ratio, i)
+				}
+			}
+		}
+	}
+}
+
+func TestResamplerInterleaved(t *testing.T) {
+	left := sine(4000, 500, 44100)
+	in := make([]float32, 2*len(left))
+	for i, v := range left {
+		in[2*i] = v
+		in[2*i+1] = -v
+	}
+
+	r, err := New[float32](2, 0.75, Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := r.Process(in, true)
+	mono, _ := Resample(left, 0.75, Medium)
+
+	if len(out) != 2*len(mono) {
+		t.Fatalf("%d samples, want %d", len(out), 2*len(mono))
+	}
+
+	for i, v := range mono {
+		if math.Abs(float64(out[2*i]-v)) > 1e-6 || math.Abs(float64(out[2*i+1]+v)) > 1e-6 {
+			t.Fatalf("frame %d differs", i)
+		}
+	}
+}
+
+func TestResamplerErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		numChannels int
+		ratio       float64
+		quality     Quality
+	}{
+		{"no channels", 0, 1, Best},
+		{"zero ratio", 1, 0, Best},
+		{"huge ratio", 1, 1e6, Best},
+		{"unknown quality", 1, 1, Quality(7)},
+		{"negative quality", 1, 1, Quality(-1)},
+	}
+
+	for _, tt := range tests {
+		if _, err := New[float32](tt.numChannels, tt.ratio, tt.quality); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+
+	r, _ := New[float32](1, 1, Best)
+	if err := r.SetRatio(-1); err == nil {
+		t.Error("expected error for negative ratio")
+	}
+}
@@ -0,0 +1,49 @@
+package windows
+
+import "github.com/almerlucke/sndfile/float"
+
+// Periodic turns a symmetric window function into its periodic version by generating a window
+// one sample longer and dropping the last sample. Periodic windows are preferred for spectral
+// analysis and overlap-add.
+func Periodic(f Function) Function {
+	return func(L int) []float64 {
+		return f(L + 1)[:L]
+	}
+}
+
+// Properties describes the spectral behaviour of a window
+type Properties struct {
+	// CoherentGain is the mean of the window, the gain applied to a bin centered sinusoid
+	CoherentGain float64
+	// EquivalentNoiseBandwidth in bins
+	EquivalentNoiseBandwidth float64
+}
+
+// Analyze calculates the coherent gain and equivalent noise bandwidth of a window
+func Analyze(w []float64) Properties {
+	var sum, sumSquares float64
+
+	for _, v := range w {
+		sum += v
+		sumSquares += v * v
+	}
+
+	N := float64(len(w))
+
+	return Properties{
+		CoherentGain:             sum / N,
+		EquivalentNoiseBandwidth: N * sumSquares / (sum * sum),
+	}
+}
+
+// Apply multiplies buf with window in place, window should be at least as long as buf
+func Apply[T float.Float](buf []T, window []float64) {
+	for i := range buf {
+		buf[i] = T(float64(buf[i]) * window[i])
+	}
+}
+
+// ApplyFunction multiplies buf in place with a window of the same length generated by f
+func ApplyFunction[T float.Float](buf []T, f Function) {
+	Apply(buf, f(len(buf)))
+}
@@ -0,0 +1,76 @@
+package windows
+
+import "math"
+
+// DolphChebyshev returns a Dolph-Chebyshev window function with equal side lobes attenuation dB
+// below the main lobe
+// See https://en.wikipedia.org/wiki/Window_function#Dolph%E2%80%93Chebyshev_window
+func DolphChebyshev(attenuation float64) Function {
+	return func(L int) []float64 {
+		r := make([]float64, L)
+		if L == 1 {
+			r[0] = 1
+			return r
+		}
+
+		order := float64(L - 1)
+		LF := float64(L)
+		beta := math.Cosh(math.Acosh(math.Pow(10, attenuation/20)) / order)
+
+		// Chebyshev polynomial samples of the frequency response
+		p := make([]complex128, L)
+		for k := 0; k < L; k++ {
+			x := beta * math.Cos(math.Pi*float64(k)/LF)
+			var t float64
+			switch {
+			case x > 1:
+				t = math.Cosh(order * math.Acosh(x))
+			case x < -1:
+				t = (1 - 2*float64((L-1)%2)) * math.Cosh(order*math.Acosh(-x))
+			default:
+				t = math.Cos(order * math.Acos(x))
+			}
+
+			p[k] = complex(t, 0)
+			if L%2 == 0 {
+				phase := math.Pi * float64(k) / LF
+				p[k] *= complex(math.Cos(phase), math.Sin(phase))
+			}
+		}
+
+		// Direct DFT, windows are short enough and this keeps the package free of dependencies
+		w := make([]float64, L)
+		for n := 0; n < L; n++ {
+			var sum float64
+			for k := 0; k < L; k++ {
+				phase := -twoPi * float64(n*k) / LF
+				sum += real(p[k])*math.Cos(phase) - imag(p[k])*math.Sin(phase)
+			}
+			w[n] = sum
+		}
+
+		// Rearrange so the peak is in the center
+		if L%2 == 1 {
+			n := (L + 1) / 2
+			for i := 0; i < n; i++ {
+				r[n-1+i] = w[i]
+				r[n-1-i] = w[i]
+			}
+		} else {
+			n := L/2 + 1
+			for i := 1; i < n; i++ {
+				r[n-2+i] = w[i]
+				r[n-1-i] = w[i]
+			}
+		}
+
+		peak := 0.0
+		for _, v := range r {
+			peak = max(peak, v)
+		}
+		for i := range r {
+			r[i] /= peak
+		}
+		return r
+	}
+}
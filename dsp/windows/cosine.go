@@ -0,0 +1,103 @@
+package windows
+
+import "math"
+
+// cosineSum generates a symmetric generalized cosine window with alternating signs
+// w[n] = a0 - a1 cos(2 pi n/(L-1)) + a2 cos(4 pi n/(L-1)) - ...
+func cosineSum(L int, coefs ...float64) []float64 {
+	r := make([]float64, L)
+	if L == 1 {
+		r[0] = 1
+		return r
+	}
+
+	LF := float64(L)
+	for i := 0; i < L; i++ {
+		x := twoPi * float64(i) / (LF - 1)
+		sign := 1.0
+		for k, a := range coefs {
+			r[i] += sign * a * math.Cos(float64(k)*x)
+			sign = -sign
+		}
+	}
+	return r
+}
+
+// Hann generates a Hann window of the requested size
+// See https://en.wikipedia.org/wiki/Window_function#Hann_and_Hamming_windows
+func Hann(L int) []float64 {
+	return cosineSum(L, 0.5, 0.5)
+}
+
+// BlackmanHarris generates a 4-term Blackman-Harris window of the requested size
+// See https://en.wikipedia.org/wiki/Window_function#Blackman%E2%80%93Harris_window
+func BlackmanHarris(L int) []float64 {
+	return cosineSum(L, 0.35875, 0.48829, 0.14128, 0.01168)
+}
+
+// FlatTop generates a flat-top window of the requested size, it has very little scalloping loss
+// which makes it the window of choice for amplitude measurements
+// See https://en.wikipedia.org/wiki/Window_function#Flat_top_window
+func FlatTop(L int) []float64 {
+	return cosineSum(L, 0.21557895, 0.41663158, 0.277263158, 0.083578947, 0.006947368)
+}
+
+// Sine generates a sine window of the requested size
+// See https://en.wikipedia.org/wiki/Window_function#Sine_window
+func Sine(L int) []float64 {
+	r := make([]float64, L)
+	if L == 1 {
+		r[0] = 1
+		return r
+	}
+
+	LF := float64(L)
+	for i := 0; i < L; i++ {
+		r[i] = math.Sin(math.Pi * float64(i) / (LF - 1))
+	}
+	return r
+}
+
+// Blackman generates a Blackman window of the requested size
+// See https://en.wikipedia.org/wiki/Window_function#Blackman_windows
+func Blackman(L int) []float64 {
+	r := make([]float64, L)
+	LF := float64(L)
+	alpha := 0.16
+	a0 := (1 - alpha) / 2.0
+	a1 := 0.5
+	a2 := alpha / 2.0
+
+	for i := 0; i < L; i++ {
+		iF := float64(i)
+		r[i] = a0 - (a1 * math.Cos((twoPi*iF)/(LF-1))) + (a2 * math.Cos((fourPi*iF)/(LF-1)))
+	}
+	return r
+}
+
+// Hamming generates a Hamming window of the requested size
+// See https://en.wikipedia.org/wiki/Window_function#Hamming_window
+func Hamming(L int) []float64 {
+	r := make([]float64, L)
+	alpha := 0.54
+	beta := 1.0 - alpha
+	Lf := float64(L)
+
+	for i := 0; i < L; i++ {
+		r[i] = alpha - (beta * math.Cos((twoPi*float64(i))/(Lf-1)))
+	}
+	return r
+}
+
+// Nuttall generates a Blackman-Nutall window
+// See https://en.wikipedia.org/wiki/Window_function#Nuttall_window.2C_continuous_first_derivative
+func Nuttall(L int) []float64 {
+	r := make([]float64, L)
+	LF := float64(L)
+	for i := 0; i < L; i++ {
+		iF := float64(i)
+		r[i] = 0.355768 - 0.487396*math.Cos((twoPi*iF)/(LF-1)) + 0.144232*math.Cos((fourPi*iF)/(LF-1)) - 0.012604*math.Cos((sixPi*iF)/(LF-1))
+	}
+
+	return r
+}
@@ -0,0 +1,23 @@
+package windows
+
+import "math"
+
+// Gaussian returns a Gaussian window function, sigma is the standard deviation relative to half
+// the window length and should be 0.5 or less
+// See https://en.wikipedia.org/wiki/Window_function#Gaussian_window
+func Gaussian(sigma float64) Function {
+	return func(L int) []float64 {
+		r := make([]float64, L)
+		if L == 1 {
+			r[0] = 1
+			return r
+		}
+
+		half := float64(L-1) / 2
+		for i := 0; i < L; i++ {
+			x := (float64(i) - half) / (sigma * half)
+			r[i] = math.Exp(-0.5 * x * x)
+		}
+		return r
+	}
+}
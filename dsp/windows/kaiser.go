@@ -0,0 +1,41 @@
+package windows
+
+import "math"
+
+// Kaiser returns a Kaiser window function with shape parameter beta, larger values of beta
+// give a wider main lobe and lower side lobes
+// See https://en.wikipedia.org/wiki/Kaiser_window
+func Kaiser(beta float64) Function {
+	return func(L int) []float64 {
+		r := make([]float64, L)
+		if L == 1 {
+			r[0] = 1
+			return r
+		}
+
+		norm := BesselI0(beta)
+		LF := float64(L)
+
+		for i := 0; i < L; i++ {
+			x := 2*float64(i)/(LF-1) - 1
+			r[i] = BesselI0(beta*math.Sqrt(1-x*x)) / norm
+		}
+		return r
+	}
+}
+
+// BesselI0 computes the zeroth order modified Bessel function of the first kind
+func BesselI0(x float64) float64 {
+	sum := 1.0
+	term := 1.0
+	halfX := x / 2
+
+	for k := 1; k < 500; k++ {
+		term *= (halfX / float64(k)) * (halfX / float64(k))
+		sum += term
+		if term < sum*1e-17 {
+			break
+		}
+	}
+	return sum
+}
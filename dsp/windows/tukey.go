@@ -0,0 +1,34 @@
+package windows
+
+import "math"
+
+// Tukey returns a tapered cosine window function, alpha is the fraction of the window inside the
+// cosine tapers. An alpha of 0 gives a rectangular and an alpha of 1 a Hann window.
+// See https://en.wikipedia.org/wiki/Window_function#Tukey_window
+func Tukey(alpha float64) Function {
+	return func(L int) []float64 {
+		r := make([]float64, L)
+		if L == 1 || alpha <= 0 {
+			for i := range r {
+				r[i] = 1
+			}
+			return r
+		}
+
+		N := float64(L - 1)
+		edge := min(alpha, 1) * N / 2
+
+		for i := 0; i < L; i++ {
+			n := float64(i)
+			switch {
+			case n < edge:
+				r[i] = 0.5 * (1 - math.Cos(math.Pi*n/edge))
+			case n > N-edge:
+				r[i] = 0.5 * (1 - math.Cos(math.Pi*(N-n)/edge))
+			default:
+				r[i] = 1
+			}
+		}
+		return r
+	}
+}
@@ -0,0 +1,177 @@
+package windows
+
+import (
+	"math"
+	"testing"
+)
+
+// sideLobeDB returns the highest side lobe level relative to the main lobe in dB by evaluating
+// the window spectrum beyond the first null
+func sideLobeDB(w []float64) float64 {
+	n := len(w) * 16
+	mags := make([]float64, n/2)
+
+	for k := range mags {
+		var re, im float64
+		for i, v := range w {
+			phase := twoPi * float64(k*i) / float64(n)
+			re += v * math.Cos(phase)
+			im -= v * math.Sin(phase)
+		}
+		mags[k] = math.Hypot(re, im)
+	}
+
+	k := 1
+	for k < len(mags)-1 && mags[k+1] < mags[k] {
+		k++
+	}
+
+	var side float64
+	for _, m := range mags[k:] {
+		side = max(side, m)
+	}
+
+	return 20 * math.Log10(side/mags[0])
+}
+
+func TestWindowShapes(t *testing.T) {
+	tests := []struct {
+		name   string
+		f      Function
+		length int
+		ends   float64
+	}{
+		{"hann", Hann, 65, 0},
+		{"blackman harris", BlackmanHarris, 64, 0.00006},
+		{"flat top", FlatTop, 65, -0.000421},
+		{"sine", Sine, 33, 0},
+		{"gaussian", Gaussian(0.4), 65, math.Exp(-0.5 / 0.16)},
+		{"tukey", Tukey(0.5), 65, 0},
+		{"dolph chebyshev odd", DolphChebyshev(60), 33, -1},
+		{"dolph chebyshev even", DolphChebyshev(60), 32, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := tt.f(tt.length)
+			if len(w) != tt.length {
+				t.Fatalf("length %d", len(w))
+			}
+
+			for i := range w {
+				if math.Abs(w[i]-w[len(w)-1-i]) > 1e-9 {
+					t.Fatalf("not symmetric at %d", i)
+				}
+			}
+
+			if tt.length%2 == 1 && math.Abs(w[tt.length/2]-1) > 1e-6 {
+				t.Fatalf("center %f, want 1", w[tt.length/2])
+			}
+
+			if tt.ends >= -0.5 && math.Abs(w[0]-tt.ends) > 1e-6 {
+				t.Fatalf("first sample %f, want %f", w[0], tt.ends)
+			}
+
+			if one := tt.f(1); len(one) != 1 || one[0] != 1 {
+				t.Fatalf("length 1 window %v", one)
+			}
+		})
+	}
+}
+
+func TestTukey(t *testing.T) {
+	hann := Hann(65)
+
+	tests := []struct {
+		alpha float64
+		want  []float64
+	}{
+		{0, rectangular(65)},
+		{1, hann},
+		{2, hann},
+	}
+
+	for _, tt := range tests {
+		w := Tukey(tt.alpha)(65)
+		for i := range w {
+			if math.Abs(w[i]-tt.want[i]) > 1e-12 {
+				t.Fatalf("alpha %v: sample %d is %f, want %f", tt.alpha, i, w[i], tt.want[i])
+			}
+		}
+	}
+
+	// The closure can be reused without changing its shape
+	f := Tukey(2)
+	a, b := f(33), f(33)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatal("window changed between calls")
+		}
+	}
+}
+
+func TestDolphChebyshevSideLobes(t *testing.T) {
+	for _, attenuation := range []float64{40, 60, 80} {
+		for _, length := range []int{31, 32} {
+			if got := sideLobeDB(DolphChebyshev(attenuation)(length)); math.Abs(got+attenuation) > 0.5 {
+				t.Errorf("%v dB, length %d: side lobes at %.2f dB", attenuation, length, got)
+			}
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name string
+		w    []float64
+		cg   float64
+		enbw float64
+	}{
+		{"rectangular", rectangular(1024), 1, 1},
+		{"hann", Periodic(Hann)(1024), 0.5, 1.5},
+		{"blackman harris", Periodic(BlackmanHarris)(1024), 0.35875, 2.0044},
+		{"flat top", Periodic(FlatTop)(1024), 0.21557895, 3.7702},
+	}
+
+	for _, tt := range tests {
+		p := Analyze(tt.w)
+
+		if math.Abs(p.CoherentGain-tt.cg) > 1e-4 {
+			t.Errorf("%s: coherent gain %f, want %f", tt.name, p.CoherentGain, tt.cg)
+		}
+
+		if math.Abs(p.EquivalentNoiseBandwidth-tt.enbw) > 1e-3 {
+			t.Errorf("%s: ENBW %f, want %f", tt.name, p.EquivalentNoiseBandwidth, tt.enbw)
+		}
+	}
+}
+
+func TestPeriodic(t *testing.T) {
+	w := Periodic(Hann)(8)
+	full := Hann(9)
+
+	if len(w) != 8 || w[0] != 0 || w[4] != full[4] {
+		t.Fatalf("periodic window %v", w)
+	}
+}
+
+func TestApply(t *testing.T) {
+	buf := []float32{2, 2, 2, 2, 2}
+	ApplyFunction(buf, Hann)
+
+	want := []float32{0, 1, 2, 1, 0}
+	for i := range buf {
+		if math.Abs(float64(buf[i]-want[i])) > 1e-6 {
+			t.Fatalf("sample %d is %f, want %f", i, buf[i], want[i])
+		}
+	}
+}
+
+func rectangular(L int) []float64 {
+	r := make([]float64, L)
+	for i := range r {
+		r[i] = 1
+	}
+
+	return r
+}
@@ -3,6 +3,5 @@ module github.com/almerlucke/sndfile
 go 1.26.4
 
 require (
-	github.com/dh1tw/gosamplerate v0.1.2
 	github.com/mkb218/gosndfile v0.0.0-20171006180803-e0c9ef895ee2
 )
@@ -0,0 +1,298 @@
+// Package granular implements a grain cloud engine that plays grains from any SoundFiler
+package granular
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+
+	"github.com/almerlucke/sndfile"
+	"github.com/almerlucke/sndfile/dsp/windows"
+	"github.com/almerlucke/sndfile/float"
+	"github.com/almerlucke/sndfile/writer"
+)
+
+const (
+	DefaultMaxGrains = 128
+	envelopeSize     = 1024
+)
+
+// Parameter is a grain parameter with a base value and a random deviation, every grain gets a value
+// uniformly distributed in [Value - Jitter, Value + Jitter]
+type Parameter struct {
+	Value  float64
+	Jitter float64
+}
+
+func (p Parameter) sample(rng *rand.Rand) float64 {
+	if p.Jitter == 0 {
+		return p.Value
+	}
+
+	return p.Value + p.Jitter*(2*rng.Float64()-1)
+}
+
+// Params control the grain cloud
+type Params struct {
+	// Position of the grain start in the source, normalized from 0 to 1
+	Position Parameter
+	// Duration of a grain in seconds
+	Duration Parameter
+	// Pitch of a grain in semitones
+	Pitch Parameter
+	// Pan of a grain from -1 (left) to 1 (right)
+	Pan Parameter
+	// Amplitude of a grain
+	Amplitude Parameter
+	// Density in grains per second
+	Density float64
+	// DensityJitter randomizes the time between grains, from 0 (regular) to 1
+	DensityJitter float64
+	// Envelope shape of a grain, nil means a Hann window
+	Envelope windows.Function
+	// ZeroCrossings starts grains at the nearest upward zero crossing of the first source channel
+	ZeroCrossings bool
+}
+
+// DefaultParams returns parameters for a steady cloud of 50ms grains from the start of the source
+func DefaultParams() Params {
+	return Params{
+		Duration:  Parameter{Value: 0.05},
+		Amplitude: Parameter{Value: 0.5},
+		Density:   20,
+	}
+}
+
+type grain struct {
+	active bool
+	// Source position in frames and step per output frame
+	pos   float64
+	speed float64
+	depth int
+	// Length and age in output frames
+	length int
+	age    int
+	gainL  float64
+	gainR  float64
+}
+
+// Cloud schedules and renders grains from a SoundFiler, rendering is deterministic for a given seed
+// and does not allocate
+type Cloud[T float.Float] struct {
+	source     sndfile.SoundFiler[T]
+	sampleRate float64
+	params     Params
+	rng        *rand.Rand
+	grains     []grain
+	envelope   []float64
+	nextOnset  float64
+	block      []T
+}
+
+// NewCloud creates a grain cloud rendering at sampleRate with room for maxGrains simultaneous grains
+func NewCloud[T float.Float](source sndfile.SoundFiler[T], sampleRate float64, maxGrains int, seed uint64, params Params) (*Cloud[T], error) {
+	if source.NumFrames() < 2 {
+		return nil, errors.New("source should have at least 2 frames")
+	}
+
+	if maxGrains <= 0 {
+		maxGrains = DefaultMaxGrains
+	}
+
+	c := &Cloud[T]{
+		source:     source,
+		sampleRate: sampleRate,
+		rng:        rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15)),
+		grains:     make([]grain, maxGrains),
+	}
+
+	c.SetParams(params)
+
+	return c, nil
+}
+
+// SetParams changes the cloud parameters, grains that are playing keep their settings. A density of
+// 0 or less pauses the cloud, when the density becomes positive again a grain starts right away.
+func (c *Cloud[T]) SetParams(params Params) {
+	if params.Envelope == nil {
+		params.Envelope = windows.Hann
+	}
+
+	if c.params.Density <= 0 && params.Density > 0 {
+		c.nextOnset = 0
+	}
+
+	c.params = params
+	c.envelope = params.Envelope(envelopeSize)
+}
+
+// Params returns the current parameters
+func (c *Cloud[T]) Params() Params {
+	return c.params
+}
+
+// ActiveGrains returns the number of grains currently playing
+func (c *Cloud[T]) ActiveGrains() int {
+	n := 0
+	for i := range c.grains {
+		if c.grains[i].active {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (c *Cloud[T]) spawn() {
+	var g *grain
+
+	for i := range c.grains {
+		if !c.grains[i].active {
+			g = &c.grains[i]
+			break
+		}
+	}
+
+	// All grains are in use, skip this one
+	if g == nil {
+		return
+	}
+
+	p := &c.params
+	numFrames := c.source.NumFrames()
+
+	pos := min(max(p.Position.sample(c.rng), 0), 1) * float64(numFrames-1)
+
+	if p.ZeroCrossings {
+		if zc := c.source.ZeroCrossings(0); len(zc) > 0 {
+			pos = float64(zc.NearestPosFrames(int64(pos), sndfile.DirectionUp).PositionFrames)
+		}
+	}
+
+	speed := math.Pow(2, p.Pitch.sample(c.rng)/12) * c.source.SampleRate() / c.sampleRate
+	depth := min(sndfile.SpeedToMipMapDepth(speed), c.source.Depth()-1)
+	pan := min(max(p.Pan.sample(c.rng), -1), 1)
+	amp := p.Amplitude.sample(c.rng)
+	angle := (pan + 1) * math.Pi / 4
+
+	*g = grain{
+		active: true,
+		pos:    pos,
+		speed:  speed,
+		depth:  depth,
+		length: max(int(p.Duration.sample(c.rng)*c.sampleRate), 1),
+		gainL:  amp * math.Cos(angle),
+		gainR:  amp * math.Sin(angle),
+	}
+}
+
+// scheduleNext returns the number of frames until the next grain, the density should be positive
+func (c *Cloud[T]) scheduleNext() float64 {
+	interval := c.sampleRate / c.params.Density
+	jitter := min(max(c.params.DensityJitter, 0), 1)
+
+	return interval * (1 + jitter*(2*c.rng.Float64()-1))
+}
+
+func (c *Cloud[T]) envelopeAt(phase float64) float64 {
+	idx := phase * float64(envelopeSize-1)
+	i := int(idx)
+	if i >= envelopeSize-1 {
+		return c.envelope[envelopeSize-1]
+	}
+
+	frac := idx - float64(i)
+
+	return c.envelope[i] + frac*(c.envelope[i+1]-c.envelope[i])
+}
+
+// Render adds the next len(left) frames of the cloud to left and right
+func (c *Cloud[T]) Render(left []T, right []T) {
+	numChannels := c.source.NumChannels()
+	lastFrame := float64(c.source.NumFrames() - 1)
+
+	for i := range left {
+		if c.params.Density > 0 {
+			for c.nextOnset <= 0 {
+				c.spawn()
+				c.nextOnset += c.scheduleNext()
+			}
+
+			c.nextOnset--
+		}
+
+		var l, r float64
+
+		for gi := range c.grains {
+			g := &c.grains[gi]
+			if !g.active {
+				continue
+			}
+
+			if g.pos >= 0 && g.pos < lastFrame {
+				env := c.envelopeAt(float64(g.age) / float64(g.length))
+				frame := c.source.LookupAll(g.pos, g.depth, false)
+
+				switch numChannels {
+				case 1:
+					v := float64(frame[0]) * env
+					l += v * g.gainL
+					r += v * g.gainR
+				default:
+					l += float64(frame[0]) * env * g.gainL
+					r += float64(frame[1]) * env * g.gainR
+				}
+			}
+
+			g.pos += g.speed
+			g.age++
+
+			if g.age >= g.length {
+				g.active = false
+			}
+		}
+
+		left[i] += T(l)
+		right[i] += T(r)
+	}
+}
+
+// RenderInterleaved writes the next len(out)/2 stereo frames of the cloud to out
+func (c *Cloud[T]) RenderInterleaved(out []T) {
+	numFrames := len(out) / 2
+
+	if cap(c.block) < 2*numFrames {
+		c.block = make([]T, 2*numFrames)
+	}
+
+	left := c.block[:numFrames]
+	right := c.block[numFrames : 2*numFrames]
+
+	clear(left)
+	clear(right)
+
+	c.Render(left, right)
+
+	for i := 0; i < numFrames; i++ {
+		out[2*i] = left[i]
+		out[2*i+1] = right[i]
+	}
+}
+
+// RenderToWriter renders numFrames stereo frames in blocks of blockSize interleaved frames to a
+// writer, which should be created for 2 channels with an input converter for interleaved []T of
+// blockSize frames (i.e. writer.NewTypeConverter[T]). numFrames is rounded up to a whole block.
+func (c *Cloud[T]) RenderToWriter(w *writer.Writer, numFrames int64, blockSize int) error {
+	block := make([]T, 2*blockSize)
+
+	for written := int64(0); written < numFrames; written += int64(blockSize) {
+		c.RenderInterleaved(block)
+
+		err := w.Write(block, written+int64(blockSize) >= numFrames)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
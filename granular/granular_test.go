@@ -0,0 +1,203 @@
+package granular
+
+import (
+	"math"
+	"testing"
+
+	"github.com/almerlucke/sndfile"
+)
+
+func sineSource(numFrames int) *sndfile.SoundFile[float32] {
+	x := make([]float32, numFrames)
+	for i := range x {
+		x[i] = float32(math.Sin(2 * math.Pi * 440 * float64(i) / 44100))
+	}
+
+	return sndfile.NewSoundFileFromBuffers([][]float32{x}, 44100)
+}
+
+func render(c *Cloud[float32], numFrames int) ([]float32, []float32) {
+	left := make([]float32, numFrames)
+	right := make([]float32, numFrames)
+	c.Render(left, right)
+
+	return left, right
+}
+
+func TestCloudDeterministic(t *testing.T) {
+	p := DefaultParams()
+	p.Position = Parameter{Value: 0.5, Jitter: 0.3}
+	p.Pitch = Parameter{Jitter: 2}
+	p.Pan = Parameter{Jitter: 1}
+	p.Density = 100
+	p.DensityJitter = 0.5
+	p.ZeroCrossings = true
+
+	run := func(seed uint64) []float32 {
+		c, err := NewCloud[float32](sineSource(44100), 48000, 0, seed, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := make([]float32, 2*48128)
+		for s := 0; s < len(out); s += 512 {
+			c.RenderInterleaved(out[s : s+512])
+		}
+
+		return out
+	}
+
+	a, b, other := run(42), run(42), run(43)
+
+	var peak float64
+	same := true
+
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("sample %d differs between runs with the same seed", i)
+		}
+		same = same && a[i] == other[i]
+		peak = max(peak, math.Abs(float64(a[i])))
+	}
+
+	if same {
+		t.Fatal("different seeds gave the same output")
+	}
+
+	if peak == 0 || peak > 10 {
+		t.Fatalf("peak %f", peak)
+	}
+}
+
+func TestCloudDensity(t *testing.T) {
+	tests := []struct {
+		name    string
+		density float64
+		frames  int
+		grains  int
+	}{
+		{"one grain", 1, 1000, 1},
+		{"regular", 100, 4800, 10},
+		{"paused", 0, 4800, 0},
+		{"negative", -5, 4800, 0},
+	}
+
+	for _, tt := range tests {
+		p := DefaultParams()
+		p.Density = tt.density
+		// Grains outlive the render so every spawned grain is still active
+		p.Duration = Parameter{Value: 1}
+
+		c, err := NewCloud[float32](sineSource(44100), 48000, 0, 1, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		left, _ := render(c, tt.frames)
+
+		if got := c.ActiveGrains(); got != tt.grains {
+			t.Errorf("%s: %d grains, want %d", tt.name, got, tt.grains)
+		}
+
+		if tt.grains == 0 {
+			for i, v := range left {
+				if v != 0 {
+					t.Fatalf("%s: sample %d is %f while paused", tt.name, i, v)
+				}
+			}
+		}
+	}
+}
+
+func TestCloudResume(t *testing.T) {
+	p := DefaultParams()
+	p.Density = 0
+	p.Duration = Parameter{Value: 1}
+
+	c, err := NewCloud[float32](sineSource(44100), 48000, 0, 1, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	render(c, 10000)
+
+	if c.ActiveGrains() != 0 {
+		t.Fatal("grains spawned while paused")
+	}
+
+	// Resuming starts a grain right away and then follows the new density
+	p.Density = 1000
+	c.SetParams(p)
+	render(c, 1)
+
+	if got := c.ActiveGrains(); got != 1 {
+		t.Fatalf("%d grains after resuming", got)
+	}
+
+	render(c, 479)
+
+	if got := c.ActiveGrains(); got != 10 {
+		t.Fatalf("%d grains after 10ms", got)
+	}
+}
+
+func TestCloudMaxGrains(t *testing.T) {
+	p := DefaultParams()
+	p.Density = 10000
+	p.Duration = Parameter{Value: 1}
+
+	c, err := NewCloud[float32](sineSource(44100), 48000, 8, 1, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	render(c, 4800)
+
+	if got := c.ActiveGrains(); got != 8 {
+		t.Fatalf("%d grains, want 8", got)
+	}
+}
+
+func TestCloudPan(t *testing.T) {
+	tests := []struct {
+		name      string
+		pan       float64
+		wantLeft  bool
+		wantRight bool
+	}{
+		{"hard left", -1, true, false},
+		{"center", 0, true, true},
+		{"hard right", 1, false, true},
+	}
+
+	for _, tt := range tests {
+		p := DefaultParams()
+		p.Position = Parameter{Value: 0.5}
+		p.Pan = Parameter{Value: tt.pan}
+
+		c, err := NewCloud[float32](sineSource(44100), 44100, 0, 1, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		left, right := render(c, 4410)
+
+		var l, r float64
+		for i := range left {
+			l = max(l, math.Abs(float64(left[i])))
+			r = max(r, math.Abs(float64(right[i])))
+		}
+
+		if (l > 1e-6) != tt.wantLeft || (r > 1e-6) != tt.wantRight {
+			t.Errorf("%s: left peak %f, right peak %f", tt.name, l, r)
+		}
+	}
+}
+
+func TestNewCloudErrors(t *testing.T) {
+	short := sndfile.NewSoundFileFromBuffers([][]float32{{1}}, 44100)
+
+	if _, err := NewCloud[float32](short, 44100, 0, 1, DefaultParams()); err == nil {
+		t.Fatal("expected error for a single frame source")
+	}
+}
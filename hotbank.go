@@ -0,0 +1,276 @@
+package sndfile
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/almerlucke/sndfile/dsp/resample"
+	"github.com/almerlucke/sndfile/float"
+)
+
+// ChangeKind is the kind of a HotSoundBank change event
+type ChangeKind int
+
+const (
+	// Reloaded means the entry was replaced with the changed file
+	Reloaded ChangeKind = iota
+	// ReloadFailed means the changed file could not be loaded, the old sound is kept
+	ReloadFailed
+	// SourceMissing means the source file disappeared, the old sound is kept
+	SourceMissing
+)
+
+// ChangeEvent is emitted when a watched source file changes
+type ChangeEvent struct {
+	Name string
+	Path string
+	Kind ChangeKind
+	Err  error
+}
+
+type watchedSource struct {
+	entry   ManifestEntry
+	modTime time.Time
+	size    int64
+	missing bool
+}
+
+// HotSoundBank is a SafeSoundBank that polls the source files of watched entries and reloads
+// them in the background when their modification time or size changes. Entries are swapped
+// atomically, readers get either the old or the new sound.
+type HotSoundBank[T float.Float] struct {
+	*SafeSoundBank[T]
+	mu      sync.Mutex
+	sources map[string]*watchedSource
+	events  chan ChangeEvent
+	load    func(ManifestEntry) (SoundFiler[T], error)
+	stop    chan struct{}
+	done    chan struct{}
+	closed  bool
+}
+
+// NewHotSoundBank creates a HotSoundBank that polls every interval, an interval of 0 disables
+// background polling so Poll has to be called manually. The event channel holds eventBuffer
+// events, events are dropped when it is full.
+func NewHotSoundBank[T float.Float](interval time.Duration, eventBuffer int, quality resample.Quality) *HotSoundBank[T] {
+	hb := &HotSoundBank[T]{
+		SafeSoundBank: NewSafeSoundBank[T](nil),
+		sources:       map[string]*watchedSource{},
+		events:        make(chan ChangeEvent, eventBuffer),
+		load: func(entry ManifestEntry) (SoundFiler[T], error) {
+			return LoadEntry[T](entry, quality)
+		},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	if interval > 0 {
+		go hb.run(interval)
+	} else {
+		close(hb.done)
+	}
+
+	return hb
+}
+
+// SetLoader replaces the function that decodes entries, by default entries are loaded with
+// LoadEntry
+func (hb *HotSoundBank[T]) SetLoader(load func(ManifestEntry) (SoundFiler[T], error)) {
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+
+	hb.load = load
+}
+
+// Events returns the channel with change events, it is closed by Close
+func (hb *HotSoundBank[T]) Events() <-chan ChangeEvent {
+	return hb.events
+}
+
+// Watch loads an entry and watches its source file for changes
+func (hb *HotSoundBank[T]) Watch(entry ManifestEntry, tags ...string) error {
+	if entry.Name == "" {
+		entry.Name = entryName(".", entry.Path)
+	}
+
+	hb.mu.Lock()
+	load := hb.load
+	hb.mu.Unlock()
+
+	// The file is decoded without holding the lock so a slow decode does not block the bank
+	info, err := os.Stat(entry.Path)
+	if err != nil {
+		return err
+	}
+
+	sf, err := load(entry)
+	if err != nil {
+		return &LoadError{Entry: entry, Err: err}
+	}
+
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+
+	if err := hb.Set(entry.Name, sf, tags...); err != nil {
+		return err
+	}
+
+	hb.sources[entry.Name] = &watchedSource{
+		entry:   entry,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+	}
+
+	return nil
+}
+
+// Remove stops watching an entry and deletes it and its aliases, removing an alias only
+// deletes the alias
+func (hb *HotSoundBank[T]) Remove(name string) {
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+
+	delete(hb.sources, name)
+	hb.SafeSoundBank.Remove(name)
+}
+
+// Unwatch stops watching an entry, the entry stays in the bank
+func (hb *HotSoundBank[T]) Unwatch(name string) {
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+
+	delete(hb.sources, name)
+}
+
+func (hb *HotSoundBank[T]) emit(event ChangeEvent) {
+	select {
+	case hb.events <- event:
+	default:
+	}
+}
+
+// polledSource is a watched source with its state at the start of a poll
+type polledSource struct {
+	name  string
+	src   *watchedSource
+	state watchedSource
+}
+
+// Poll checks all watched source files once and reloads the changed ones, Poll does nothing
+// after Close. Files are checked and decoded without holding the lock, a source that is
+// unwatched, removed or watched again in the meantime is left alone.
+func (hb *HotSoundBank[T]) Poll() {
+	hb.mu.Lock()
+
+	if hb.closed {
+		hb.mu.Unlock()
+		return
+	}
+
+	polled := make([]polledSource, 0, len(hb.sources))
+	for name, src := range hb.sources {
+		polled = append(polled, polledSource{name: name, src: src, state: *src})
+	}
+
+	load := hb.load
+	hb.mu.Unlock()
+
+	for _, p := range polled {
+		path := p.state.entry.Path
+
+		info, err := os.Stat(path)
+		if err != nil {
+			if !p.state.missing {
+				hb.mu.Lock()
+
+				if hb.current(p) && !p.src.missing {
+					p.src.missing = true
+					hb.emit(ChangeEvent{Name: p.name, Path: path, Kind: SourceMissing, Err: err})
+				}
+
+				hb.mu.Unlock()
+			}
+
+			continue
+		}
+
+		if !p.state.missing && info.ModTime().Equal(p.state.modTime) && info.Size() == p.state.size {
+			continue
+		}
+
+		sf, err := load(p.state.entry)
+
+		hb.mu.Lock()
+
+		// Skip the source when it changed hands or another poll already handled this change
+		if !hb.current(p) || (!p.src.missing && info.ModTime().Equal(p.src.modTime) && info.Size() == p.src.size) {
+			hb.mu.Unlock()
+			continue
+		}
+
+		// The new stat is kept when loading fails, a file that is still being written is
+		// retried when it changes again
+		p.src.missing = false
+		p.src.modTime = info.ModTime()
+		p.src.size = info.Size()
+
+		event := ChangeEvent{Name: p.name, Path: path, Kind: Reloaded}
+
+		if err == nil {
+			err = hb.Set(p.name, sf)
+		}
+
+		if err != nil {
+			event.Kind = ReloadFailed
+			event.Err = err
+		}
+
+		hb.emit(event)
+		hb.mu.Unlock()
+	}
+}
+
+// current returns true if a polled source is still watched and the bank is open, hb.mu must be
+// held
+func (hb *HotSoundBank[T]) current(p polledSource) bool {
+	return !hb.closed && hb.sources[p.name] == p.src
+}
+
+func (hb *HotSoundBank[T]) run(interval time.Duration) {
+	defer close(hb.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-hb.stop:
+			return
+		case <-ticker.C:
+			hb.Poll()
+		}
+	}
+}
+
+// Close stops polling and closes the event channel, closing more than once has no effect
+func (hb *HotSoundBank[T]) Close() error {
+	hb.mu.Lock()
+
+	if hb.closed {
+		hb.mu.Unlock()
+		return nil
+	}
+
+	hb.closed = true
+	close(hb.stop)
+	hb.mu.Unlock()
+
+	<-hb.done
+
+	hb.mu.Lock()
+	close(hb.events)
+	hb.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,253 @@
+package sndfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/almerlucke/sndfile/dsp/resample"
+)
+
+// testHotBank returns a hot bank that polls every interval, its loader creates a silent file
+// with a frame per byte of the source file, a source file containing bad fails to load
+func testHotBank(interval time.Duration) *HotSoundBank[float64] {
+	hb := NewHotSoundBank[float64](interval, 8, resample.Best)
+
+	hb.SetLoader(func(e ManifestEntry) (SoundFiler[float64], error) {
+		data, err := os.ReadFile(e.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		if string(data) == "bad" {
+			return nil, errors.New("bad")
+		}
+
+		return NewSoundFileFromBuffers([][]float64{make([]float64, len(data))}, 1000), nil
+	})
+
+	return hb
+}
+
+func TestHotSoundBankPoll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.wav")
+	if err := os.WriteFile(path, []byte("1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	hb := testHotBank(0)
+	defer hb.Close()
+
+	if err := hb.Watch(ManifestEntry{Name: "a", Path: path}, "x"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every step changes the file size so the change is seen regardless of the mod time
+	// resolution
+	tests := []struct {
+		name    string
+		content string
+		kind    ChangeKind
+		frames  int64
+	}{
+		{"reload", "1234", Reloaded, 4},
+		{"load error", "bad", ReloadFailed, 4},
+		{"missing", "", SourceMissing, 4},
+		{"restored", "12", Reloaded, 2},
+	}
+
+	for _, tt := range tests {
+		var err error
+		if tt.kind == SourceMissing {
+			err = os.Remove(path)
+		} else {
+			err = os.WriteFile(path, []byte(tt.content), 0o644)
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hb.Poll()
+
+		select {
+		case ev := <-hb.Events():
+			if ev.Kind != tt.kind || ev.Name != "a" || ev.Path != path {
+				t.Errorf("%s: event %+v", tt.name, ev)
+			}
+		default:
+			t.Fatalf("%s: no event", tt.name)
+		}
+
+		if sf, _ := hb.Get("a"); sf.NumFrames() != tt.frames {
+			t.Errorf("%s: %d frames, want %d", tt.name, sf.NumFrames(), tt.frames)
+		}
+	}
+
+	// Polling an unchanged file emits nothing and reloads keep the tags
+	hb.Poll()
+
+	if len(hb.Events()) != 0 {
+		t.Fatal("event for an unchanged file")
+	}
+
+	if info, _ := hb.Info("a"); len(info.Tags) != 1 {
+		t.Fatal("tags lost on reload")
+	}
+}
+
+func TestHotSoundBankRemove(t *testing.T) {
+	dir := t.TempDir()
+
+	hb := testHotBank(0)
+	defer hb.Close()
+
+	for _, name := range []string{"a", "b"} {
+		path := filepath.Join(dir, name+".wav")
+		if err := os.WriteFile(path, []byte("1"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := hb.Watch(ManifestEntry{Name: name, Path: path}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte("12"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hb.Remove("a")
+	hb.Unwatch("b")
+	hb.Poll()
+
+	if _, ok := hb.Get("a"); ok {
+		t.Fatal("removed entry added again by Poll")
+	}
+
+	if sf, ok := hb.Get("b"); !ok || sf.NumFrames() != 1 {
+		t.Fatal("unwatched entry reloaded")
+	}
+
+	if len(hb.Events()) != 0 {
+		t.Fatal("event for an entry that is not watched")
+	}
+}
+
+func TestHotSoundBankSlowLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.wav")
+	if err := os.WriteFile(path, []byte("1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	hb := testHotBank(0)
+	defer hb.Close()
+
+	if err := hb.Watch(ManifestEntry{Name: "a", Path: path}); err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	hb.SetLoader(func(e ManifestEntry) (SoundFiler[float64], error) {
+		close(started)
+		<-release
+
+		return NewSoundFileFromBuffers([][]float64{{0}}, 1000), nil
+	})
+
+	if err := os.WriteFile(path, []byte("12"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	polled := make(chan struct{})
+
+	go func() {
+		hb.Poll()
+		close(polled)
+	}()
+
+	// The bank stays usable while a file is decoded, an entry removed in the meantime is not
+	// added again
+	<-started
+	hb.Remove("a")
+	close(release)
+	<-polled
+
+	if _, ok := hb.Get("a"); ok {
+		t.Fatal("removed entry added again by Poll")
+	}
+
+	if len(hb.Events()) != 0 {
+		t.Fatal("event for a removed entry")
+	}
+}
+
+func TestHotSoundBankWatchErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	hb := testHotBank(0)
+	defer hb.Close()
+
+	if err := hb.Watch(ManifestEntry{Path: filepath.Join(dir, "missing.wav")}); err == nil {
+		t.Fatal("expected error for a missing file")
+	}
+
+	path := filepath.Join(dir, "bad.wav")
+	if err := os.WriteFile(path, []byte("bad"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var le *LoadError
+	if err := hb.Watch(ManifestEntry{Path: path}); !errors.As(err, &le) {
+		t.Fatalf("error %v", err)
+	}
+
+	if hb.Len() != 0 {
+		t.Fatal("failed entry added")
+	}
+}
+
+func TestHotSoundBankClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.wav")
+	if err := os.WriteFile(path, []byte("1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	hb := testHotBank(time.Millisecond)
+
+	if err := hb.Watch(ManifestEntry{Name: "a", Path: path}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+
+	for range 4 {
+		wg.Go(func() {
+			if err := hb.Close(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	wg.Wait()
+
+	if _, ok := <-hb.Events(); ok {
+		t.Fatal("event channel open after Close")
+	}
+
+	// Polling after Close must not send on the closed channel
+	if err := os.WriteFile(path, []byte("12"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	hb.Poll()
+
+	if sf, _ := hb.Get("a"); sf.NumFrames() != 1 {
+		t.Fatal("entry reloaded after Close")
+	}
+}
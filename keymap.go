@@ -0,0 +1,201 @@
+package sndfile
+
+import (
+	"errors"
+	"math"
+
+	"github.com/almerlucke/sndfile/float"
+)
+
+// Zone maps a key and velocity range to a SoundBank entry
+type Zone struct {
+	// Sample is the SoundBank name of the sound to play
+	Sample string
+	// LowKey and HighKey are the inclusive MIDI key range
+	LowKey  int
+	HighKey int
+	// LowVelocity and HighVelocity are the inclusive velocity range, both 0 means 1 to 127
+	LowVelocity  int
+	HighVelocity int
+	// RootKey is the key at which the sample plays at its original pitch
+	RootKey int
+	// Tune in cents
+	Tune float64
+	// Gain is a linear amplitude factor, 0 is treated as 1
+	Gain float64
+	// FadeInLow to FadeInHigh fades the zone in over a velocity range, FadeOutLow to FadeOutHigh
+	// fades it out, equal ranges disable the fade
+	FadeInLow   int
+	FadeInHigh  int
+	FadeOutLow  int
+	FadeOutHigh int
+	// Group is a round robin group, matching zones with the same non-zero group take turns
+	Group int
+}
+
+// Match is a zone resolved for a note
+type Match[T float.Float] struct {
+	Zone  *Zone
+	Sound SoundFiler[T]
+	// Pitch is the playback ratio relative to the original pitch of the sound
+	Pitch float64
+	// Gain includes the zone gain and velocity crossfade
+	Gain float64
+}
+
+// Keymap maps notes and velocities to zones of a SoundBank. Resolve advances the round robin
+// counters, so a keymap is not safe for concurrent use.
+type Keymap[T float.Float] struct {
+	bank       SoundBank[T]
+	zones      []*Zone
+	roundRobin map[int]int
+}
+
+// NewKeymap creates an empty keymap over bank
+func NewKeymap[T float.Float](bank SoundBank[T]) *Keymap[T] {
+	return &Keymap[T]{
+		bank:       bank,
+		roundRobin: map[int]int{},
+	}
+}
+
+// AddZone adds a zone to the keymap, the sample must exist in the SoundBank
+func (km *Keymap[T]) AddZone(zone Zone) error {
+	if _, ok := km.bank[zone.Sample]; !ok {
+		return errors.New("zone sample not found in sound bank")
+	}
+
+	if zone.LowKey < 0 || zone.HighKey > 127 || zone.LowKey > zone.HighKey {
+		return errors.New("invalid zone key range")
+	}
+
+	if zone.LowVelocity == 0 && zone.HighVelocity == 0 {
+		zone.LowVelocity = 1
+		zone.HighVelocity = 127
+	}
+
+	if zone.LowVelocity < 0 || zone.HighVelocity > 127 || zone.LowVelocity > zone.HighVelocity {
+		return errors.New("invalid zone velocity range")
+	}
+
+	if zone.Gain == 0 {
+		zone.Gain = 1
+	}
+
+	km.zones = append(km.zones, &zone)
+
+	return nil
+}
+
+// Zones returns the zones of the keymap
+func (km *Keymap[T]) Zones() []*Zone {
+	return km.zones
+}
+
+// Bank returns the SoundBank of the keymap
+func (km *Keymap[T]) Bank() SoundBank[T] {
+	return km.bank
+}
+
+// ResetRoundRobin restarts all round robin groups at their first zone
+func (km *Keymap[T]) ResetRoundRobin() {
+	clear(km.roundRobin)
+}
+
+func (z *Zone) matches(note int, velocity int) bool {
+	return note >= z.LowKey && note <= z.HighKey && velocity >= z.LowVelocity && velocity <= z.HighVelocity
+}
+
+// velocityGain returns the equal power crossfade gain for velocity
+func (z *Zone) velocityGain(velocity int) float64 {
+	gain := 1.0
+
+	if z.FadeInHigh > z.FadeInLow {
+		if velocity <= z.FadeInLow {
+			return 0
+		}
+		if velocity < z.FadeInHigh {
+			gain *= math.Sqrt(float64(velocity-z.FadeInLow) / float64(z.FadeInHigh-z.FadeInLow))
+		}
+	}
+
+	if z.FadeOutHigh > z.FadeOutLow {
+		if velocity >= z.FadeOutHigh {
+			return 0
+		}
+		if velocity > z.FadeOutLow {
+			gain *= math.Sqrt(float64(z.FadeOutHigh-velocity) / float64(z.FadeOutHigh-z.FadeOutLow))
+		}
+	}
+
+	return gain
+}
+
+// PitchRatio returns the playback ratio of the zone for note
+func (z *Zone) PitchRatio(note int) float64 {
+	return math.Pow(2, (float64(note-z.RootKey)+z.Tune/100.0)/12.0)
+}
+
+func (km *Keymap[T]) appendMatch(dst []Match[T], zone *Zone, note int, velocity int) []Match[T] {
+	gain := zone.Gain * zone.velocityGain(velocity)
+	if gain == 0 {
+		return dst
+	}
+
+	return append(dst, Match[T]{
+		Zone:  zone,
+		Sound: km.bank[zone.Sample],
+		Pitch: zone.PitchRatio(note),
+		Gain:  gain,
+	})
+}
+
+// Resolve appends the zones to play for note and velocity to dst and returns the extended
+// slice. Of each round robin group only the next matching zone in turn is added.
+func (km *Keymap[T]) Resolve(dst []Match[T], note int, velocity int) []Match[T] {
+	for i, zone := range km.zones {
+		if !zone.matches(note, velocity) {
+			continue
+		}
+
+		if zone.Group == 0 {
+			dst = km.appendMatch(dst, zone, note, velocity)
+			continue
+		}
+
+		// Handle each group once, at its first matching zone
+		first := true
+		for _, other := range km.zones[:i] {
+			if other.Group == zone.Group && other.matches(note, velocity) {
+				first = false
+				break
+			}
+		}
+
+		if !first {
+			continue
+		}
+
+		count := 0
+		for _, other := range km.zones[i:] {
+			if other.Group == zone.Group && other.matches(note, velocity) {
+				count++
+			}
+		}
+
+		turn := km.roundRobin[zone.Group] % count
+		km.roundRobin[zone.Group]++
+
+		for _, other := range km.zones[i:] {
+			if other.Group == zone.Group && other.matches(note, velocity) {
+				if turn == 0 {
+					dst = km.appendMatch(dst, other, note, velocity)
+					break
+				}
+				turn--
+			}
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,150 @@
+package sndfile
+
+import (
+	"math"
+	"testing"
+)
+
+func testBank(names ...string) SoundBank[float64] {
+	bank := SoundBank[float64]{}
+	for _, name := range names {
+		bank[name] = NewSoundFileFromBuffers([][]float64{make([]float64, 100)}, 44100)
+	}
+
+	return bank
+}
+
+func TestKeymapAddZone(t *testing.T) {
+	tests := []struct {
+		name string
+		zone Zone
+		ok   bool
+	}{
+		{"valid", Zone{Sample: "a", LowKey: 60, HighKey: 72}, true},
+		{"full range", Zone{Sample: "a", LowKey: 0, HighKey: 127, LowVelocity: 1, HighVelocity: 127}, true},
+		{"unknown sample", Zone{Sample: "x", LowKey: 60, HighKey: 72}, false},
+		{"inverted keys", Zone{Sample: "a", LowKey: 72, HighKey: 60}, false},
+		{"key above 127", Zone{Sample: "a", LowKey: 0, HighKey: 128}, false},
+		{"inverted velocity", Zone{Sample: "a", HighKey: 127, LowVelocity: 100, HighVelocity: 10}, false},
+	}
+
+	for _, tt := range tests {
+		km := NewKeymap(testBank("a"))
+		if err := km.AddZone(tt.zone); (err == nil) != tt.ok {
+			t.Errorf("%s: error %v", tt.name, err)
+		}
+	}
+
+	km := NewKeymap(testBank("a"))
+	km.AddZone(Zone{Sample: "a", HighKey: 127})
+
+	z := km.Zones()[0]
+	if z.LowVelocity != 1 || z.HighVelocity != 127 || z.Gain != 1 {
+		t.Fatalf("defaults not applied: %+v", z)
+	}
+}
+
+func TestKeymapResolve(t *testing.T) {
+	km := NewKeymap(testBank("low", "high", "soft", "loud"))
+	km.AddZone(Zone{Sample: "low", LowKey: 0, HighKey: 59, RootKey: 48})
+	km.AddZone(Zone{Sample: "high", LowKey: 60, HighKey: 127, RootKey: 60, Tune: 50, Gain: 0.5})
+	km.AddZone(Zone{Sample: "soft", LowKey: 0, HighKey: 127, RootKey: 60, LowVelocity: 1, HighVelocity: 63})
+	km.AddZone(Zone{Sample: "loud", LowKey: 0, HighKey: 127, RootKey: 60, LowVelocity: 64, HighVelocity: 127})
+
+	tests := []struct {
+		note, velocity int
+		samples        []string
+		pitch          float64
+		gain           float64
+	}{
+		{48, 10, []string{"low", "soft"}, 1, 1},
+		{60, 100, []string{"high", "loud"}, math.Pow(2, 0.5/12), 0.5},
+		{72, 64, []string{"high", "loud"}, math.Pow(2, 12.5/12), 0.5},
+		{59, 63, []string{"low", "soft"}, math.Pow(2, 11.0/12), 1},
+	}
+
+	var dst []Match[float64]
+
+	for _, tt := range tests {
+		dst = km.Resolve(dst[:0], tt.note, tt.velocity)
+
+		if len(dst) != len(tt.samples) {
+			t.Fatalf("note %d velocity %d: %d matches", tt.note, tt.velocity, len(dst))
+		}
+
+		for i, m := range dst {
+			if m.Zone.Sample != tt.samples[i] || m.Sound != km.Bank()[tt.samples[i]] {
+				t.Fatalf("note %d velocity %d: match %d is %s", tt.note, tt.velocity, i, m.Zone.Sample)
+			}
+		}
+
+		if math.Abs(dst[0].Pitch-tt.pitch) > 1e-12 || dst[0].Gain != tt.gain {
+			t.Errorf("note %d: pitch %f gain %f", tt.note, dst[0].Pitch, dst[0].Gain)
+		}
+	}
+}
+
+func TestKeymapVelocityCrossfade(t *testing.T) {
+	km := NewKeymap(testBank("a"))
+	km.AddZone(Zone{Sample: "a", HighKey: 127, FadeInLow: 20, FadeInHigh: 40, FadeOutLow: 80, FadeOutHigh: 100})
+
+	tests := []struct {
+		velocity int
+		gain     float64
+	}{
+		{10, 0},
+		{20, 0},
+		{30, math.Sqrt(0.5)},
+		{40, 1},
+		{60, 1},
+		{90, math.Sqrt(0.5)},
+		{100, 0},
+		{120, 0},
+	}
+
+	for _, tt := range tests {
+		dst := km.Resolve(nil, 60, tt.velocity)
+
+		var gain float64
+		if len(dst) == 1 {
+			gain = dst[0].Gain
+		}
+
+		if math.Abs(gain-tt.gain) > 1e-12 {
+			t.Errorf("velocity %d: gain %f, want %f", tt.velocity, gain, tt.gain)
+		}
+	}
+}
+
+func TestKeymapRoundRobin(t *testing.T) {
+	km := NewKeymap(testBank("a", "b", "c", "x", "y"))
+	km.AddZone(Zone{Sample: "a", LowKey: 60, HighKey: 72, Group: 1})
+	km.AddZone(Zone{Sample: "x", LowKey: 60, HighKey: 72, Group: 2})
+	km.AddZone(Zone{Sample: "b", LowKey: 60, HighKey: 72, Group: 1})
+	km.AddZone(Zone{Sample: "y", LowKey: 60, HighKey: 72, Group: 2})
+	km.AddZone(Zone{Sample: "c", LowKey: 66, HighKey: 72, Group: 1})
+
+	tests := []struct {
+		note  int
+		group []string
+	}{
+		{60, []string{"a", "b", "a", "b"}},
+		{70, []string{"a", "b", "c", "a"}},
+	}
+
+	for _, tt := range tests {
+		km.ResetRoundRobin()
+
+		for i, want := range tt.group {
+			dst := km.Resolve(nil, tt.note, 100)
+
+			if len(dst) != 2 || dst[0].Zone.Sample != want {
+				t.Fatalf("note %d turn %d: %v", tt.note, i, dst)
+			}
+
+			if wantOther := []string{"x", "y"}[i%2]; dst[1].Zone.Sample != wantOther {
+				t.Fatalf("note %d turn %d: group 2 played %s, want %s", tt.note, i, dst[1].Zone.Sample, wantOther)
+			}
+		}
+	}
+}
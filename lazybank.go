@@ -0,0 +1,280 @@
+package sndfile
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"unsafe"
+
+	"github.com/almerlucke/sndfile/dsp/resample"
+	"github.com/almerlucke/sndfile/float"
+)
+
+// Footprint returns the approximate number of bytes held by the sample buffers and zero
+// crossings of a SoundFiler, a SoundFiler can report its own size with a Footprint method
+func Footprint[T float.Float](sf SoundFiler[T]) int64 {
+	if fp, ok := sf.(interface{ Footprint() int64 }); ok {
+		return fp.Footprint()
+	}
+
+	var size int64
+
+	sampleSize := int64(unsafe.Sizeof(T(0)))
+	crossingSize := int64(unsafe.Sizeof(ZeroCrossing{}))
+
+	for c := 0; c < sf.NumChannels(); c++ {
+		for d := 0; d < sf.Depth(); d++ {
+			size += int64(len(sf.Buffer(c, d))) * sampleSize
+		}
+
+		size += int64(len(sf.ZeroCrossings(c))) * crossingSize
+	}
+
+	return size
+}
+
+type lazyEntry[T float.Float] struct {
+	entry     ManifestEntry
+	sf        SoundFiler[T]
+	footprint int64
+	refs      int
+	loading   chan struct{}
+	element   *list.Element
+}
+
+// LazySoundBank registers sound files by path and decodes them on first access. Loaded entries
+// that are not in use are evicted in least recently used order when the memory budget is
+// exceeded. Entries in use are never evicted, so the budget can be exceeded temporarily.
+type LazySoundBank[T float.Float] struct {
+	mu      sync.Mutex
+	entries map[string]*lazyEntry[T]
+	lru     *list.List
+	budget  int64
+	used    int64
+	load    func(ManifestEntry) (SoundFiler[T], error)
+}
+
+// NewLazySoundBank creates a lazy bank with a memory budget in bytes, a budget of 0 means no
+// limit
+func NewLazySoundBank[T float.Float](budget int64, quality resample.Quality) *LazySoundBank[T] {
+	return &LazySoundBank[T]{
+		entries: map[string]*lazyEntry[T]{},
+		lru:     list.New(),
+		budget:  budget,
+		load: func(entry ManifestEntry) (SoundFiler[T], error) {
+			return LoadEntry[T](entry, quality)
+		},
+	}
+}
+
+// SetLoader replaces the function that decodes entries, by default entries are loaded with
+// LoadEntry
+func (lb *LazySoundBank[T]) SetLoader(load func(ManifestEntry) (SoundFiler[T], error)) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	lb.load = load
+}
+
+// Register adds an entry without loading it, a registered entry with the same name is replaced
+// once it is no longer in use
+func (lb *LazySoundBank[T]) Register(entry ManifestEntry) error {
+	if entry.Name == "" {
+		entry.Name = entryName(".", entry.Path)
+	}
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	if e, ok := lb.entries[entry.Name]; ok {
+		if e.refs > 0 || e.loading != nil {
+			return errors.New("entry is in use")
+		}
+
+		lb.unload(e)
+	}
+
+	lb.entries[entry.Name] = &lazyEntry[T]{entry: entry}
+
+	return nil
+}
+
+// Unregister removes an entry that is not in use
+func (lb *LazySoundBank[T]) Unregister(name string) error {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	e, ok := lb.entries[name]
+	if !ok {
+		return errors.New("entry not found")
+	}
+
+	if e.refs > 0 || e.loading != nil {
+		return errors.New("entry is in use")
+	}
+
+	lb.unload(e)
+	delete(lb.entries, name)
+
+	return nil
+}
+
+// Acquire returns the sound file of an entry, decoding it when needed, and marks it in use.
+// Every successful Acquire must be paired with a Release.
+func (lb *LazySoundBank[T]) Acquire(name string) (SoundFiler[T], error) {
+	lb.mu.Lock()
+
+	e, ok := lb.entries[name]
+	if !ok {
+		lb.mu.Unlock()
+		return nil, errors.New("entry not found")
+	}
+
+	e.refs++
+
+	// Wait for a load in progress by another caller
+	for e.loading != nil {
+		loading := e.loading
+		lb.mu.Unlock()
+		<-loading
+		lb.mu.Lock()
+	}
+
+	if e.sf == nil {
+		loading := make(chan struct{})
+		e.loading = loading
+		load := lb.load
+		lb.mu.Unlock()
+
+		sf, err := load(e.entry)
+
+		lb.mu.Lock()
+		e.loading = nil
+		close(loading)
+
+		if err != nil {
+			e.refs--
+			lb.mu.Unlock()
+			return nil, &LoadError{Entry: e.entry, Err: err}
+		}
+
+		e.sf = sf
+		e.footprint = Footprint(sf)
+		e.element = lb.lru.PushFront(e)
+		lb.used += e.footprint
+	} else {
+		lb.lru.MoveToFront(e.element)
+	}
+
+	sf := e.sf
+
+	lb.evict()
+	lb.mu.Unlock()
+
+	return sf, nil
+}
+
+// Release marks one user of an entry as done
+func (lb *LazySoundBank[T]) Release(name string) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	e, ok := lb.entries[name]
+	if !ok || e.refs == 0 {
+		return
+	}
+
+	e.refs--
+
+	lb.evict()
+}
+
+// unload drops the sound file of an entry
+func (lb *LazySoundBank[T]) unload(e *lazyEntry[T]) {
+	if e.sf == nil {
+		return
+	}
+
+	lb.lru.Remove(e.element)
+	lb.used -= e.footprint
+	e.sf = nil
+	e.element = nil
+	e.footprint = 0
+}
+
+// evict unloads least recently used entries that are not in use until the budget is met
+func (lb *LazySoundBank[T]) evict() {
+	if lb.budget <= 0 {
+		return
+	}
+
+	for el := lb.lru.Back(); el != nil && lb.used > lb.budget; {
+		prev := el.Prev()
+
+		if e := el.Value.(*lazyEntry[T]); e.refs == 0 {
+			lb.unload(e)
+		}
+
+		el = prev
+	}
+}
+
+// SetBudget changes the memory budget and evicts entries when needed
+func (lb *LazySoundBank[T]) SetBudget(budget int64) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	lb.budget = budget
+	lb.evict()
+}
+
+// Budget returns the memory budget in bytes
+func (lb *LazySoundBank[T]) Budget() int64 {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	return lb.budget
+}
+
+// Used returns the number of bytes held by loaded entries
+func (lb *LazySoundBank[T]) Used() int64 {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	return lb.used
+}
+
+// Loaded returns true if the entry is decoded in memory
+func (lb *LazySoundBank[T]) Loaded(name string) bool {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	e, ok := lb.entries[name]
+
+	return ok && e.sf != nil
+}
+
+// Refs returns the number of active users of an entry
+func (lb *LazySoundBank[T]) Refs(name string) int {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	if e, ok := lb.entries[name]; ok {
+		return e.refs
+	}
+
+	return 0
+}
+
+// Names returns the names of all registered entries
+func (lb *LazySoundBank[T]) Names() []string {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	names := make([]string, 0, len(lb.entries))
+	for name := range lb.entries {
+		names = append(names, name)
+	}
+
+	return names
+}
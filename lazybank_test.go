@@ -0,0 +1,208 @@
+package sndfile
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+
+	"github.com/almerlucke/sndfile/dsp/resample"
+)
+
+// testLazyBank returns a lazy bank whose loader creates silent mono files of 100 float64 frames
+// (800 bytes) and fails for entries named bad
+func testLazyBank(budget int64, names ...string) (*LazySoundBank[float64], *atomic.Int32) {
+	lb := NewLazySoundBank[float64](budget, resample.Best)
+	loads := &atomic.Int32{}
+
+	lb.SetLoader(func(e ManifestEntry) (SoundFiler[float64], error) {
+		loads.Add(1)
+
+		if e.Name == "bad" {
+			return nil, errors.New("bad")
+		}
+
+		return NewSoundFileFromBuffers([][]float64{make([]float64, 100)}, 44100), nil
+	})
+
+	for _, name := range names {
+		lb.Register(ManifestEntry{Name: name, Path: name + ".wav"})
+	}
+
+	return lb, loads
+}
+
+func TestFootprint(t *testing.T) {
+	tests := []struct {
+		name string
+		sf   SoundFiler[float64]
+		want int64
+	}{
+		{"silent mono", NewSoundFileFromBuffers([][]float64{make([]float64, 100)}, 44100), 800},
+		{"stereo", NewSoundFileFromBuffers([][]float64{make([]float64, 10), make([]float64, 10)}, 44100), 160},
+	}
+
+	for _, tt := range tests {
+		if got := Footprint(tt.sf); got != tt.want {
+			t.Errorf("%s: %d bytes, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	crossing := NewSoundFileFromBuffers([][]float64{{-1, 1, -1, 1}}, 44100)
+	want := 32 + int64(len(crossing.ZeroCrossings(0)))*int64(unsafe.Sizeof(ZeroCrossing{}))
+
+	if got := Footprint[float64](crossing); got != want || want == 32 {
+		t.Errorf("crossings: %d bytes, want %d", got, want)
+	}
+
+	if got := Footprint[float32](NewSoundFileFromBuffers([][]float32{make([]float32, 100)}, 44100)); got != 400 {
+		t.Errorf("float32: %d bytes", got)
+	}
+}
+
+func TestLazySoundBankLoadOnce(t *testing.T) {
+	lb, loads := testLazyBank(0, "a")
+
+	if lb.Loaded("a") {
+		t.Fatal("entry loaded on register")
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			if _, err := lb.Acquire("a"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if loads.Load() != 1 || lb.Refs("a") != 10 || !lb.Loaded("a") || lb.Used() != 800 {
+		t.Fatalf("%d loads, %d refs, %d bytes", loads.Load(), lb.Refs("a"), lb.Used())
+	}
+
+	for range 10 {
+		lb.Release("a")
+	}
+
+	// Releasing more often than acquiring is ignored
+	lb.Release("a")
+	lb.Release("missing")
+
+	if lb.Refs("a") != 0 || !lb.Loaded("a") {
+		t.Fatal("entry unloaded without a budget")
+	}
+}
+
+func TestLazySoundBankEviction(t *testing.T) {
+	lb, loads := testLazyBank(2500, "a", "b", "c", "d")
+
+	use := func(names ...string) {
+		for _, name := range names {
+			if _, err := lb.Acquire(name); err != nil {
+				t.Fatal(err)
+			}
+			lb.Release(name)
+		}
+	}
+
+	use("a", "b", "c")
+
+	if lb.Used() != 2400 {
+		t.Fatalf("%d bytes used", lb.Used())
+	}
+
+	// Loading d exceeds the budget, a is least recently used
+	use("a", "b", "d")
+
+	tests := []struct {
+		name   string
+		loaded bool
+	}{
+		{"a", true},
+		{"b", true},
+		{"c", false},
+		{"d", true},
+	}
+
+	for _, tt := range tests {
+		if lb.Loaded(tt.name) != tt.loaded {
+			t.Errorf("%s loaded %v, want %v", tt.name, !tt.loaded, tt.loaded)
+		}
+	}
+
+	if loads.Load() != 4 {
+		t.Fatalf("%d loads", loads.Load())
+	}
+
+	// Entries in use are kept even when the budget is exceeded
+	if _, err := lb.Acquire("a"); err != nil {
+		t.Fatal(err)
+	}
+
+	lb.SetBudget(100)
+
+	if !lb.Loaded("a") || lb.Loaded("b") || lb.Loaded("d") || lb.Used() != 800 || lb.Budget() != 100 {
+		t.Fatalf("after lowering the budget %d bytes used", lb.Used())
+	}
+
+	lb.Release("a")
+
+	if lb.Loaded("a") || lb.Used() != 0 {
+		t.Fatal("released entry not evicted")
+	}
+}
+
+func TestLazySoundBankRegistration(t *testing.T) {
+	lb, _ := testLazyBank(0, "a", "bad")
+
+	if err := lb.Register(ManifestEntry{Path: "dir/c.wav"}); err != nil {
+		t.Fatal(err)
+	}
+
+	names := lb.Names()
+	slices.Sort(names)
+
+	if !slices.Equal(names, []string{"a", "bad", "dir/c"}) {
+		t.Fatalf("names %v", names)
+	}
+
+	if _, err := lb.Acquire("missing"); err == nil {
+		t.Fatal("expected error for an unknown entry")
+	}
+
+	_, err := lb.Acquire("bad")
+
+	var le *LoadError
+	if !errors.As(err, &le) || le.Entry.Name != "bad" || lb.Refs("bad") != 0 {
+		t.Fatalf("error %v", err)
+	}
+
+	if _, err := lb.Acquire("a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lb.Register(ManifestEntry{Name: "a", Path: "other.wav"}); err == nil {
+		t.Fatal("expected error replacing an entry in use")
+	}
+
+	if err := lb.Unregister("a"); err == nil {
+		t.Fatal("expected error removing an entry in use")
+	}
+
+	lb.Release("a")
+
+	if err := lb.Register(ManifestEntry{Name: "a", Path: "other.wav"}); err != nil || lb.Loaded("a") || lb.Used() != 0 {
+		t.Fatalf("replacing an entry: %v", err)
+	}
+
+	if err := lb.Unregister("a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lb.Unregister("a"); err == nil {
+		t.Fatal("expected error removing an unknown entry")
+	}
+}
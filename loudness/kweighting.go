@@ -0,0 +1,80 @@
+package loudness
+
+import "math"
+
+// biquad is a direct form I second order section used for K-weighting
+type biquad struct {
+	b0, b1, b2 float64
+	a1, a2     float64
+	x1, x2     float64
+	y1, y2     float64
+}
+
+func (bq *biquad) process(x float64) float64 {
+	y := bq.b0*x + bq.b1*bq.x1 + bq.b2*bq.x2 - bq.a1*bq.y1 - bq.a2*bq.y2
+	bq.x2 = bq.x1
+	bq.x1 = x
+	bq.y2 = bq.y1
+	bq.y1 = y
+	return y
+}
+
+func (bq *biquad) reset() {
+	bq.x1, bq.x2, bq.y1, bq.y2 = 0, 0, 0, 0
+}
+
+// kWeighting is the two stage pre-filter of ITU-R BS.1770, a high shelf modelling the
+// acoustic effect of the head followed by the RLB high pass. The coefficients are derived
+// from the analog prototypes so any sample rate can be used.
+type kWeighting struct {
+	shelf    biquad
+	highPass biquad
+}
+
+func newKWeighting(sampleRate float64) *kWeighting {
+	kw := &kWeighting{}
+
+	// Stage 1: high shelf
+	f0 := 1681.974450955533
+	g := 3.999843853973347
+	q := 0.7071752369554196
+
+	k := math.Tan(math.Pi * f0 / sampleRate)
+	vh := math.Pow(10.0, g/20.0)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1.0 + k/q + k*k
+
+	kw.shelf = biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2.0 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2.0 * (k*k - 1.0) / a0,
+		a2: (1.0 - k/q + k*k) / a0,
+	}
+
+	// Stage 2: RLB high pass
+	f0 = 38.13547087602444
+	q = 0.5003270373238773
+
+	k = math.Tan(math.Pi * f0 / sampleRate)
+	a0 = 1.0 + k/q + k*k
+
+	kw.highPass = biquad{
+		b0: 1.0,
+		b1: -2.0,
+		b2: 1.0,
+		a1: 2.0 * (k*k - 1.0) / a0,
+		a2: (1.0 - k/q + k*k) / a0,
+	}
+
+	return kw
+}
+
+func (kw *kWeighting) process(x float64) float64 {
+	return kw.highPass.process(kw.shelf.process(x))
+}
+
+func (kw *kWeighting) reset() {
+	kw.shelf.reset()
+	kw.highPass.reset()
+}
@@ -0,0 +1,34 @@
+// Package loudness implements EBU R128 / ITU-R BS.1770 loudness and true-peak measurement
+package loudness
+
+import (
+	"github.com/almerlucke/sndfile"
+	"github.com/almerlucke/sndfile/float"
+)
+
+// Measure measures the loudness of a complete SoundFiler
+func Measure[T float.Float](sf sndfile.SoundFiler[T]) (*Result, error) {
+	numChannels := sf.NumChannels()
+
+	m, err := NewMeter(numChannels, sf.SampleRate())
+	if err != nil {
+		return nil, err
+	}
+
+	channels := make([][]T, numChannels)
+	for c := range channels {
+		channels[c] = sf.Buffer(c, 0)
+	}
+
+	frame := make([]float64, numChannels)
+
+	for i := int64(0); i < sf.NumFrames(); i++ {
+		for c := range channels {
+			frame[c] = float64(channels[c][i])
+		}
+
+		m.ProcessFrame(frame)
+	}
+
+	return m.Result(), nil
+}
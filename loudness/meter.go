@@ -0,0 +1,350 @@
+package loudness
+
+import (
+	"errors"
+	"math"
+	"slices"
+)
+
+const (
+	// AbsoluteGate is the absolute gating threshold in LUFS
+	AbsoluteGate = -70.0
+	// RelativeGate is the relative gating threshold in LU used for integrated loudness
+	RelativeGate = -10.0
+	// RangeRelativeGate is the relative gating threshold in LU used for loudness range
+	RangeRelativeGate = -20.0
+)
+
+const (
+	// Gating blocks are built from 100ms sub blocks, 4 for momentary and 30 for short-term
+	subBlocksPerSecond = 10
+	momentarySubBlocks = 4
+	shortTermSubBlocks = 30
+	loudnessOffset     = -0.691
+	surroundGain       = 1.41
+)
+
+// Meter measures momentary, short-term and integrated loudness, loudness range and
+// true-peak according to EBU R128 / ITU-R BS.1770. The meter can be fed interleaved
+// blocks of any size and implements writer.Monitor so it can follow a writer.Writer.
+type Meter struct {
+	numChannels int
+	sampleRate  float64
+	// Per channel K-weighting filters, weights and true-peak estimators
+	filters   []*kWeighting
+	gains     []float64
+	truePeaks []*truePeak
+	// Sub block (100ms) accumulation
+	subBlockSize  int
+	subBlockPos   int
+	subBlockPower float64
+	// Ring of the last shortTermSubBlocks sub block powers
+	subBlocks     []float64
+	subBlockIndex int
+	subBlockCount int
+	// Gating block powers (400ms) and short-term powers (3s), both at 10Hz
+	momentaryPowers []float64
+	shortTermPowers []float64
+	samplePeak      float64
+	frame           []float64
+}
+
+// NewMeter creates a loudness meter for the given channel count and sample rate. For five
+// and six channel input the channel order L, R, C, (LFE), Ls, Rs is assumed.
+func NewMeter(numChannels int, sampleRate float64) (*Meter, error) {
+	if numChannels <= 0 {
+		return nil, errors.New("number of channels should be larger than 0")
+	}
+
+	if sampleRate <= 0 {
+		return nil, errors.New("sample rate should be larger than 0")
+	}
+
+	m := &Meter{
+		numChannels:  numChannels,
+		sampleRate:   sampleRate,
+		filters:      make([]*kWeighting, numChannels),
+		gains:        make([]float64, numChannels),
+		truePeaks:    make([]*truePeak, numChannels),
+		subBlockSize: max(int(math.Round(sampleRate/subBlocksPerSecond)), 1),
+		subBlocks:    make([]float64, shortTermSubBlocks),
+		frame:        make([]float64, numChannels),
+	}
+
+	for c := 0; c < numChannels; c++ {
+		m.filters[c] = newKWeighting(sampleRate)
+		m.truePeaks[c] = newTruePeak(sampleRate)
+		m.gains[c] = channelGain(c, numChannels)
+	}
+
+	return m, nil
+}
+
+func channelGain(channel int, numChannels int) float64 {
+	switch numChannels {
+	case 5:
+		if channel >= 3 {
+			return surroundGain
+		}
+	case 6:
+		// LFE is excluded from the measurement
+		if channel == 3 {
+			return 0.0
+		}
+		if channel >= 4 {
+			return surroundGain
+		}
+	}
+
+	return 1.0
+}
+
+// NumChannels returns the number of channels the meter expects
+func (m *Meter) NumChannels() int {
+	return m.numChannels
+}
+
+// SampleRate returns the sample rate the meter was created for
+func (m *Meter) SampleRate() float64 {
+	return m.sampleRate
+}
+
+// Process feeds a block of interleaved samples to the meter
+func (m *Meter) Process(samples []float32) {
+	numFrames := len(samples) / m.numChannels
+
+	for i := 0; i < numFrames; i++ {
+		for c := 0; c < m.numChannels; c++ {
+			m.frame[c] = float64(samples[i*m.numChannels+c])
+		}
+
+		m.ProcessFrame(m.frame)
+	}
+}
+
+// ProcessFrame feeds a single frame holding one sample per channel to the meter
+func (m *Meter) ProcessFrame(frame []float64) {
+	var power float64
+
+	for c, x := range frame[:m.numChannels] {
+		m.samplePeak = max(m.samplePeak, math.Abs(x))
+		m.truePeaks[c].process(x)
+
+		y := m.filters[c].process(x)
+		power += m.gains[c] * y * y
+	}
+
+	m.subBlockPower += power
+	m.subBlockPos++
+
+	if m.subBlockPos == m.subBlockSize {
+		m.pushSubBlock(m.subBlockPower / float64(m.subBlockSize))
+		m.subBlockPower = 0
+		m.subBlockPos = 0
+	}
+}
+
+func (m *Meter) pushSubBlock(power float64) {
+	m.subBlocks[m.subBlockIndex] = power
+	m.subBlockIndex = (m.subBlockIndex + 1) % shortTermSubBlocks
+	m.subBlockCount++
+
+	if m.subBlockCount >= momentarySubBlocks {
+		m.momentaryPowers = append(m.momentaryPowers, m.windowPower(momentarySubBlocks))
+	}
+
+	if m.subBlockCount >= shortTermSubBlocks {
+		m.shortTermPowers = append(m.shortTermPowers, m.windowPower(shortTermSubBlocks))
+	}
+}
+
+// windowPower returns the mean power of the last n sub blocks
+func (m *Meter) windowPower(n int) float64 {
+	var sum float64
+
+	idx := m.subBlockIndex
+	for range n {
+		idx--
+		if idx < 0 {
+			idx = shortTermSubBlocks - 1
+		}
+		sum += m.subBlocks[idx]
+	}
+
+	return sum / float64(n)
+}
+
+// Momentary returns the loudness of the last 400ms in LUFS
+func (m *Meter) Momentary() float64 {
+	if m.subBlockCount < momentarySubBlocks {
+		return math.Inf(-1)
+	}
+
+	return powerToLoudness(m.windowPower(momentarySubBlocks))
+}
+
+// ShortTerm returns the loudness of the last 3s in LUFS
+func (m *Meter) ShortTerm() float64 {
+	if m.subBlockCount < shortTermSubBlocks {
+		return math.Inf(-1)
+	}
+
+	return powerToLoudness(m.windowPower(shortTermSubBlocks))
+}
+
+// MaxMomentary returns the highest momentary loudness measured so far in LUFS
+func (m *Meter) MaxMomentary() float64 {
+	return maxLoudness(m.momentaryPowers)
+}
+
+// MaxShortTerm returns the highest short-term loudness measured so far in LUFS
+func (m *Meter) MaxShortTerm() float64 {
+	return maxLoudness(m.shortTermPowers)
+}
+
+// Integrated returns the gated integrated loudness of everything fed so far in LUFS
+func (m *Meter) Integrated() float64 {
+	gated := gate(m.momentaryPowers, loudnessToPower(AbsoluteGate))
+	if len(gated) == 0 {
+		return math.Inf(-1)
+	}
+
+	relativeThreshold := loudnessToPower(powerToLoudness(meanPower(gated)) + RelativeGate)
+
+	gated = gate(gated, relativeThreshold)
+	if len(gated) == 0 {
+		return math.Inf(-1)
+	}
+
+	return powerToLoudness(meanPower(gated))
+}
+
+// LoudnessRange returns the loudness range (LRA) in LU following EBU Tech 3342
+func (m *Meter) LoudnessRange() float64 {
+	gated := gate(m.shortTermPowers, loudnessToPower(AbsoluteGate))
+	if len(gated) == 0 {
+		return 0
+	}
+
+	relativeThreshold := loudnessToPower(powerToLoudness(meanPower(gated)) + RangeRelativeGate)
+
+	gated = gate(gated, relativeThreshold)
+	if len(gated) == 0 {
+		return 0
+	}
+
+	slices.Sort(gated)
+
+	low := gated[int(math.Round(float64(len(gated)-1)*0.10))]
+	high := gated[int(math.Round(float64(len(gated)-1)*0.95))]
+
+	return powerToLoudness(high) - powerToLoudness(low)
+}
+
+// SamplePeak returns the highest absolute sample value over all channels
+func (m *Meter) SamplePeak() float64 {
+	return m.samplePeak
+}
+
+// TruePeak returns the highest oversampled absolute sample value over all channels
+func (m *Meter) TruePeak() float64 {
+	var peak float64
+
+	for _, tp := range m.truePeaks {
+		peak = max(peak, tp.peak)
+	}
+
+	// The interpolated peak can never be lower than the sample peak
+	return max(peak, m.samplePeak)
+}
+
+// ChannelTruePeak returns the highest oversampled absolute sample value of a channel
+func (m *Meter) ChannelTruePeak(channel int) float64 {
+	return m.truePeaks[channel].peak
+}
+
+// Reset clears all filter state and measurements
+func (m *Meter) Reset() {
+	for c := 0; c < m.numChannels; c++ {
+		m.filters[c].reset()
+		m.truePeaks[c].reset()
+	}
+
+	clear(m.subBlocks)
+	m.subBlockPos = 0
+	m.subBlockPower = 0
+	m.subBlockIndex = 0
+	m.subBlockCount = 0
+	m.momentaryPowers = m.momentaryPowers[:0]
+	m.shortTermPowers = m.shortTermPowers[:0]
+	m.samplePeak = 0
+}
+
+// Result holds the outcome of a loudness measurement
+type Result struct {
+	// Integrated loudness in LUFS
+	Integrated float64
+	// Loudness range in LU
+	LoudnessRange float64
+	// Highest momentary and short-term loudness in LUFS
+	MaxMomentary float64
+	MaxShortTerm float64
+	// True-peak and sample peak in dBTP and dBFS
+	TruePeak   float64
+	SamplePeak float64
+}
+
+// Result returns a summary of the current measurements
+func (m *Meter) Result() *Result {
+	return &Result{
+		Integrated:    m.Integrated(),
+		LoudnessRange: m.LoudnessRange(),
+		MaxMomentary:  m.MaxMomentary(),
+		MaxShortTerm:  m.MaxShortTerm(),
+		TruePeak:      AmplitudeToDB(m.TruePeak()),
+		SamplePeak:    AmplitudeToDB(m.SamplePeak()),
+	}
+}
+
+// AmplitudeToDB converts a linear amplitude to decibels
+func AmplitudeToDB(amp float64) float64 {
+	return 20.0 * math.Log10(amp)
+}
+
+func powerToLoudness(power float64) float64 {
+	return loudnessOffset + 10.0*math.Log10(power)
+}
+
+func loudnessToPower(loudness float64) float64 {
+	return math.Pow(10.0, (loudness-loudnessOffset)/10.0)
+}
+
+func gate(powers []float64, threshold float64) []float64 {
+	gated := make([]float64, 0, len(powers))
+
+	for _, p := range powers {
+		if p > threshold {
+			gated = append(gated, p)
+		}
+	}
+
+	return gated
+}
+
+func meanPower(powers []float64) float64 {
+	var sum float64
+
+	for _, p := range powers {
+		sum += p
+	}
+
+	return sum / float64(len(powers))
+}
+
+func maxLoudness(powers []float64) float64 {
+	if len(powers) == 0 {
+		return math.Inf(-1)
+	}
+
+	return powerToLoudness(slices.Max(powers))
+}
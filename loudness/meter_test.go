@@ -0,0 +1,170 @@
+package loudness
+
+import (
+	"math"
+	"testing"
+
+	"github.com/almerlucke/sndfile"
+)
+
+// sine returns numChannels interleaved channels with a 997 Hz sine of amplitude amp in the
+// channels listed in active
+func sine(numChannels int, active []int, amp float64, sampleRate float64, seconds float64) []float32 {
+	n := int(sampleRate * seconds)
+	buf := make([]float32, n*numChannels)
+
+	for i := 0; i < n; i++ {
+		v := float32(amp * math.Sin(2*math.Pi*997*float64(i)/sampleRate))
+		for _, c := range active {
+			buf[i*numChannels+c] = v
+		}
+	}
+
+	return buf
+}
+
+func TestNewMeterErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		numChannels int
+		sampleRate  float64
+	}{
+		{"no channels", 0, 48000},
+		{"negative channels", -1, 48000},
+		{"no sample rate", 2, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewMeter(tt.numChannels, tt.sampleRate); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestIntegratedLoudness(t *testing.T) {
+	tests := []struct {
+		name        string
+		numChannels int
+		active      []int
+		amp         float64
+		sampleRate  float64
+		want        float64
+	}{
+		{"mono channel full scale 48k", 2, []int{0}, 1, 48000, -3.01},
+		{"mono channel full scale 44.1k", 2, []int{0}, 1, 44100, -3.01},
+		{"stereo -23 LUFS", 2, []int{0, 1}, math.Pow(10, -23.0/20.0), 48000, -23},
+		{"stereo -23 LUFS 96k", 2, []int{0, 1}, math.Pow(10, -23.0/20.0), 96000, -23},
+		{"surround front left", 5, []int{0}, 1, 48000, -3.01},
+		{"surround left surround", 5, []int{3}, 1, 48000, -3.01 + 1.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewMeter(tt.numChannels, tt.sampleRate)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m.Process(sine(tt.numChannels, tt.active, tt.amp, tt.sampleRate, 10))
+
+			if got := m.Integrated(); math.Abs(got-tt.want) > 0.05 {
+				t.Fatalf("integrated loudness %.3f, want %.3f", got, tt.want)
+			}
+
+			if got := m.MaxMomentary(); math.Abs(got-tt.want) > 0.1 {
+				t.Fatalf("max momentary loudness %.3f, want %.3f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSilenceAndReset(t *testing.T) {
+	m, err := NewMeter(1, 48000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.Process(make([]float32, 48000*2))
+
+	if !math.IsInf(m.Integrated(), -1) || m.LoudnessRange() != 0 {
+		t.Fatalf("silence measured as %f LUFS, %f LU", m.Integrated(), m.LoudnessRange())
+	}
+
+	m.Process(sine(1, []int{0}, 1, 48000, 5))
+	m.Reset()
+
+	if !math.IsInf(m.Integrated(), -1) || m.SamplePeak() != 0 || m.TruePeak() != 0 {
+		t.Fatal("reset did not clear measurements")
+	}
+}
+
+func TestTruePeak(t *testing.T) {
+	// A sine at a quarter of the sample rate with a 45 degree phase offset has all samples at
+	// 1/sqrt(2) of the true peak
+	sampleRate := 48000.0
+	buf := make([]float32, int(sampleRate))
+
+	for i := range buf {
+		buf[i] = float32(math.Sin(math.Pi/2*float64(i) + math.Pi/4))
+	}
+
+	m, err := NewMeter(1, sampleRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.Process(buf)
+
+	if got := AmplitudeToDB(m.SamplePeak()); math.Abs(got+3.01) > 0.01 {
+		t.Fatalf("sample peak %.3f dBFS, want -3.01", got)
+	}
+
+	if got := AmplitudeToDB(m.TruePeak()); math.Abs(got) > 0.5 {
+		t.Fatalf("true peak %.3f dBTP, want 0", got)
+	}
+}
+
+func TestLoudnessRange(t *testing.T) {
+	// Alternating 20 second sections at -20 and -30 LUFS give a loudness range close to 10 LU
+	sampleRate := 48000.0
+
+	m, err := NewMeter(2, sampleRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, level := range []float64{-20, -30, -20, -30} {
+		m.Process(sine(2, []int{0, 1}, math.Pow(10, level/20.0), sampleRate, 20))
+	}
+
+	if got := m.LoudnessRange(); math.Abs(got-10) > 1 {
+		t.Fatalf("loudness range %.2f LU, want 10", got)
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	sampleRate := 48000.0
+	amp := math.Pow(10, -23.0/20.0)
+	left := make([]float64, int(sampleRate)*5)
+	right := make([]float64, len(left))
+
+	for i := range left {
+		left[i] = amp * math.Sin(2*math.Pi*997*float64(i)/sampleRate)
+		right[i] = left[i]
+	}
+
+	res, err := Measure[float64](sndfile.NewSoundFileFromBuffers([][]float64{left, right}, sampleRate))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(res.Integrated+23) > 0.05 {
+		t.Fatalf("integrated loudness %.3f, want -23", res.Integrated)
+	}
+
+	if _, err := Measure[float64](sndfile.NewSoundFileFromBuffers([][]float64{}, sampleRate)); err == nil {
+		t.Fatal("expected error for a sound without channels")
+	}
+}
@@ -0,0 +1,100 @@
+package loudness
+
+import "math"
+
+const truePeakTaps = 12
+
+// truePeak estimates the inter-sample peak of one channel by polyphase oversampling
+// as described in ITU-R BS.1770-4 Annex 2
+type truePeak struct {
+	factor  int
+	phases  [][]float64
+	history []float64
+	pos     int
+	peak    float64
+}
+
+// truePeakFactor returns the oversampling factor needed to get at least 192kHz
+func truePeakFactor(sampleRate float64) int {
+	switch {
+	case sampleRate < 96000.0:
+		return 4
+	case sampleRate < 192000.0:
+		return 2
+	default:
+		return 1
+	}
+}
+
+func newTruePeak(sampleRate float64) *truePeak {
+	factor := truePeakFactor(sampleRate)
+
+	tp := &truePeak{
+		factor:  factor,
+		phases:  make([][]float64, factor),
+		history: make([]float64, truePeakTaps),
+	}
+
+	// Hann windowed sinc interpolation kernel split into phases, each phase is
+	// normalized to unity gain at DC
+	halfTaps := float64(truePeakTaps) / 2.0
+
+	for p := 0; p < factor; p++ {
+		coefs := make([]float64, truePeakTaps)
+		sum := 0.0
+
+		for k := 0; k < truePeakTaps; k++ {
+			t := float64(k) - halfTaps + float64(p)/float64(factor)
+			c := 1.0
+			if t != 0.0 {
+				c = math.Sin(math.Pi*t) / (math.Pi * t)
+			}
+			c *= 0.5 + 0.5*math.Cos(math.Pi*t/halfTaps)
+			coefs[k] = c
+			sum += c
+		}
+
+		for k := range coefs {
+			coefs[k] /= sum
+		}
+
+		tp.phases[p] = coefs
+	}
+
+	return tp
+}
+
+func (tp *truePeak) process(x float64) {
+	tp.pos--
+	if tp.pos < 0 {
+		tp.pos = truePeakTaps - 1
+	}
+
+	tp.history[tp.pos] = x
+
+	if tp.factor == 1 {
+		tp.peak = max(tp.peak, math.Abs(x))
+		return
+	}
+
+	for _, coefs := range tp.phases {
+		var sum float64
+
+		idx := tp.pos
+		for _, c := range coefs {
+			sum += tp.history[idx] * c
+			idx++
+			if idx == truePeakTaps {
+				idx = 0
+			}
+		}
+
+		tp.peak = max(tp.peak, math.Abs(sum))
+	}
+}
+
+func (tp *truePeak) reset() {
+	clear(tp.history)
+	tp.pos = 0
+	tp.peak = 0
+}
@@ -90,6 +90,11 @@ func NewMipMapSoundFile[T float.Float](filePath string, depth int) (*MipMapSound
 		return nil, err
 	}
 
+	return NewMipMapSoundFileFromSoundFile(sndFile, depth)
+}
+
+// NewMipMapSoundFileFromSoundFile creates a mipmap sound file from a loaded sound file
+func NewMipMapSoundFileFromSoundFile[T float.Float](sndFile *SoundFile[T], depth int) (*MipMapSoundFile[T], error) {
 	mmsf := &MipMapSoundFile[T]{
 		depth:         depth,
 		sampleRate:    sndFile.SampleRate(),
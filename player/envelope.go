@@ -0,0 +1,80 @@
+package player
+
+// ADSR describes a linear attack, decay, sustain, release amplitude envelope, times are in seconds
+type ADSR struct {
+	Attack  float64
+	Decay   float64
+	Sustain float64
+	Release float64
+}
+
+type envelopeStage int
+
+const (
+	stageIdle envelopeStage = iota
+	stageAttack
+	stageDecay
+	stageSustain
+	stageRelease
+)
+
+type envelope struct {
+	adsr       ADSR
+	sampleRate float64
+	stage      envelopeStage
+	level      float64
+	step       float64
+}
+
+// stepFor returns the level change per sample to cover distance in seconds
+func (e *envelope) stepFor(distance float64, seconds float64) float64 {
+	samples := seconds * e.sampleRate
+	if samples < 1 {
+		return distance
+	}
+
+	return distance / samples
+}
+
+func (e *envelope) start(adsr ADSR, sampleRate float64) {
+	e.adsr = adsr
+	e.sampleRate = sampleRate
+	e.level = 0
+	e.stage = stageAttack
+	e.step = e.stepFor(1, adsr.Attack)
+}
+
+func (e *envelope) release() {
+	if e.stage == stageIdle || e.stage == stageRelease {
+		return
+	}
+
+	e.stage = stageRelease
+	e.step = e.stepFor(e.level, e.adsr.Release)
+}
+
+func (e *envelope) tick() float64 {
+	switch e.stage {
+	case stageAttack:
+		e.level += e.step
+		if e.level >= 1 {
+			e.level = 1
+			e.stage = stageDecay
+			e.step = e.stepFor(1-e.adsr.Sustain, e.adsr.Decay)
+		}
+	case stageDecay:
+		e.level -= e.step
+		if e.level <= e.adsr.Sustain {
+			e.level = e.adsr.Sustain
+			e.stage = stageSustain
+		}
+	case stageRelease:
+		e.level -= e.step
+		if e.level <= 0 {
+			e.level = 0
+			e.stage = stageIdle
+		}
+	}
+
+	return e.level
+}
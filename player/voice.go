@@ -0,0 +1,267 @@
+// Package player implements a sample playback voice for SoundFilers
+package player
+
+import (
+	"math"
+
+	"github.com/almerlucke/sndfile"
+	"github.com/almerlucke/sndfile/float"
+)
+
+// LoopMode determines what happens when the playback position reaches the loop end
+type LoopMode int
+
+const (
+	// LoopOff plays the sound once
+	LoopOff LoopMode = iota
+	// LoopForward jumps back to the loop start
+	LoopForward
+	// LoopPingPong reverses direction at the loop boundaries
+	LoopPingPong
+	// LoopSustain loops forward until the note is released and then plays to the end
+	LoopSustain
+)
+
+// Config describes how a voice plays a sound
+type Config[T float.Float] struct {
+	// Start offset in frames
+	Start int64
+	// Pitch as a playback ratio, 1 plays at the original pitch
+	Pitch float64
+	Loop  LoopMode
+	// LoopStart and LoopEnd in frames, a LoopEnd of 0 means the end of the sound
+	LoopStart int64
+	LoopEnd   int64
+	Envelope  ADSR
+	Gain      float64
+	// Pan from -1 to 1, only used for mono sounds rendered to two channels
+	Pan float64
+	// ReleaseSample is played once at the same pitch when the note is released
+	ReleaseSample sndfile.SoundFiler[T]
+	ReleaseGain   float64
+}
+
+// DefaultConfig returns a config that plays a sound once at the original pitch
+func DefaultConfig[T float.Float]() Config[T] {
+	return Config[T]{
+		Pitch: 1,
+		Envelope: ADSR{
+			Attack:  0.002,
+			Sustain: 1,
+			Release: 0.05,
+		},
+		Gain:        1,
+		ReleaseGain: 1,
+	}
+}
+
+// playhead reads a sound at a given speed with mipmap depth selection
+type playhead[T float.Float] struct {
+	sound     sndfile.SoundFiler[T]
+	pos       float64
+	speed     float64
+	depth     int
+	direction float64
+	active    bool
+}
+
+func (p *playhead[T]) start(sound sndfile.SoundFiler[T], pos float64, pitch float64, sampleRate float64) {
+	p.sound = sound
+	p.pos = pos
+	p.direction = 1
+	p.active = true
+	p.setPitch(pitch, sampleRate)
+}
+
+func (p *playhead[T]) setPitch(pitch float64, sampleRate float64) {
+	p.speed = pitch * p.sound.SampleRate() / sampleRate
+	p.depth = min(sndfile.SpeedToMipMapDepth(p.speed), p.sound.Depth()-1)
+}
+
+// Voice plays a SoundFiler with variable pitch, looping and an amplitude envelope. Rendering does
+// not allocate, so a voice can be used from a real-time render loop.
+type Voice[T float.Float] struct {
+	sampleRate   float64
+	cfg          Config[T]
+	main         playhead[T]
+	release      playhead[T]
+	releaseGain  float64
+	env          envelope
+	loopStart    float64
+	loopEnd      float64
+	released     bool
+	panL, panR   float64
+	lastFrame    float64
+	releaseFrame float64
+}
+
+// NewVoice creates an idle voice rendering at sampleRate
+func NewVoice[T float.Float](sampleRate float64) *Voice[T] {
+	return &Voice[T]{
+		sampleRate: sampleRate,
+	}
+}
+
+// NoteOn starts playing a sound, a voice that is still playing is restarted. The start offset is
+// clamped to the sound, an empty sound leaves the voice inactive.
+func (v *Voice[T]) NoteOn(sound sndfile.SoundFiler[T], cfg Config[T]) {
+	if sound.NumFrames() < 1 {
+		v.Stop()
+		return
+	}
+
+	v.cfg = cfg
+	v.released = false
+	v.release.active = false
+	v.lastFrame = float64(sound.NumFrames() - 1)
+
+	v.loopStart = float64(cfg.LoopStart)
+	v.loopEnd = float64(cfg.LoopEnd)
+	if cfg.LoopEnd <= 0 || v.loopEnd > v.lastFrame {
+		v.loopEnd = v.lastFrame
+	}
+
+	angle := (min(max(cfg.Pan, -1), 1) + 1) * math.Pi / 4
+	v.panL = math.Cos(angle) * math.Sqrt2
+	v.panR = math.Sin(angle) * math.Sqrt2
+
+	start := float64(min(max(cfg.Start, 0), sound.NumFrames()-1))
+
+	v.main.start(sound, start, cfg.Pitch, v.sampleRate)
+	v.env.start(cfg.Envelope, v.sampleRate)
+}
+
+// NoteOff releases the note, the envelope enters its release stage and the release sample starts
+func (v *Voice[T]) NoteOff() {
+	if v.released || !v.Active() {
+		return
+	}
+
+	v.released = true
+	v.env.release()
+
+	if rs := v.cfg.ReleaseSample; rs != nil && rs.NumFrames() > 1 {
+		v.releaseGain = v.cfg.ReleaseGain * v.env.level
+		v.releaseFrame = float64(rs.NumFrames() - 1)
+		v.release.start(rs, 0, v.cfg.Pitch, v.sampleRate)
+	}
+}
+
+// Stop silences the voice immediately
+func (v *Voice[T]) Stop() {
+	v.main.active = false
+	v.release.active = false
+	v.env.stage = stageIdle
+}
+
+// Active returns true while the voice produces sound
+func (v *Voice[T]) Active() bool {
+	return v.main.active || v.release.active
+}
+
+// SetPitch changes the playback ratio of a playing voice
+func (v *Voice[T]) SetPitch(pitch float64) {
+	v.cfg.Pitch = pitch
+
+	if v.main.sound != nil {
+		v.main.setPitch(pitch, v.sampleRate)
+	}
+
+	if v.release.sound != nil {
+		v.release.setPitch(pitch, v.sampleRate)
+	}
+}
+
+// Position returns the playback position in frames of the sound
+func (v *Voice[T]) Position() float64 {
+	return v.main.pos
+}
+
+// advance moves the main playhead and handles looping
+func (v *Voice[T]) advance() {
+	p := &v.main
+	p.pos += p.speed * p.direction
+
+	loop := v.cfg.Loop
+	if loop == LoopSustain && v.released {
+		loop = LoopOff
+	}
+
+	looping := loop != LoopOff && v.loopEnd > v.loopStart
+
+	switch {
+	case looping && loop == LoopPingPong:
+		if p.direction > 0 && p.pos >= v.loopEnd {
+			p.pos = v.loopEnd - (p.pos - v.loopEnd)
+			p.direction = -1
+		} else if p.direction < 0 && p.pos <= v.loopStart {
+			p.pos = v.loopStart + (v.loopStart - p.pos)
+			p.direction = 1
+		}
+	case looping:
+		for p.pos >= v.loopEnd {
+			p.pos -= v.loopEnd - v.loopStart
+		}
+	default:
+		if p.pos >= v.lastFrame || p.pos < 0 {
+			p.active = false
+		}
+	}
+}
+
+// mix adds a frame of a sound to the output channels at index i
+func (v *Voice[T]) mix(out [][]T, i int, frame []T, gain float64) {
+	if len(frame) == 1 {
+		s := float64(frame[0]) * gain
+		if len(out) == 2 {
+			out[0][i] += T(s * v.panL)
+			out[1][i] += T(s * v.panR)
+			return
+		}
+
+		for c := range out {
+			out[c][i] += T(s)
+		}
+		return
+	}
+
+	for c := range out {
+		out[c][i] += T(float64(frame[c%len(frame)]) * gain)
+	}
+}
+
+// Render adds the next len(out[0]) frames of the voice to the output channels. A mono sound is
+// panned over two output channels or copied to all channels, otherwise sound channels are
+// mapped to output channels in order.
+func (v *Voice[T]) Render(out [][]T) {
+	if len(out) == 0 {
+		return
+	}
+
+	for i := range out[0] {
+		if !v.Active() {
+			return
+		}
+
+		if v.main.active {
+			level := v.env.tick()
+			frame := v.main.sound.LookupAll(v.main.pos, v.main.depth, false)
+			v.mix(out, i, frame, level*v.cfg.Gain)
+			v.advance()
+
+			if v.released && v.env.stage == stageIdle {
+				v.main.active = false
+			}
+		}
+
+		if v.release.active {
+			frame := v.release.sound.LookupAll(v.release.pos, v.release.depth, false)
+			v.mix(out, i, frame, v.releaseGain)
+			v.release.pos += v.release.speed
+
+			if v.release.pos >= v.releaseFrame {
+				v.release.active = false
+			}
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package player
+
+import (
+	"math"
+	"testing"
+
+	"github.com/almerlucke/sndfile"
+)
+
+// ramp returns a mono sound where every frame holds its own index
+func ramp(numFrames int) *sndfile.SoundFile[float64] {
+	buf := make([]float64, numFrames)
+	for i := range buf {
+		buf[i] = float64(i)
+	}
+
+	return sndfile.NewSoundFileFromBuffers([][]float64{buf}, 44100)
+}
+
+// flatConfig plays without envelope shaping so output samples equal the source position
+func flatConfig() Config[float64] {
+	cfg := DefaultConfig[float64]()
+	cfg.Envelope = ADSR{Sustain: 1}
+
+	return cfg
+}
+
+func renderMono(v *Voice[float64], numFrames int) []float64 {
+	out := [][]float64{make([]float64, numFrames)}
+	v.Render(out)
+
+	return out[0]
+}
+
+func TestVoicePlayback(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *Config[float64])
+		want   []float64
+	}{
+		{"once", func(cfg *Config[float64]) {}, []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 0, 0, 0}},
+		{"start", func(cfg *Config[float64]) { cfg.Start = 6 }, []float64{6, 7, 8, 0}},
+		{"negative start", func(cfg *Config[float64]) { cfg.Start = -10 }, []float64{0, 1, 2}},
+		{"start past end", func(cfg *Config[float64]) { cfg.Start = 100 }, []float64{9, 0, 0}},
+		{"double speed", func(cfg *Config[float64]) { cfg.Pitch = 2 }, []float64{0, 2, 4, 6, 8, 0}},
+		{"gain", func(cfg *Config[float64]) { cfg.Gain = 0.5 }, []float64{0, 0.5, 1, 1.5}},
+		{"loop forward", func(cfg *Config[float64]) {
+			cfg.Loop = LoopForward
+			cfg.LoopStart = 2
+			cfg.LoopEnd = 5
+		}, []float64{0, 1, 2, 3, 4, 2, 3, 4, 2, 3}},
+		{"loop ping pong", func(cfg *Config[float64]) {
+			cfg.Loop = LoopPingPong
+			cfg.LoopStart = 2
+			cfg.LoopEnd = 5
+		}, []float64{0, 1, 2, 3, 4, 5, 4, 3, 2, 3, 4, 5}},
+	}
+
+	for _, tt := range tests {
+		cfg := flatConfig()
+		tt.modify(&cfg)
+
+		v := NewVoice[float64](44100)
+		v.NoteOn(ramp(10), cfg)
+
+		got := renderMono(v, len(tt.want))
+		for i := range tt.want {
+			if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestVoiceSustainLoop(t *testing.T) {
+	cfg := flatConfig()
+	cfg.Loop = LoopSustain
+	cfg.LoopStart = 2
+	cfg.LoopEnd = 4
+	cfg.Envelope.Release = 1
+
+	v := NewVoice[float64](44100)
+	v.NoteOn(ramp(8), cfg)
+
+	if got := renderMono(v, 6); got[5] != 3 {
+		t.Fatalf("sustain loop %v", got)
+	}
+
+	// After the release the loop is left and the sound plays to the end
+	v.NoteOff()
+
+	// The slow release keeps the level close to 1
+	want := []float64{2, 3, 4, 5, 6, 0}
+	got := renderMono(v, 6)
+
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-2 {
+			t.Fatalf("after release %v, want %v", got, want)
+		}
+	}
+
+	if v.Active() {
+		t.Fatal("voice still active after the end of the sound")
+	}
+}
+
+func TestVoiceRelease(t *testing.T) {
+	cfg := flatConfig()
+	cfg.Loop = LoopForward
+	cfg.Envelope.Release = 10.0 / 44100
+
+	v := NewVoice[float64](44100)
+	v.NoteOn(sndfile.NewSoundFileFromBuffers([][]float64{{1, 1, 1, 1}}, 44100), cfg)
+	renderMono(v, 3)
+	v.NoteOff()
+
+	got := renderMono(v, 12)
+	for i := 1; i < 10; i++ {
+		if got[i] >= got[i-1] {
+			t.Fatalf("release is not decreasing: %v", got)
+		}
+	}
+
+	if got[11] != 0 || v.Active() {
+		t.Fatalf("voice active after release: %v", got)
+	}
+}
+
+func TestVoiceReleaseSample(t *testing.T) {
+	cfg := flatConfig()
+	cfg.Loop = LoopForward
+	cfg.ReleaseSample = sndfile.NewSoundFileFromBuffers([][]float64{{10, 20, 30, 40}}, 44100)
+	cfg.ReleaseGain = 0.5
+
+	v := NewVoice[float64](44100)
+	v.NoteOn(sndfile.NewSoundFileFromBuffers([][]float64{{0, 0, 0, 0}}, 44100), cfg)
+	renderMono(v, 2)
+	v.NoteOff()
+
+	want := []float64{5, 10, 15, 0}
+	if got := renderMono(v, 4); got[0] != want[0] || got[1] != want[1] || got[2] != want[2] || got[3] != want[3] {
+		t.Fatalf("release sample %v, want %v", got, want)
+	}
+}
+
+func TestVoicePan(t *testing.T) {
+	tests := []struct {
+		pan         float64
+		left, right float64
+	}{
+		{-1, math.Sqrt2, 0},
+		{0, 1, 1},
+		{1, 0, math.Sqrt2},
+	}
+
+	for _, tt := range tests {
+		cfg := flatConfig()
+		cfg.Pan = tt.pan
+
+		v := NewVoice[float64](44100)
+		v.NoteOn(sndfile.NewSoundFileFromBuffers([][]float64{{1, 1, 1}}, 44100), cfg)
+
+		out := [][]float64{make([]float64, 1), make([]float64, 1)}
+		v.Render(out)
+
+		if math.Abs(out[0][0]-tt.left) > 1e-9 || math.Abs(out[1][0]-tt.right) > 1e-9 {
+			t.Errorf("pan %v: %f, %f", tt.pan, out[0][0], out[1][0])
+		}
+	}
+}
+
+func TestVoiceEmptySound(t *testing.T) {
+	v := NewVoice[float64](44100)
+	v.NoteOn(sndfile.NewSoundFileFromBuffers([][]float64{{}}, 44100), flatConfig())
+
+	if v.Active() {
+		t.Fatal("voice active for an empty sound")
+	}
+
+	renderMono(v, 4)
+}
@@ -0,0 +1,25 @@
+package sndfile
+
+import "github.com/almerlucke/sndfile/float"
+
+// ChannelProcessor processes a block of a single channel in place, i.e. filters.FIRProcessor,
+// filters.IIRProcessor and filters.ParametricEQ
+type ChannelProcessor[T float.Float] interface {
+	ProcessChannel(channel int, block []T)
+	Reset()
+}
+
+// ProcessSoundFile runs all channels of a SoundFiler through a processor and returns the result as a
+// new SoundFile. The processor is reset before use.
+func ProcessSoundFile[T float.Float](sf SoundFiler[T], p ChannelProcessor[T]) *SoundFile[T] {
+	p.Reset()
+
+	channels := make([][]T, sf.NumChannels())
+
+	for c := range channels {
+		channels[c] = append([]T(nil), sf.Buffer(c, 0)...)
+		p.ProcessChannel(c, channels[c])
+	}
+
+	return NewSoundFileFromBuffers(channels, sf.SampleRate())
+}
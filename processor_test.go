@@ -0,0 +1,55 @@
+package sndfile
+
+import (
+	"testing"
+)
+
+// gainProcessor scales channel blocks and counts resets
+type gainProcessor struct {
+	gains  []float64
+	resets int
+}
+
+func (p *gainProcessor) ProcessChannel(channel int, block []float64) {
+	for i := range block {
+		block[i] *= p.gains[channel]
+	}
+}
+
+func (p *gainProcessor) Reset() {
+	p.resets++
+}
+
+func TestProcessSoundFile(t *testing.T) {
+	left := []float64{1, 2, 3}
+	right := []float64{-1, -2, -3}
+	sf := NewSoundFileFromBuffers([][]float64{left, right}, 44100)
+	p := &gainProcessor{gains: []float64{2, 0.5}}
+
+	out := ProcessSoundFile[float64](sf, p)
+
+	if p.resets != 1 {
+		t.Fatalf("processor reset %d times", p.resets)
+	}
+
+	tests := []struct {
+		channel int
+		want    []float64
+	}{
+		{0, []float64{2, 4, 6}},
+		{1, []float64{-0.5, -1, -1.5}},
+	}
+
+	for _, tt := range tests {
+		for i, v := range out.Buffer(tt.channel, 0) {
+			if v != tt.want[i] {
+				t.Fatalf("channel %d sample %d is %f, want %f", tt.channel, i, v, tt.want[i])
+			}
+		}
+	}
+
+	// The source is left untouched
+	if left[0] != 1 || right[0] != -1 || out.SampleRate() != 44100 {
+		t.Fatal("source modified")
+	}
+}
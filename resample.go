@@ -0,0 +1,23 @@
+package sndfile
+
+import (
+	"github.com/almerlucke/sndfile/dsp/resample"
+	"github.com/almerlucke/sndfile/float"
+)
+
+// ResampleSoundFile converts a SoundFiler to a new SoundFile with the given sample rate
+func ResampleSoundFile[T float.Float](sf SoundFiler[T], sampleRate float64, quality resample.Quality) (*SoundFile[T], error) {
+	ratio := sampleRate / sf.SampleRate()
+	channels := make([][]T, sf.NumChannels())
+
+	for c := range channels {
+		buf, err := resample.Resample(sf.Buffer(c, 0), ratio, quality)
+		if err != nil {
+			return nil, err
+		}
+
+		channels[c] = buf
+	}
+
+	return NewSoundFileFromBuffers(channels, sampleRate), nil
+}
@@ -0,0 +1,49 @@
+package sndfile
+
+import (
+	"math"
+	"testing"
+
+	"github.com/almerlucke/sndfile/dsp/resample"
+)
+
+func TestResampleSoundFile(t *testing.T) {
+	channels := make([][]float64, 2)
+	for c := range channels {
+		channels[c] = make([]float64, 4410)
+		for i := range channels[c] {
+			channels[c][i] = math.Sin(2*math.Pi*441*float64(i)/44100) * float64(c+1)
+		}
+	}
+
+	sf := NewSoundFileFromBuffers(channels, 44100)
+
+	tests := []struct {
+		sampleRate float64
+		numFrames  int64
+	}{
+		{48000, 4800},
+		{22050, 2205},
+		{88200, 8820},
+	}
+
+	for _, tt := range tests {
+		out, err := ResampleSoundFile[float64](sf, tt.sampleRate, resample.Medium)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if out.SampleRate() != tt.sampleRate || out.NumChannels() != 2 || math.Abs(float64(out.NumFrames()-tt.numFrames)) > 1 {
+			t.Fatalf("%v Hz: %v Hz, %d channels, %d frames", tt.sampleRate, out.SampleRate(), out.NumChannels(), out.NumFrames())
+		}
+
+		mid := out.NumFrames() / 2
+		want := math.Sin(2 * math.Pi * 441 * float64(mid) / tt.sampleRate)
+
+		for c := 0; c < 2; c++ {
+			if got := out.Buffer(c, 0)[mid]; math.Abs(got-want*float64(c+1)) > 1e-3 {
+				t.Fatalf("%v Hz channel %d: %f, want %f", tt.sampleRate, c, got, want*float64(c+1))
+			}
+		}
+	}
+}
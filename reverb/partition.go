@@ -0,0 +1,82 @@
+package reverb
+
+import "github.com/almerlucke/sndfile/dsp/fft"
+
+// partitionedKernel holds the spectra of consecutive blockSize parts of a kernel, each zero
+// padded to twice the block size for uniform partitioned overlap-save convolution
+type partitionedKernel struct {
+	spectra [][]complex128
+}
+
+func newPartitionedKernel(kernel []float64, blockSize int, numPartitions int, rfft *fft.Real[float64]) *partitionedKernel {
+	pk := &partitionedKernel{
+		spectra: make([][]complex128, numPartitions),
+	}
+
+	block := make([]float64, 2*blockSize)
+
+	for p := range pk.spectra {
+		clear(block)
+
+		start := p * blockSize
+		if start < len(kernel) {
+			copy(block, kernel[start:min(start+blockSize, len(kernel))])
+		}
+
+		pk.spectra[p] = make([]complex128, rfft.NumBins())
+		rfft.Forward(pk.spectra[p], block)
+	}
+
+	return pk
+}
+
+// delayLine is a frequency domain delay line holding the spectra of the last input blocks
+type delayLine struct {
+	spectra [][]complex128
+	pos     int
+}
+
+func newDelayLine(numPartitions int, numBins int) *delayLine {
+	dl := &delayLine{
+		spectra: make([][]complex128, numPartitions),
+	}
+
+	for i := range dl.spectra {
+		dl.spectra[i] = make([]complex128, numBins)
+	}
+
+	return dl
+}
+
+// next returns the slot for a new spectrum, which becomes the most recent one
+func (dl *delayLine) next() []complex128 {
+	dl.pos--
+	if dl.pos < 0 {
+		dl.pos = len(dl.spectra) - 1
+	}
+
+	return dl.spectra[dl.pos]
+}
+
+// at returns the spectrum of i blocks ago
+func (dl *delayLine) at(i int) []complex128 {
+	return dl.spectra[(dl.pos+i)%len(dl.spectra)]
+}
+
+func (dl *delayLine) reset() {
+	for _, s := range dl.spectra {
+		clear(s)
+	}
+
+	dl.pos = 0
+}
+
+// multiplyAccumulate adds the convolution of the delay line with a partitioned kernel to acc
+func multiplyAccumulate(acc []complex128, dl *delayLine, pk *partitionedKernel) {
+	for p, h := range pk.spectra {
+		x := dl.at(p)
+		for k := range acc {
+			acc[k] += x[k] * h[k]
+		}
+	}
+}
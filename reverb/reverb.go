@@ -0,0 +1,434 @@
+// Package reverb implements convolution reverb with sound files as impulse responses
+package reverb
+
+import (
+	"errors"
+	"math"
+
+	"github.com/almerlucke/sndfile"
+	"github.com/almerlucke/sndfile/dsp/fft"
+	"github.com/almerlucke/sndfile/dsp/resample"
+	"github.com/almerlucke/sndfile/float"
+)
+
+const (
+	DefaultBlockSize = 512
+	// Length of the fade out applied when an impulse response is shortened with MaxLength
+	trimFadeTime = 0.01
+)
+
+// Options for the convolver, use DefaultOptions as a starting point
+type Options struct {
+	// BlockSize is the partition size, it determines the latency of streaming processing.
+	// Zero means DefaultBlockSize.
+	BlockSize int
+	// Wet and Dry are the linear gains of the convolved and the original signal
+	Wet float64
+	Dry float64
+	// PreDelay in seconds added before the impulse response
+	PreDelay float64
+	// TrimThreshold in dB below the impulse response peak, the tail below the threshold is removed.
+	// Zero disables trimming.
+	TrimThreshold float64
+	// TrimLeading also removes the start of the impulse response below the threshold
+	TrimLeading bool
+	// MaxLength in seconds of the impulse response, zero means no limit
+	MaxLength float64
+	// Normalize scales the impulse response to unit energy
+	Normalize bool
+}
+
+// DefaultOptions returns options for a fully wet convolution without impulse response processing
+func DefaultOptions() Options {
+	return Options{
+		BlockSize: DefaultBlockSize,
+		Wet:       1.0,
+	}
+}
+
+// path routes an input channel through an impulse response channel to an output channel
+type path struct {
+	input  int
+	ir     int
+	output int
+}
+
+// Convolver applies an impulse response with uniform partitioned FFT convolution. A mono impulse
+// response is applied to every input channel. A stereo impulse response turns a mono input into
+// stereo or is applied per channel to a stereo input. A four channel impulse response is treated as
+// true stereo with the channel order L->L, L->R, R->L, R->R.
+type Convolver[T float.Float] struct {
+	opt         Options
+	blockSize   int
+	sampleRate  float64
+	numInputs   int
+	numOutputs  int
+	irLength    int
+	paths       []path
+	rfft        *fft.Real[float64]
+	kernels     []*partitionedKernel
+	delayLines  []*delayLine
+	inputBlocks [][]float64
+	wetBlocks   [][]float64
+	acc         [][]complex128
+	timeBlock   []float64
+	fill        int
+}
+
+// New creates a convolver for numInputs (1 or 2) channels at sampleRate, the impulse response is
+// resampled when its sample rate differs
+func New[T float.Float](ir sndfile.SoundFiler[T], numInputs int, sampleRate float64, opt Options) (*Convolver[T], error) {
+	if numInputs < 1 || numInputs > 2 {
+		return nil, errors.New("number of input channels should be 1 or 2")
+	}
+
+	if opt.BlockSize == 0 {
+		opt.BlockSize = DefaultBlockSize
+	}
+
+	if opt.BlockSize < 1 {
+		return nil, errors.New("block size should be at least 1")
+	}
+
+	kernels, err := prepareImpulseResponse(ir, sampleRate, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Convolver[T]{
+		opt:        opt,
+		blockSize:  opt.BlockSize,
+		sampleRate: sampleRate,
+		numInputs:  numInputs,
+		irLength:   len(kernels[0]),
+	}
+
+	switch len(kernels) {
+	case 1:
+		c.numOutputs = numInputs
+		for i := 0; i < numInputs; i++ {
+			c.paths = append(c.paths, path{input: i, ir: 0, output: i})
+		}
+	case 2:
+		c.numOutputs = 2
+		c.paths = []path{{input: 0, ir: 0, output: 0}, {input: numInputs - 1, ir: 1, output: 1}}
+	case 4:
+		c.numOutputs = 2
+		c.paths = []path{
+			{input: 0, ir: 0, output: 0},
+			{input: 0, ir: 1, output: 1},
+			{input: numInputs - 1, ir: 2, output: 0},
+			{input: numInputs - 1, ir: 3, output: 1},
+		}
+	default:
+		return nil, errors.New("impulse response should have 1, 2 or 4 channels")
+	}
+
+	c.rfft, err = fft.NewReal[float64](2 * c.blockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	numBins := c.rfft.NumBins()
+	numPartitions := max((c.irLength+c.blockSize-1)/c.blockSize, 1)
+
+	c.kernels = make([]*partitionedKernel, len(kernels))
+	for i, kernel := range kernels {
+		c.kernels[i] = newPartitionedKernel(kernel, c.blockSize, numPartitions, c.rfft)
+	}
+
+	c.delayLines = make([]*delayLine, numInputs)
+	c.inputBlocks = make([][]float64, numInputs)
+
+	for i := 0; i < numInputs; i++ {
+		c.delayLines[i] = newDelayLine(numPartitions, numBins)
+		c.inputBlocks[i] = make([]float64, 2*c.blockSize)
+	}
+
+	c.wetBlocks = make([][]float64, c.numOutputs)
+	c.acc = make([][]complex128, c.numOutputs)
+
+	for o := 0; o < c.numOutputs; o++ {
+		c.wetBlocks[o] = make([]float64, c.blockSize)
+		c.acc[o] = make([]complex128, numBins)
+	}
+
+	c.timeBlock = make([]float64, 2*c.blockSize)
+
+	return c, nil
+}
+
+// prepareImpulseResponse resamples, trims, normalizes and pre-delays the impulse response channels
+func prepareImpulseResponse[T float.Float](ir sndfile.SoundFiler[T], sampleRate float64, opt Options) ([][]float64, error) {
+	kernels := make([][]float64, ir.NumChannels())
+
+	for c := range kernels {
+		buf := ir.Buffer(c, 0)
+
+		if ir.SampleRate() != sampleRate {
+			var err error
+			buf, err = resample.Resample(buf, sampleRate/ir.SampleRate(), resample.Best)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		kernels[c] = make([]float64, len(buf))
+		for i, v := range buf {
+			kernels[c][i] = float64(v)
+		}
+	}
+
+	if len(kernels) == 0 || len(kernels[0]) == 0 {
+		return nil, errors.New("impulse response is empty")
+	}
+
+	if opt.TrimThreshold != 0 {
+		kernels = trim(kernels, opt.TrimThreshold, opt.TrimLeading)
+	}
+
+	if opt.MaxLength > 0 {
+		maxFrames := int(opt.MaxLength * sampleRate)
+		if maxFrames < len(kernels[0]) {
+			fade := min(int(trimFadeTime*sampleRate), maxFrames)
+			for c := range kernels {
+				kernels[c] = kernels[c][:maxFrames]
+				for i := 0; i < fade; i++ {
+					kernels[c][maxFrames-1-i] *= float64(i) / float64(fade)
+				}
+			}
+		}
+	}
+
+	// MaxLength can cut the kernels below one frame
+	if len(kernels[0]) == 0 {
+		return nil, errors.New("impulse response is shorter than one frame")
+	}
+
+	if opt.Normalize {
+		var energy float64
+		for _, kernel := range kernels {
+			var sum float64
+			for _, v := range kernel {
+				sum += v * v
+			}
+			energy = max(energy, sum)
+		}
+
+		if energy > 0 {
+			scale := 1.0 / math.Sqrt(energy)
+			for _, kernel := range kernels {
+				for i := range kernel {
+					kernel[i] *= scale
+				}
+			}
+		}
+	}
+
+	if preDelay := int(math.Round(opt.PreDelay * sampleRate)); preDelay > 0 {
+		for c, kernel := range kernels {
+			kernels[c] = append(make([]float64, preDelay), kernel...)
+		}
+	}
+
+	return kernels, nil
+}
+
+// trim removes the parts of all channels below threshold dB relative to the peak
+func trim(kernels [][]float64, threshold float64, leading bool) [][]float64 {
+	var peak float64
+	for _, kernel := range kernels {
+		for _, v := range kernel {
+			peak = max(peak, math.Abs(v))
+		}
+	}
+
+	level := peak * math.Pow(10, -math.Abs(threshold)/20)
+	first := len(kernels[0])
+	last := 0
+
+	for _, kernel := range kernels {
+		for i, v := range kernel {
+			if math.Abs(v) >= level {
+				first = min(first, i)
+				last = max(last, i)
+			}
+		}
+	}
+
+	if !leading {
+		first = 0
+	}
+
+	if first > last {
+		return kernels
+	}
+
+	for c := range kernels {
+		kernels[c] = kernels[c][first : last+1]
+	}
+
+	return kernels
+}
+
+// NumInputChannels returns the number of input channels
+func (c *Convolver[T]) NumInputChannels() int {
+	return c.numInputs
+}
+
+// NumOutputChannels returns the number of output channels
+func (c *Convolver[T]) NumOutputChannels() int {
+	return c.numOutputs
+}
+
+// Latency returns the delay of streaming processing in samples, both wet and dry signal are delayed
+func (c *Convolver[T]) Latency() int {
+	return c.blockSize
+}
+
+// ImpulseResponseLength returns the length of the prepared impulse response including pre-delay
+func (c *Convolver[T]) ImpulseResponseLength() int {
+	return c.irLength
+}
+
+// SetMix changes the wet and dry gains
+func (c *Convolver[T]) SetMix(wet float64, dry float64) {
+	c.opt.Wet = wet
+	c.opt.Dry = dry
+}
+
+// Reset clears all convolution state
+func (c *Convolver[T]) Reset() {
+	for i := range c.delayLines {
+		c.delayLines[i].reset()
+		clear(c.inputBlocks[i])
+	}
+
+	for o := range c.wetBlocks {
+		clear(c.wetBlocks[o])
+	}
+
+	c.fill = 0
+}
+
+// convolveBlock computes the wet output of the input block that was just completed
+func (c *Convolver[T]) convolveBlock() {
+	for i, dl := range c.delayLines {
+		c.rfft.Forward(dl.next(), c.inputBlocks[i])
+	}
+
+	for o := range c.acc {
+		clear(c.acc[o])
+	}
+
+	for _, p := range c.paths {
+		multiplyAccumulate(c.acc[p.output], c.delayLines[p.input], c.kernels[p.ir])
+	}
+
+	for o, acc := range c.acc {
+		c.rfft.Inverse(c.timeBlock, acc)
+		copy(c.wetBlocks[o], c.timeBlock[c.blockSize:])
+	}
+
+	// The current block becomes the previous block for the next overlap-save step
+	for i := range c.inputBlocks {
+		copy(c.inputBlocks[i], c.inputBlocks[i][c.blockSize:])
+	}
+}
+
+// tick pushes one input frame and writes one output frame
+func (c *Convolver[T]) tick(in func(channel int) float64, out func(channel int, v float64)) {
+	f := c.fill
+
+	for o := 0; o < c.numOutputs; o++ {
+		dry := c.inputBlocks[min(o, c.numInputs-1)][f]
+		out(o, c.opt.Wet*c.wetBlocks[o][f]+c.opt.Dry*dry)
+	}
+
+	for i := 0; i < c.numInputs; i++ {
+		c.inputBlocks[i][c.blockSize+f] = in(i)
+	}
+
+	c.fill++
+
+	if c.fill == c.blockSize {
+		c.convolveBlock()
+		c.fill = 0
+	}
+}
+
+// Process convolves a block of deinterleaved input channels into deinterleaved output channels,
+// all input and output channels should have the same length
+func (c *Convolver[T]) Process(input [][]T, output [][]T) {
+	var idx int
+
+	in := func(channel int) float64 { return float64(input[channel][idx]) }
+	out := func(channel int, v float64) { output[channel][idx] = T(v) }
+
+	for idx = range input[0] {
+		c.tick(in, out)
+	}
+}
+
+// ProcessInterleaved convolves a block of interleaved input frames into interleaved output frames
+func (c *Convolver[T]) ProcessInterleaved(input []T, output []T) {
+	var frame int
+
+	in := func(channel int) float64 { return float64(input[frame*c.numInputs+channel]) }
+	out := func(channel int, v float64) { output[frame*c.numOutputs+channel] = T(v) }
+
+	numFrames := len(input) / c.numInputs
+	for frame = 0; frame < numFrames; frame++ {
+		c.tick(in, out)
+	}
+}
+
+// ProcessSoundFile convolves a whole SoundFiler offline, the result includes the reverb tail and is
+// compensated for the latency. The convolver is reset before and after use.
+func (c *Convolver[T]) ProcessSoundFile(sf sndfile.SoundFiler[T]) (*sndfile.SoundFile[T], error) {
+	if sf.NumChannels() != c.numInputs {
+		return nil, errors.New("sound file channel count does not match the convolver")
+	}
+
+	if sf.SampleRate() != c.sampleRate {
+		return nil, errors.New("sound file sample rate does not match the convolver")
+	}
+
+	c.Reset()
+	defer c.Reset()
+
+	numFrames := int(sf.NumFrames())
+	outFrames := numFrames + c.irLength - 1
+	latency := c.Latency()
+
+	inputs := make([][]T, c.numInputs)
+	for i := range inputs {
+		inputs[i] = sf.Buffer(i, 0)
+	}
+
+	outputs := make([][]T, c.numOutputs)
+	for o := range outputs {
+		outputs[o] = make([]T, outFrames)
+	}
+
+	var idx int
+
+	in := func(channel int) float64 {
+		if idx < numFrames {
+			return float64(inputs[channel][idx])
+		}
+		return 0
+	}
+
+	out := func(channel int, v float64) {
+		if j := idx - latency; j >= 0 && j < outFrames {
+			outputs[channel][j] = T(v)
+		}
+	}
+
+	for idx = 0; idx < outFrames+latency; idx++ {
+		c.tick(in, out)
+	}
+
+	return sndfile.NewSoundFileFromBuffers(outputs, c.sampleRate), nil
+}
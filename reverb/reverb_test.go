@@ -0,0 +1,230 @@
+package reverb
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/almerlucke/sndfile"
+)
+
+func noise(rng *rand.Rand, n int, decay float64) []float64 {
+	buf := make([]float64, n)
+	for i := range buf {
+		buf[i] = rng.NormFloat64()
+		if decay > 0 {
+			buf[i] *= math.Exp(-float64(i) / decay)
+		}
+	}
+
+	return buf
+}
+
+// convolveAt returns sample n of the direct convolution of x and h
+func convolveAt(x []float64, h []float64, n int) float64 {
+	var sum float64
+
+	for j, v := range h {
+		if k := n - j; k >= 0 && k < len(x) {
+			sum += x[k] * v
+		}
+	}
+
+	return sum
+}
+
+func TestConvolverMatchesDirect(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	irs := make([][]float64, 4)
+	for i := range irs {
+		irs[i] = noise(rng, 1500, 300)
+	}
+
+	tests := []struct {
+		name      string
+		ir        [][]float64
+		numInputs int
+		// per output the list of (input, ir) pairs that are summed
+		paths [][][2]int
+	}{
+		{"mono ir, mono in", irs[:1], 1, [][][2]int{{{0, 0}}}},
+		{"mono ir, stereo in", irs[:1], 2, [][][2]int{{{0, 0}}, {{1, 0}}}},
+		{"stereo ir, mono in", irs[:2], 1, [][][2]int{{{0, 0}}, {{0, 1}}}},
+		{"stereo ir, stereo in", irs[:2], 2, [][][2]int{{{0, 0}}, {{1, 1}}}},
+		{"true stereo", irs, 2, [][][2]int{{{0, 0}, {1, 2}}, {{0, 1}, {1, 3}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputs := make([][]float64, tt.numInputs)
+			for i := range inputs {
+				inputs[i] = noise(rng, 2000, 0)
+			}
+
+			opt := DefaultOptions()
+			opt.BlockSize = 128
+			opt.Dry = 0.5
+
+			c, err := New[float64](sndfile.NewSoundFileFromBuffers(tt.ir, 48000), tt.numInputs, 48000, opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.NumOutputChannels() != len(tt.paths) {
+				t.Fatalf("%d output channels, want %d", c.NumOutputChannels(), len(tt.paths))
+			}
+
+			out, err := c.ProcessSoundFile(sndfile.NewSoundFileFromBuffers(inputs, 48000))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want := int64(len(inputs[0]) + len(tt.ir[0]) - 1); out.NumFrames() != want {
+				t.Fatalf("%d frames, want %d", out.NumFrames(), want)
+			}
+
+			for o, paths := range tt.paths {
+				buf := out.Buffer(o, 0)
+
+				for n := range buf {
+					var want float64
+					for _, p := range paths {
+						want += convolveAt(inputs[p[0]], tt.ir[p[1]], n)
+					}
+
+					if dry := inputs[min(o, tt.numInputs-1)]; n < len(dry) {
+						want += 0.5 * dry[n]
+					}
+
+					if math.Abs(buf[n]-want) > 1e-9 {
+						t.Fatalf("output %d frame %d is %f, want %f", o, n, buf[n], want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestConvolverStreaming(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	ir := sndfile.NewSoundFileFromBuffers([][]float64{noise(rng, 700, 200), noise(rng, 700, 200)}, 44100)
+	x := noise(rng, 3000, 0)
+
+	c, err := New[float64](ir, 1, 44100, Options{BlockSize: 64, Wet: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offline, err := c.ProcessSoundFile(sndfile.NewSoundFileFromBuffers([][]float64{x}, 44100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Feed odd sized interleaved blocks, the output is the offline result delayed by the latency
+	out := make([]float64, 2*len(x))
+	for s := 0; s < len(x); s += 37 {
+		end := min(s+37, len(x))
+		c.ProcessInterleaved(x[s:end], out[2*s:2*end])
+	}
+
+	for n := c.Latency(); n < len(x); n++ {
+		for ch := 0; ch < 2; ch++ {
+			if want := offline.Buffer(ch, 0)[n-c.Latency()]; math.Abs(out[2*n+ch]-want) > 1e-9 {
+				t.Fatalf("channel %d frame %d is %f, want %f", ch, n, out[2*n+ch], want)
+			}
+		}
+	}
+}
+
+func TestImpulseResponseOptions(t *testing.T) {
+	ir := make([]float64, 1000)
+	for i := range ir {
+		ir[i] = math.Pow(0.99, float64(i))
+	}
+	ir[0] = 0.0001
+
+	tests := []struct {
+		name   string
+		opt    Options
+		length int
+		check  func(kernel []float64) bool
+	}{
+		{"default", Options{}, 1000, nil},
+		{"pre delay", Options{PreDelay: 0.01}, 1010, func(k []float64) bool { return k[9] == 0 && k[10] != 0 }},
+		{"max length", Options{MaxLength: 0.5}, 500, func(k []float64) bool { return k[499] == 0 }},
+		{"trim tail", Options{TrimThreshold: 60}, 689, nil},
+		{"trim leading", Options{TrimThreshold: 60, TrimLeading: true}, 688, nil},
+		{"normalize", Options{Normalize: true}, 1000, func(k []float64) bool {
+			var energy float64
+			for _, v := range k {
+				energy += v * v
+			}
+			return math.Abs(energy-1) < 1e-9
+		}},
+	}
+
+	for _, tt := range tests {
+		kernels, err := prepareImpulseResponse[float64](sndfile.NewSoundFileFromBuffers([][]float64{ir}, 1000), 1000, tt.opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(kernels[0]) != tt.length {
+			t.Errorf("%s: length %d, want %d", tt.name, len(kernels[0]), tt.length)
+		}
+
+		if tt.check != nil && !tt.check(kernels[0]) {
+			t.Errorf("%s: unexpected kernel", tt.name)
+		}
+	}
+
+	// A different sample rate resamples the impulse response
+	kernels, err := prepareImpulseResponse[float64](sndfile.NewSoundFileFromBuffers([][]float64{ir}, 1000), 2000, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(kernels[0]) != 2000 {
+		t.Fatalf("resampled length %d", len(kernels[0]))
+	}
+}
+
+func TestConvolverErrors(t *testing.T) {
+	mono := sndfile.NewSoundFileFromBuffers([][]float64{{1, 0.5}}, 48000)
+	three := sndfile.NewSoundFileFromBuffers([][]float64{{1}, {1}, {1}}, 48000)
+	empty := sndfile.NewSoundFileFromBuffers([][]float64{{}}, 48000)
+
+	tests := []struct {
+		name      string
+		ir        *sndfile.SoundFile[float64]
+		numInputs int
+		opt       Options
+	}{
+		{"no inputs", mono, 0, DefaultOptions()},
+		{"three inputs", mono, 3, DefaultOptions()},
+		{"negative block size", mono, 1, Options{BlockSize: -1}},
+		{"three channel ir", three, 1, DefaultOptions()},
+		{"empty ir", empty, 1, DefaultOptions()},
+		{"max length below one frame", mono, 1, Options{MaxLength: 0.00001}},
+	}
+
+	for _, tt := range tests {
+		if _, err := New[float64](tt.ir, tt.numInputs, 48000, tt.opt); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+
+	c, err := New[float64](mono, 1, 48000, DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.ProcessSoundFile(sndfile.NewSoundFileFromBuffers([][]float64{{1}}, 44100)); err == nil {
+		t.Error("expected error for sample rate mismatch")
+	}
+
+	if _, err := c.ProcessSoundFile(sndfile.NewSoundFileFromBuffers([][]float64{{1}, {1}}, 48000)); err == nil {
+		t.Error("expected error for channel count mismatch")
+	}
+}
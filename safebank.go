@@ -0,0 +1,386 @@
+package sndfile
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/almerlucke/sndfile/float"
+)
+
+// GroupSeparator separates the groups in hierarchical entry names like drums/kick/hard
+const GroupSeparator = "/"
+
+// EntryInfo holds the tags and metadata of a SafeSoundBank entry
+type EntryInfo struct {
+	Tags     []string
+	Metadata map[string]string
+}
+
+type safeEntry[T float.Float] struct {
+	sf   SoundFiler[T]
+	info EntryInfo
+}
+
+// Query selects SafeSoundBank entries, zero fields match everything
+type Query[T float.Float] struct {
+	// Group matches entries in the group or its subgroups
+	Group string
+	// Tags must all be present
+	Tags []string
+	// Metadata values must all match
+	Metadata map[string]string
+	Channels int
+	// SampleRate must match exactly
+	SampleRate float64
+	// MinDuration and MaxDuration in seconds, MaxDuration is exclusive
+	MinDuration float64
+	MaxDuration float64
+	// Match is an additional filter, it is called without holding the lock of the bank so it may
+	// use the bank
+	Match func(name string, sf SoundFiler[T]) bool
+}
+
+// SafeSoundBank is a SoundBank that is safe for concurrent use, with hierarchical names,
+// aliases, tags and metadata
+type SafeSoundBank[T float.Float] struct {
+	mu      sync.RWMutex
+	entries map[string]*safeEntry[T]
+	aliases map[string]string
+}
+
+// NewSafeSoundBank creates a SafeSoundBank with the entries of bank, bank may be nil and nil
+// sound files in bank are skipped
+func NewSafeSoundBank[T float.Float](bank SoundBank[T]) *SafeSoundBank[T] {
+	sb := &SafeSoundBank[T]{
+		entries: map[string]*safeEntry[T]{},
+		aliases: map[string]string{},
+	}
+
+	for name, sf := range bank {
+		if sf == nil {
+			continue
+		}
+
+		sb.entries[name] = &safeEntry[T]{sf: sf}
+	}
+
+	return sb
+}
+
+// resolve returns the entry name of a name or alias
+func (sb *SafeSoundBank[T]) resolve(name string) string {
+	if target, ok := sb.aliases[name]; ok {
+		return target
+	}
+
+	return name
+}
+
+// Set adds or replaces an entry, the tags and metadata of a replaced entry are kept
+func (sb *SafeSoundBank[T]) Set(name string, sf SoundFiler[T], tags ...string) error {
+	if sf == nil {
+		return errors.New("sound file is nil")
+	}
+
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	if _, ok := sb.aliases[name]; ok {
+		return errors.New("name is an alias")
+	}
+
+	e, ok := sb.entries[name]
+	if !ok {
+		e = &safeEntry[T]{}
+		sb.entries[name] = e
+	}
+
+	e.sf = sf
+	e.addTags(tags)
+
+	return nil
+}
+
+// Get returns the sound file of a name or alias
+func (sb *SafeSoundBank[T]) Get(name string) (SoundFiler[T], bool) {
+	sb.mu.RLock()
+	defer sb.mu.RUnlock()
+
+	if e, ok := sb.entries[sb.resolve(name)]; ok {
+		return e.sf, true
+	}
+
+	return nil, false
+}
+
+// Remove deletes an entry and its aliases, removing an alias only deletes the alias
+func (sb *SafeSoundBank[T]) Remove(name string) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	if _, ok := sb.aliases[name]; ok {
+		delete(sb.aliases, name)
+		return
+	}
+
+	delete(sb.entries, name)
+
+	maps.DeleteFunc(sb.aliases, func(_ string, target string) bool {
+		return target == name
+	})
+}
+
+// Alias adds an alternative name for an entry
+func (sb *SafeSoundBank[T]) Alias(alias string, name string) error {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	if _, ok := sb.entries[alias]; ok {
+		return errors.New("alias is an entry name")
+	}
+
+	name = sb.resolve(name)
+	if _, ok := sb.entries[name]; !ok {
+		return errors.New("entry not found")
+	}
+
+	sb.aliases[alias] = name
+
+	return nil
+}
+
+// Aliases returns the aliases of an entry
+func (sb *SafeSoundBank[T]) Aliases(name string) []string {
+	sb.mu.RLock()
+	defer sb.mu.RUnlock()
+
+	var aliases []string
+
+	for alias, target := range sb.aliases {
+		if target == name {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	slices.Sort(aliases)
+
+	return aliases
+}
+
+func (e *safeEntry[T]) addTags(tags []string) {
+	for _, tag := range tags {
+		if !slices.Contains(e.info.Tags, tag) {
+			e.info.Tags = append(e.info.Tags, tag)
+		}
+	}
+}
+
+// Tag adds tags to an entry
+func (sb *SafeSoundBank[T]) Tag(name string, tags ...string) error {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	e, ok := sb.entries[sb.resolve(name)]
+	if !ok {
+		return errors.New("entry not found")
+	}
+
+	e.addTags(tags)
+
+	return nil
+}
+
+// Untag removes tags from an entry
+func (sb *SafeSoundBank[T]) Untag(name string, tags ...string) error {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	e, ok := sb.entries[sb.resolve(name)]
+	if !ok {
+		return errors.New("entry not found")
+	}
+
+	e.info.Tags = slices.DeleteFunc(e.info.Tags, func(tag string) bool {
+		return slices.Contains(tags, tag)
+	})
+
+	return nil
+}
+
+// SetMetadata sets a metadata value of an entry
+func (sb *SafeSoundBank[T]) SetMetadata(name string, key string, value string) error {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	e, ok := sb.entries[sb.resolve(name)]
+	if !ok {
+		return errors.New("entry not found")
+	}
+
+	if e.info.Metadata == nil {
+		e.info.Metadata = map[string]string{}
+	}
+
+	e.info.Metadata[key] = value
+
+	return nil
+}
+
+// Info returns a copy of the tags and metadata of an entry
+func (sb *SafeSoundBank[T]) Info(name string) (EntryInfo, bool) {
+	sb.mu.RLock()
+	defer sb.mu.RUnlock()
+
+	e, ok := sb.entries[sb.resolve(name)]
+	if !ok {
+		return EntryInfo{}, false
+	}
+
+	return EntryInfo{
+		Tags:     slices.Clone(e.info.Tags),
+		Metadata: maps.Clone(e.info.Metadata),
+	}, true
+}
+
+// InGroup returns true if name is in group or one of its subgroups, the empty group contains
+// all names
+func InGroup(name string, group string) bool {
+	group = strings.TrimSuffix(group, GroupSeparator)
+
+	return group == "" || strings.HasPrefix(name, group+GroupSeparator)
+}
+
+// Groups returns the direct subgroups of group
+func (sb *SafeSoundBank[T]) Groups(group string) []string {
+	sb.mu.RLock()
+	defer sb.mu.RUnlock()
+
+	prefix := strings.TrimSuffix(group, GroupSeparator)
+	if prefix != "" {
+		prefix += GroupSeparator
+	}
+
+	var groups []string
+
+	for name := range sb.entries {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		rest := name[len(prefix):]
+		if i := strings.Index(rest, GroupSeparator); i >= 0 {
+			sub := prefix + rest[:i]
+			if !slices.Contains(groups, sub) {
+				groups = append(groups, sub)
+			}
+		}
+	}
+
+	slices.Sort(groups)
+
+	return groups
+}
+
+// matches checks all query fields except Match
+func (e *safeEntry[T]) matches(name string, q *Query[T]) bool {
+	if q.Group != "" && !InGroup(name, q.Group) {
+		return false
+	}
+
+	for _, tag := range q.Tags {
+		if !slices.Contains(e.info.Tags, tag) {
+			return false
+		}
+	}
+
+	for key, value := range q.Metadata {
+		if v, ok := e.info.Metadata[key]; !ok || v != value {
+			return false
+		}
+	}
+
+	sf := e.sf
+
+	if q.Channels > 0 && sf.NumChannels() != q.Channels {
+		return false
+	}
+
+	if q.SampleRate > 0 && sf.SampleRate() != q.SampleRate {
+		return false
+	}
+
+	if sf.Duration() < q.MinDuration || (q.MaxDuration > 0 && sf.Duration() >= q.MaxDuration) {
+		return false
+	}
+
+	return true
+}
+
+// Find returns the sorted names of all entries matching the query
+func (sb *SafeSoundBank[T]) Find(q Query[T]) []string {
+	var names []string
+	var files []SoundFiler[T]
+
+	sb.mu.RLock()
+
+	for name, e := range sb.entries {
+		if e.matches(name, &q) {
+			names = append(names, name)
+			files = append(files, e.sf)
+		}
+	}
+
+	sb.mu.RUnlock()
+
+	if q.Match != nil {
+		n := 0
+
+		for i, name := range names {
+			if q.Match(name, files[i]) {
+				names[n] = name
+				n++
+			}
+		}
+
+		names = names[:n]
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// Names returns the sorted names of all entries
+func (sb *SafeSoundBank[T]) Names() []string {
+	return sb.Find(Query[T]{})
+}
+
+// Len returns the number of entries
+func (sb *SafeSoundBank[T]) Len() int {
+	sb.mu.RLock()
+	defer sb.mu.RUnlock()
+
+	return len(sb.entries)
+}
+
+// Snapshot returns a plain SoundBank with the current entries and aliases, for example to build
+// a Keymap
+func (sb *SafeSoundBank[T]) Snapshot() SoundBank[T] {
+	sb.mu.RLock()
+	defer sb.mu.RUnlock()
+
+	bank := make(SoundBank[T], len(sb.entries)+len(sb.aliases))
+
+	for name, e := range sb.entries {
+		bank[name] = e.sf
+	}
+
+	for alias, name := range sb.aliases {
+		bank[alias] = sb.entries[name].sf
+	}
+
+	return bank
+}
@@ -0,0 +1,227 @@
+package sndfile
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+// silentFile returns a silent sound file at a sample rate of 1000
+func silentFile(channels int, numFrames int) SoundFiler[float64] {
+	buffers := make([][]float64, channels)
+	for c := range buffers {
+		buffers[c] = make([]float64, numFrames)
+	}
+
+	return NewSoundFileFromBuffers(buffers, 1000)
+}
+
+func testSafeBank(t *testing.T) *SafeSoundBank[float64] {
+	sb := NewSafeSoundBank(SoundBank[float64]{"pads/warm": silentFile(2, 5000), "nil": nil})
+
+	entries := []struct {
+		name     string
+		channels int
+		frames   int
+		tags     []string
+	}{
+		{"drums/kick/01", 1, 300, []string{"kick"}},
+		{"drums/kick/02", 1, 700, []string{"kick"}},
+		{"drums/kick/03", 2, 100, []string{"kick"}},
+		{"drums/snare/01", 1, 100, []string{"snare"}},
+	}
+
+	for _, e := range entries {
+		if err := sb.Set(e.name, silentFile(e.channels, e.frames), e.tags...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := sb.Alias("bd", "drums/kick/01"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sb.SetMetadata("bd", "author", "x"); err != nil {
+		t.Fatal(err)
+	}
+
+	return sb
+}
+
+func TestSafeSoundBankFind(t *testing.T) {
+	sb := testSafeBank(t)
+
+	tests := []struct {
+		name  string
+		query Query[float64]
+		want  []string
+	}{
+		{"all", Query[float64]{}, []string{"drums/kick/01", "drums/kick/02", "drums/kick/03", "drums/snare/01", "pads/warm"}},
+		{"group", Query[float64]{Group: "drums/kick"}, []string{"drums/kick/01", "drums/kick/02", "drums/kick/03"}},
+		{"group prefix", Query[float64]{Group: "drums/k"}, nil},
+		{"tags", Query[float64]{Tags: []string{"snare"}}, []string{"drums/snare/01"}},
+		{"metadata", Query[float64]{Metadata: map[string]string{"author": "x"}}, []string{"drums/kick/01"}},
+		{"channels", Query[float64]{Channels: 2}, []string{"drums/kick/03", "pads/warm"}},
+		{"duration", Query[float64]{Tags: []string{"kick"}, MinDuration: 0.2, MaxDuration: 0.7}, []string{"drums/kick/01"}},
+		{"match", Query[float64]{Match: func(name string, sf SoundFiler[float64]) bool { return sf.NumFrames() == 100 }}, []string{"drums/kick/03", "drums/snare/01"}},
+	}
+
+	for _, tt := range tests {
+		if got := sb.Find(tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSafeSoundBankNil(t *testing.T) {
+	sb := testSafeBank(t)
+
+	if _, ok := sb.Get("nil"); ok {
+		t.Fatal("nil sound file copied from bank")
+	}
+
+	if err := sb.Set("x", nil); err == nil {
+		t.Fatal("expected error setting a nil sound file")
+	}
+
+	if sb.Len() != 5 {
+		t.Fatalf("%d entries", sb.Len())
+	}
+}
+
+func TestSafeSoundBankMatchCallback(t *testing.T) {
+	sb := testSafeBank(t)
+
+	// The Match callback may use the bank
+	got := sb.Find(Query[float64]{
+		Group: "drums/snare",
+		Match: func(name string, sf SoundFiler[float64]) bool {
+			return sb.Set(name+"-copy", sf) == nil && len(sb.Aliases("drums/kick/01")) == 1
+		},
+	})
+
+	if !slices.Equal(got, []string{"drums/snare/01"}) {
+		t.Fatalf("found %v", got)
+	}
+
+	if _, ok := sb.Get("drums/snare/01-copy"); !ok {
+		t.Fatal("entry not set from callback")
+	}
+}
+
+func TestSafeSoundBankAliases(t *testing.T) {
+	sb := testSafeBank(t)
+
+	if err := sb.Alias("drums/kick/02", "bd"); err == nil {
+		t.Fatal("expected error for an alias that is an entry name")
+	}
+
+	if err := sb.Alias("x", "missing"); err == nil {
+		t.Fatal("expected error for an unknown entry")
+	}
+
+	if err := sb.Set("bd", silentFile(1, 10)); err == nil {
+		t.Fatal("expected error setting an alias")
+	}
+
+	// An alias of an alias resolves to the entry
+	if err := sb.Alias("kick", "bd"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := sb.Aliases("drums/kick/01"); !slices.Equal(got, []string{"bd", "kick"}) {
+		t.Fatalf("aliases %v", got)
+	}
+
+	if len(sb.Snapshot()) != 7 {
+		t.Fatalf("snapshot has %d entries", len(sb.Snapshot()))
+	}
+
+	sb.Remove("kick")
+
+	if _, ok := sb.Get("bd"); !ok {
+		t.Fatal("removing an alias removed the entry")
+	}
+
+	sb.Remove("drums/kick/01")
+
+	if _, ok := sb.Get("bd"); ok {
+		t.Fatal("alias kept after removing the entry")
+	}
+}
+
+func TestSafeSoundBankTags(t *testing.T) {
+	sb := testSafeBank(t)
+
+	if err := sb.Tag("bd", "kick", "808"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replacing an entry keeps its tags and metadata
+	if err := sb.Set("drums/kick/01", silentFile(1, 10), "short"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sb.Untag("drums/kick/01", "kick"); err != nil {
+		t.Fatal(err)
+	}
+
+	info, _ := sb.Info("bd")
+	if !slices.Equal(info.Tags, []string{"808", "short"}) || info.Metadata["author"] != "x" {
+		t.Fatalf("info %+v", info)
+	}
+
+	// Info returns a copy
+	info.Tags[0] = "changed"
+	if info, _ := sb.Info("bd"); info.Tags[0] != "808" {
+		t.Fatal("info shares tags with the bank")
+	}
+
+	for _, err := range []error{sb.Tag("missing", "t"), sb.Untag("missing", "t"), sb.SetMetadata("missing", "k", "v")} {
+		if err == nil {
+			t.Fatal("expected error for an unknown entry")
+		}
+	}
+}
+
+func TestSafeSoundBankGroups(t *testing.T) {
+	sb := testSafeBank(t)
+
+	tests := []struct {
+		group string
+		want  []string
+	}{
+		{"", []string{"drums", "pads"}},
+		{"drums", []string{"drums/kick", "drums/snare"}},
+		{"drums/", []string{"drums/kick", "drums/snare"}},
+		{"drums/kick", nil},
+	}
+
+	for _, tt := range tests {
+		if got := sb.Groups(tt.group); !slices.Equal(got, tt.want) {
+			t.Errorf("%q: %v, want %v", tt.group, got, tt.want)
+		}
+	}
+}
+
+func TestSafeSoundBankConcurrent(t *testing.T) {
+	sb := testSafeBank(t)
+
+	var wg sync.WaitGroup
+
+	for range 8 {
+		wg.Go(func() {
+			sb.Set("x", silentFile(1, 10))
+			sb.Get("bd")
+			sb.Tag("x", "t")
+			sb.Find(Query[float64]{Tags: []string{"t"}})
+			sb.Snapshot()
+		})
+	}
+
+	wg.Wait()
+
+	if got := sb.Find(Query[float64]{Tags: []string{"t"}}); !slices.Equal(got, []string{"x"}) {
+		t.Fatalf("found %v", got)
+	}
+}
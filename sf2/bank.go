@@ -0,0 +1,256 @@
+package sf2
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/almerlucke/sndfile"
+	"github.com/almerlucke/sndfile/float"
+	"github.com/almerlucke/sndfile/player"
+	"github.com/almerlucke/sndfile/sfz"
+)
+
+// Generator operators used for playback
+const (
+	GenStartAddrsOffset       uint16 = 0
+	GenStartLoopAddrsOffset   uint16 = 2
+	GenEndLoopAddrsOffset     uint16 = 3
+	GenStartAddrsCoarseOffset uint16 = 4
+	GenAttackVolEnv           uint16 = 34
+	GenDecayVolEnv            uint16 = 36
+	GenSustainVolEnv          uint16 = 37
+	GenReleaseVolEnv          uint16 = 38
+	GenInstrument             uint16 = 41
+	GenKeyRange               uint16 = 43
+	GenVelRange               uint16 = 44
+	GenStartLoopCoarseOffset  uint16 = 45
+	GenInitialAttenuation     uint16 = 48
+	GenEndLoopCoarseOffset    uint16 = 50
+	GenCoarseTune             uint16 = 51
+	GenFineTune               uint16 = 52
+	GenSampleID               uint16 = 53
+	GenSampleModes            uint16 = 54
+	GenOverridingRootKey      uint16 = 58
+)
+
+// generators holds the combined generators of a global and local zone
+type generators map[uint16]Generator
+
+func merge(global *Zone, zone *Zone) generators {
+	gens := generators{}
+
+	for _, z := range []*Zone{global, zone} {
+		if z == nil {
+			continue
+		}
+
+		for _, g := range z.Generators {
+			gens[g.Operator] = g
+		}
+	}
+
+	return gens
+}
+
+func (gens generators) int(operator uint16, def int) int {
+	if g, ok := gens[operator]; ok {
+		return g.Int()
+	}
+
+	return def
+}
+
+func (gens generators) keyRange(operator uint16) (int, int) {
+	if g, ok := gens[operator]; ok {
+		return g.Range()
+	}
+
+	return 0, 127
+}
+
+// SampleData returns the normalized sample data of a sample, including the sm24 low bytes
+// when present
+func SampleData[T float.Float](f *File, sh SampleHeader) []T {
+	buf := make([]T, sh.End-sh.Start)
+
+	for i := range buf {
+		index := int(sh.Start) + i
+
+		if f.data24 != nil {
+			buf[i] = T(float64(int32(f.data[index])<<8|int32(f.data24[index])) / 8388608.0)
+		} else {
+			buf[i] = T(float64(f.data[index]) / 32768.0)
+		}
+	}
+
+	return buf
+}
+
+// SampleNames returns the SoundBank names of the samples, duplicate sample names get the sample
+// index appended
+func (f *File) SampleNames() []string {
+	names := make([]string, len(f.Samples))
+	seen := map[string]bool{}
+
+	for i, sh := range f.Samples {
+		name := sh.Name
+		if seen[name] {
+			name = fmt.Sprintf("%s#%d", name, i)
+		}
+
+		seen[name] = true
+		names[i] = name
+	}
+
+	return names
+}
+
+// SoundBank returns all samples of f as mono sound files, ROM samples are skipped
+func SoundBank[T float.Float](f *File) sndfile.SoundBank[T] {
+	bank := sndfile.SoundBank[T]{}
+	names := f.SampleNames()
+
+	for i, sh := range f.Samples {
+		if sh.SampleType&ROMSample != 0 {
+			continue
+		}
+
+		bank[names[i]] = sndfile.NewSoundFileFromBuffers([][]T{SampleData[T](f, sh)}, float64(sh.SampleRate))
+	}
+
+	return bank
+}
+
+// timecentsToSeconds converts an absolute time in timecents to seconds
+func timecentsToSeconds(tc int) float64 {
+	return math.Pow(2, float64(tc)/1200.0)
+}
+
+// centibelsToGain converts an attenuation in centibels to a linear gain
+func centibelsToGain(cb int) float64 {
+	return math.Pow(10, -float64(max(cb, 0))/200.0)
+}
+
+// Regions returns the playable regions of a preset, preset generators are added to the
+// instrument generators as the SoundFont 2 specification prescribes
+func (f *File) Regions(preset int) ([]sfz.Region, error) {
+	if preset < 0 || preset >= len(f.Presets) {
+		return nil, errors.New("preset index out of range")
+	}
+
+	names := f.SampleNames()
+	p := &f.Presets[preset]
+
+	var regions []sfz.Region
+
+	for i := range p.Zones {
+		pgens := merge(p.Global, &p.Zones[i])
+
+		instIndex := pgens.int(GenInstrument, -1)
+		if instIndex < 0 || instIndex >= len(f.Instruments) {
+			return nil, errors.New("instrument index out of range")
+		}
+
+		inst := &f.Instruments[instIndex]
+
+		for j := range inst.Zones {
+			igens := merge(inst.Global, &inst.Zones[j])
+
+			sampleIndex := int(igens[GenSampleID].Amount)
+			if sampleIndex >= len(f.Samples) {
+				return nil, errors.New("sample index out of range")
+			}
+
+			sh := f.Samples[sampleIndex]
+			if sh.SampleType&ROMSample != 0 {
+				continue
+			}
+
+			r, ok := region(igens, pgens, sh)
+			if !ok {
+				continue
+			}
+
+			r.Zone.Sample = names[sampleIndex]
+			regions = append(regions, r)
+		}
+	}
+
+	return regions, nil
+}
+
+// region combines instrument and preset generators into a region, false is returned when the
+// key or velocity ranges do not overlap
+func region(igens generators, pgens generators, sh SampleHeader) (sfz.Region, bool) {
+	r := sfz.DefaultRegion()
+	z := &r.Zone
+
+	ilo, ihi := igens.keyRange(GenKeyRange)
+	plo, phi := pgens.keyRange(GenKeyRange)
+	z.LowKey, z.HighKey = max(ilo, plo), min(ihi, phi)
+
+	ilo, ihi = igens.keyRange(GenVelRange)
+	plo, phi = pgens.keyRange(GenVelRange)
+	z.LowVelocity, z.HighVelocity = max(ilo, plo, 1), min(ihi, phi)
+
+	if z.LowKey > z.HighKey || z.LowVelocity > z.HighVelocity {
+		return r, false
+	}
+
+	z.RootKey = igens.int(GenOverridingRootKey, -1)
+	if z.RootKey < 0 {
+		z.RootKey = int(sh.OriginalPitch)
+		if z.RootKey > 127 {
+			z.RootKey = 60
+		}
+	}
+
+	z.Tune = float64((igens.int(GenCoarseTune, 0)+pgens.int(GenCoarseTune, 0))*100 +
+		igens.int(GenFineTune, 0) + pgens.int(GenFineTune, 0) + int(sh.PitchCorrection))
+	z.Gain = centibelsToGain(igens.int(GenInitialAttenuation, 0) + pgens.int(GenInitialAttenuation, 0))
+
+	r.Offset = int64(igens.int(GenStartAddrsOffset, 0) + igens.int(GenStartAddrsCoarseOffset, 0)*32768)
+
+	switch igens.int(GenSampleModes, 0) & 3 {
+	case 1:
+		r.Loop = player.LoopForward
+	case 3:
+		r.Loop = player.LoopSustain
+	}
+
+	r.LoopStart = int64(sh.StartLoop) - int64(sh.Start) +
+		int64(igens.int(GenStartLoopAddrsOffset, 0)+igens.int(GenStartLoopCoarseOffset, 0)*32768)
+	r.LoopEnd = int64(sh.EndLoop) - int64(sh.Start) +
+		int64(igens.int(GenEndLoopAddrsOffset, 0)+igens.int(GenEndLoopCoarseOffset, 0)*32768)
+
+	if r.LoopStart < 0 || r.LoopEnd <= r.LoopStart {
+		r.Loop = player.LoopOff
+		r.LoopStart, r.LoopEnd = 0, 0
+	}
+
+	r.Envelope = player.ADSR{
+		Attack:  timecentsToSeconds(igens.int(GenAttackVolEnv, -12000) + pgens.int(GenAttackVolEnv, 0)),
+		Decay:   timecentsToSeconds(igens.int(GenDecayVolEnv, -12000) + pgens.int(GenDecayVolEnv, 0)),
+		Sustain: centibelsToGain(igens.int(GenSustainVolEnv, 0) + pgens.int(GenSustainVolEnv, 0)),
+		Release: timecentsToSeconds(igens.int(GenReleaseVolEnv, -12000) + pgens.int(GenReleaseVolEnv, 0)),
+	}
+
+	return r, true
+}
+
+// Load reads a SoundFont 2 file and returns a preset as an instrument with all samples of the
+// file in its SoundBank
+func Load[T float.Float](filePath string, preset int) (*sfz.Instrument[T], error) {
+	f, err := Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	regions, err := f.Regions(preset)
+	if err != nil {
+		return nil, err
+	}
+
+	return sfz.NewInstrument(SoundBank[T](f), regions)
+}
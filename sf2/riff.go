@@ -0,0 +1,74 @@
+package sf2
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+type chunk struct {
+	id   string
+	data []byte
+}
+
+// readChunks splits data into RIFF chunks
+func readChunks(data []byte) ([]chunk, error) {
+	var chunks []chunk
+
+	for len(data) >= 8 {
+		id := string(data[:4])
+		size := int(binary.LittleEndian.Uint32(data[4:8]))
+		data = data[8:]
+
+		if size > len(data) {
+			return nil, errors.New("chunk size exceeds file size")
+		}
+
+		chunks = append(chunks, chunk{id: id, data: data[:size]})
+
+		// Chunks are padded to an even size
+		size += size & 1
+		if size > len(data) {
+			size = len(data)
+		}
+
+		data = data[size:]
+	}
+
+	return chunks, nil
+}
+
+// readList returns the form type and sub chunks of a RIFF or LIST chunk
+func readList(c chunk) (string, []chunk, error) {
+	if len(c.data) < 4 {
+		return "", nil, errors.New("list chunk too small")
+	}
+
+	chunks, err := readChunks(c.data[4:])
+
+	return string(c.data[:4]), chunks, err
+}
+
+// records splits chunk data into fixed size records
+func records(data []byte, size int, name string) ([][]byte, error) {
+	if len(data)%size != 0 {
+		return nil, errors.New("invalid " + name + " chunk size")
+	}
+
+	recs := make([][]byte, len(data)/size)
+	for i := range recs {
+		recs[i] = data[i*size : (i+1)*size]
+	}
+
+	return recs, nil
+}
+
+// cString returns a zero terminated string
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+
+	return string(b)
+}
@@ -0,0 +1,330 @@
+// Package sf2 reads SoundFont 2 files
+package sf2
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// SampleType flags of a sample header
+const (
+	MonoSample   uint16 = 1
+	RightSample  uint16 = 2
+	LeftSample   uint16 = 4
+	LinkedSample uint16 = 8
+	ROMSample    uint16 = 0x8000
+)
+
+// SampleHeader describes a sample in the sample data, positions are in sample data points
+type SampleHeader struct {
+	Name            string
+	Start           uint32
+	End             uint32
+	StartLoop       uint32
+	EndLoop         uint32
+	SampleRate      uint32
+	OriginalPitch   uint8
+	PitchCorrection int8
+	SampleLink      uint16
+	SampleType      uint16
+}
+
+// Generator is a zone generator, the amount is interpreted depending on the operator
+type Generator struct {
+	Operator uint16
+	Amount   uint16
+}
+
+// Int returns the amount as a signed value
+func (g Generator) Int() int {
+	return int(int16(g.Amount))
+}
+
+// Range returns the amount as a low and high range
+func (g Generator) Range() (int, int) {
+	return int(g.Amount & 0xff), int(g.Amount >> 8)
+}
+
+// Zone is a preset or instrument zone
+type Zone struct {
+	Generators []Generator
+}
+
+// Generator returns the last generator with operator in the zone
+func (z *Zone) Generator(operator uint16) (Generator, bool) {
+	for i := len(z.Generators) - 1; i >= 0; i-- {
+		if z.Generators[i].Operator == operator {
+			return z.Generators[i], true
+		}
+	}
+
+	return Generator{}, false
+}
+
+// Instrument is an SF2 instrument, Global holds the global zone if the instrument has one
+type Instrument struct {
+	Name   string
+	Global *Zone
+	Zones  []Zone
+}
+
+// Preset is an SF2 preset, Global holds the global zone if the preset has one
+type Preset struct {
+	Name   string
+	Preset uint16
+	Bank   uint16
+	Global *Zone
+	Zones  []Zone
+}
+
+// File is a parsed SoundFont 2 file
+type File struct {
+	Name        string
+	Samples     []SampleHeader
+	Instruments []Instrument
+	Presets     []Preset
+	data        []int16
+	data24      []byte
+}
+
+// Open reads a SoundFont 2 file from disk
+func Open(filePath string) (*File, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+// Parse parses a SoundFont 2 file
+func Parse(data []byte) (*File, error) {
+	chunks, err := readChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chunks) == 0 || chunks[0].id != "RIFF" {
+		return nil, errors.New("not a RIFF file")
+	}
+
+	form, lists, err := readList(chunks[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if form != "sfbk" {
+		return nil, errors.New("not a SoundFont 2 file")
+	}
+
+	f := &File{}
+	pdta := map[string][]byte{}
+
+	for _, list := range lists {
+		if list.id != "LIST" {
+			continue
+		}
+
+		typ, subs, err := readList(list)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, sub := range subs {
+			switch typ {
+			case "INFO":
+				if sub.id == "INAM" {
+					f.Name = cString(sub.data)
+				}
+			case "sdta":
+				switch sub.id {
+				case "smpl":
+					f.data = make([]int16, len(sub.data)/2)
+					for i := range f.data {
+						f.data[i] = int16(binary.LittleEndian.Uint16(sub.data[i*2:]))
+					}
+				case "sm24":
+					f.data24 = sub.data
+				}
+			case "pdta":
+				pdta[sub.id] = sub.data
+			}
+		}
+	}
+
+	if f.data == nil {
+		return nil, errors.New("missing sample data")
+	}
+
+	// The sm24 chunk is ignored when its size does not match the sample data
+	if len(f.data24) < len(f.data) {
+		f.data24 = nil
+	}
+
+	if err := f.parseHydra(pdta); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+type bag struct {
+	genIndex int
+}
+
+func parseBags(data []byte, name string) ([]bag, error) {
+	recs, err := records(data, 4, name)
+	if err != nil {
+		return nil, err
+	}
+
+	bags := make([]bag, len(recs))
+	for i, rec := range recs {
+		bags[i].genIndex = int(binary.LittleEndian.Uint16(rec))
+	}
+
+	return bags, nil
+}
+
+func parseGenerators(data []byte, name string) ([]Generator, error) {
+	recs, err := records(data, 4, name)
+	if err != nil {
+		return nil, err
+	}
+
+	gens := make([]Generator, len(recs))
+	for i, rec := range recs {
+		gens[i].Operator = binary.LittleEndian.Uint16(rec)
+		gens[i].Amount = binary.LittleEndian.Uint16(rec[2:])
+	}
+
+	return gens, nil
+}
+
+// zones returns the zones of bags bagStart up to bagEnd, the first zone is returned as global
+// zone when it does not end with the terminal operator
+func zones(bags []bag, gens []Generator, bagStart int, bagEnd int, terminal uint16) (*Zone, []Zone, error) {
+	if bagStart > bagEnd || bagEnd >= len(bags) {
+		return nil, nil, errors.New("invalid bag index")
+	}
+
+	var global *Zone
+	var zs []Zone
+
+	for b := bagStart; b < bagEnd; b++ {
+		start, end := bags[b].genIndex, bags[b+1].genIndex
+		if start > end || end > len(gens) {
+			return nil, nil, errors.New("invalid generator index")
+		}
+
+		zone := Zone{Generators: gens[start:end]}
+
+		n := len(zone.Generators)
+		if n > 0 && zone.Generators[n-1].Operator == terminal {
+			zs = append(zs, zone)
+		} else if b == bagStart {
+			global = &zone
+		}
+	}
+
+	return global, zs, nil
+}
+
+func (f *File) parseHydra(pdta map[string][]byte) error {
+	for _, id := range []string{"phdr", "pbag", "pgen", "inst", "ibag", "igen", "shdr"} {
+		if _, ok := pdta[id]; !ok {
+			return fmt.Errorf("missing %s chunk", id)
+		}
+	}
+
+	shdr, err := records(pdta["shdr"], 46, "shdr")
+	if err != nil {
+		return err
+	}
+
+	// The last record of each list is a terminal record
+	for _, rec := range shdr[:max(len(shdr)-1, 0)] {
+		sh := SampleHeader{
+			Name:            cString(rec[:20]),
+			Start:           binary.LittleEndian.Uint32(rec[20:]),
+			End:             binary.LittleEndian.Uint32(rec[24:]),
+			StartLoop:       binary.LittleEndian.Uint32(rec[28:]),
+			EndLoop:         binary.LittleEndian.Uint32(rec[32:]),
+			SampleRate:      binary.LittleEndian.Uint32(rec[36:]),
+			OriginalPitch:   rec[40],
+			PitchCorrection: int8(rec[41]),
+			SampleLink:      binary.LittleEndian.Uint16(rec[42:]),
+			SampleType:      binary.LittleEndian.Uint16(rec[44:]),
+		}
+
+		if sh.Start > sh.End || int(sh.End) > len(f.data) {
+			return fmt.Errorf("sample %s out of range", sh.Name)
+		}
+
+		f.Samples = append(f.Samples, sh)
+	}
+
+	ibags, err := parseBags(pdta["ibag"], "ibag")
+	if err != nil {
+		return err
+	}
+
+	igens, err := parseGenerators(pdta["igen"], "igen")
+	if err != nil {
+		return err
+	}
+
+	insts, err := records(pdta["inst"], 22, "inst")
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i+1 < len(insts); i++ {
+		global, zs, err := zones(ibags, igens,
+			int(binary.LittleEndian.Uint16(insts[i][20:])), int(binary.LittleEndian.Uint16(insts[i+1][20:])), GenSampleID)
+		if err != nil {
+			return err
+		}
+
+		f.Instruments = append(f.Instruments, Instrument{
+			Name:   cString(insts[i][:20]),
+			Global: global,
+			Zones:  zs,
+		})
+	}
+
+	pbags, err := parseBags(pdta["pbag"], "pbag")
+	if err != nil {
+		return err
+	}
+
+	pgens, err := parseGenerators(pdta["pgen"], "pgen")
+	if err != nil {
+		return err
+	}
+
+	phdrs, err := records(pdta["phdr"], 38, "phdr")
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i+1 < len(phdrs); i++ {
+		global, zs, err := zones(pbags, pgens,
+			int(binary.LittleEndian.Uint16(phdrs[i][24:])), int(binary.LittleEndian.Uint16(phdrs[i+1][24:])), GenInstrument)
+		if err != nil {
+			return err
+		}
+
+		f.Presets = append(f.Presets, Preset{
+			Name:   cString(phdrs[i][:20]),
+			Preset: binary.LittleEndian.Uint16(phdrs[i][20:]),
+			Bank:   binary.LittleEndian.Uint16(phdrs[i][22:]),
+			Global: global,
+			Zones:  zs,
+		})
+	}
+
+	return nil
+}
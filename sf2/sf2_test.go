@@ -0,0 +1,367 @@
+package sf2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/almerlucke/sndfile/player"
+	"github.com/almerlucke/sndfile/sfz"
+)
+
+func chunkBytes(id string, data []byte) []byte {
+	var b bytes.Buffer
+
+	b.WriteString(id)
+	binary.Write(&b, binary.LittleEndian, uint32(len(data)))
+	b.Write(data)
+
+	if len(data)%2 == 1 {
+		b.WriteByte(0)
+	}
+
+	return b.Bytes()
+}
+
+func listBytes(typ string, subs ...[]byte) []byte {
+	return chunkBytes("LIST", append([]byte(typ), bytes.Join(subs, nil)...))
+}
+
+func le(values ...any) []byte {
+	var b bytes.Buffer
+	for _, v := range values {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+
+	return b.Bytes()
+}
+
+func name20(s string) []byte {
+	b := make([]byte, 20)
+	copy(b, s)
+
+	return b
+}
+
+// testFont describes a SoundFont with a single preset over a single instrument
+type testFont struct {
+	samples []SampleHeader
+	data    []int16
+	data24  []byte
+	// instrument and preset zones, the terminal generator is appended when missing
+	instZones   [][]Generator
+	presetZones [][]Generator
+}
+
+func keyRange(low int, high int) uint16 {
+	return uint16(low | high<<8)
+}
+
+func signed(v int) uint16 {
+	return uint16(int16(v))
+}
+
+// zoneBytes encodes zones as bag and generator records including the terminal records
+func zoneBytes(zones [][]Generator) ([]byte, []byte) {
+	var bags, gens bytes.Buffer
+	n := 0
+
+	for _, zone := range zones {
+		bags.Write(le(uint16(n), uint16(0)))
+
+		for _, g := range zone {
+			gens.Write(le(g.Operator, g.Amount))
+			n++
+		}
+	}
+
+	bags.Write(le(uint16(n), uint16(0)))
+	gens.Write(make([]byte, 4))
+
+	return bags.Bytes(), gens.Bytes()
+}
+
+func (tf testFont) bytes() []byte {
+	var shdr bytes.Buffer
+	for _, sh := range tf.samples {
+		shdr.Write(name20(sh.Name))
+		shdr.Write(le(sh.Start, sh.End, sh.StartLoop, sh.EndLoop, sh.SampleRate, sh.OriginalPitch,
+			sh.PitchCorrection, sh.SampleLink, sh.SampleType))
+	}
+	shdr.Write(name20("EOS"))
+	shdr.Write(make([]byte, 26))
+
+	ibag, igen := zoneBytes(tf.instZones)
+	pbag, pgen := zoneBytes(tf.presetZones)
+
+	inst := bytes.Join([][]byte{name20("inst"), le(uint16(0)), name20("EOI"), le(uint16(len(tf.instZones)))}, nil)
+	phdr := bytes.Join([][]byte{
+		name20("preset"), le(uint16(3), uint16(1), uint16(0), uint32(0), uint32(0), uint32(0)),
+		name20("EOP"), le(uint16(0), uint16(0), uint16(len(tf.presetZones)), uint32(0), uint32(0), uint32(0)),
+	}, nil)
+
+	sdta := [][]byte{chunkBytes("smpl", le(tf.data))}
+	if tf.data24 != nil {
+		sdta = append(sdta, chunkBytes("sm24", tf.data24))
+	}
+
+	return chunkBytes("RIFF", append([]byte("sfbk"), bytes.Join([][]byte{
+		listBytes("INFO", chunkBytes("INAM", []byte("test font\x00"))),
+		listBytes("sdta", sdta...),
+		listBytes("pdta", chunkBytes("phdr", phdr), chunkBytes("pbag", pbag), chunkBytes("pmod", nil),
+			chunkBytes("pgen", pgen), chunkBytes("inst", inst), chunkBytes("ibag", ibag), chunkBytes("imod", nil),
+			chunkBytes("igen", igen), chunkBytes("shdr", shdr.Bytes())),
+	}, nil)...))
+}
+
+func newTestFont(instZone []Generator, presetZone []Generator) testFont {
+	data := make([]int16, 146)
+	for i := 0; i < 100; i++ {
+		data[i] = int16(i * 100)
+	}
+
+	return testFont{
+		samples: []SampleHeader{{
+			Name: "s1", Start: 0, End: 100, StartLoop: 10, EndLoop: 90,
+			SampleRate: 22050, OriginalPitch: 62, PitchCorrection: -5, SampleType: MonoSample,
+		}},
+		data:        data,
+		instZones:   [][]Generator{append(instZone, Generator{GenSampleID, 0})},
+		presetZones: [][]Generator{append(presetZone, Generator{GenInstrument, 0})},
+	}
+}
+
+func TestParse(t *testing.T) {
+	tf := newTestFont([]Generator{{GenKeyRange, keyRange(40, 80)}}, nil)
+	tf.instZones = append([][]Generator{{{GenSampleModes, 1}}}, tf.instZones...)
+	tf.presetZones = append([][]Generator{{{GenCoarseTune, 1}}}, tf.presetZones...)
+
+	f, err := Parse(tf.bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f.Name != "test font" || len(f.Samples) != 1 || f.Samples[0] != tf.samples[0] {
+		t.Fatalf("file %+v", f)
+	}
+
+	if len(f.Instruments) != 1 || f.Instruments[0].Name != "inst" || f.Instruments[0].Global == nil || len(f.Instruments[0].Zones) != 1 {
+		t.Fatalf("instruments %+v", f.Instruments)
+	}
+
+	p := f.Presets
+	if len(p) != 1 || p[0].Name != "preset" || p[0].Preset != 3 || p[0].Bank != 1 || p[0].Global == nil || len(p[0].Zones) != 1 {
+		t.Fatalf("presets %+v", p)
+	}
+
+	if g, ok := f.Instruments[0].Zones[0].Generator(GenKeyRange); !ok || g.Amount != keyRange(40, 80) {
+		t.Fatalf("key range generator %v", g)
+	}
+}
+
+func TestRegions(t *testing.T) {
+	tests := []struct {
+		name   string
+		inst   []Generator
+		preset []Generator
+		check  func(t *testing.T, r regionResult)
+	}{
+		{"defaults", nil, nil, func(t *testing.T, r regionResult) {
+			r.expect(t, r.Zone.LowKey == 0 && r.Zone.HighKey == 127 && r.Zone.RootKey == 62 && r.Zone.Tune == -5)
+			r.expect(t, r.Loop == player.LoopOff && r.Zone.Gain == 1 && r.Zone.Sample == "s1")
+		}},
+		{"key ranges intersect", []Generator{{GenKeyRange, keyRange(40, 80)}}, []Generator{{GenKeyRange, keyRange(60, 100)}}, func(t *testing.T, r regionResult) {
+			r.expect(t, r.Zone.LowKey == 60 && r.Zone.HighKey == 80)
+		}},
+		{"velocity range", []Generator{{GenVelRange, keyRange(0, 64)}}, nil, func(t *testing.T, r regionResult) {
+			r.expect(t, r.Zone.LowVelocity == 1 && r.Zone.HighVelocity == 64)
+		}},
+		{"tuning adds up", []Generator{{GenFineTune, 10}, {GenCoarseTune, signed(-1)}}, []Generator{{GenCoarseTune, 2}, {GenFineTune, signed(-3)}}, func(t *testing.T, r regionResult) {
+			r.expect(t, r.Zone.Tune == 100+10-3-5)
+		}},
+		{"root key override", []Generator{{GenOverridingRootKey, 48}}, nil, func(t *testing.T, r regionResult) {
+			r.expect(t, r.Zone.RootKey == 48)
+		}},
+		{"attenuation", []Generator{{GenInitialAttenuation, 60}}, []Generator{{GenInitialAttenuation, 60}}, func(t *testing.T, r regionResult) {
+			r.expect(t, math.Abs(r.Zone.Gain-math.Pow(10, -0.6)) < 1e-12)
+		}},
+		{"loop", []Generator{{GenSampleModes, 1}, {GenStartLoopAddrsOffset, 2}, {GenEndLoopAddrsOffset, signed(-4)}}, nil, func(t *testing.T, r regionResult) {
+			r.expect(t, r.Loop == player.LoopForward && r.LoopStart == 12 && r.LoopEnd == 86)
+		}},
+		{"sustain loop", []Generator{{GenSampleModes, 3}}, nil, func(t *testing.T, r regionResult) {
+			r.expect(t, r.Loop == player.LoopSustain && r.LoopStart == 10 && r.LoopEnd == 90)
+		}},
+		{"invalid loop", []Generator{{GenSampleModes, 1}, {GenStartLoopAddrsOffset, 90}}, nil, func(t *testing.T, r regionResult) {
+			r.expect(t, r.Loop == player.LoopOff && r.LoopEnd == 0)
+		}},
+		{"offset", []Generator{{GenStartAddrsOffset, 5}}, nil, func(t *testing.T, r regionResult) {
+			r.expect(t, r.Offset == 5)
+		}},
+		{"envelope", []Generator{{GenAttackVolEnv, 0}, {GenSustainVolEnv, 200}, {GenReleaseVolEnv, 1200}}, []Generator{{GenReleaseVolEnv, 1200}}, func(t *testing.T, r regionResult) {
+			env := r.Envelope
+			r.expect(t, env.Attack == 1 && math.Abs(env.Sustain-0.1) < 1e-12 && env.Release == 4 && env.Decay == math.Pow(2, -10))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(newTestFont(tt.inst, tt.preset).bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			regions, err := f.Regions(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(regions) != 1 {
+				t.Fatalf("%d regions", len(regions))
+			}
+
+			tt.check(t, regionResult{regions[0]})
+		})
+	}
+}
+
+type regionResult struct {
+	sfz.Region
+}
+
+func (r regionResult) expect(t *testing.T, ok bool) {
+	t.Helper()
+
+	if !ok {
+		t.Fatalf("unexpected region %+v", r.Region)
+	}
+}
+
+func TestRegionsSkipped(t *testing.T) {
+	tests := []struct {
+		name   string
+		inst   []Generator
+		preset []Generator
+	}{
+		{"disjoint keys", []Generator{{GenKeyRange, keyRange(0, 40)}}, []Generator{{GenKeyRange, keyRange(60, 100)}}},
+		{"disjoint velocities", []Generator{{GenVelRange, keyRange(1, 40)}}, []Generator{{GenVelRange, keyRange(60, 127)}}},
+	}
+
+	for _, tt := range tests {
+		f, err := Parse(newTestFont(tt.inst, tt.preset).bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if regions, err := f.Regions(0); err != nil || len(regions) != 0 {
+			t.Errorf("%s: %d regions, error %v", tt.name, len(regions), err)
+		}
+	}
+
+	f, _ := Parse(newTestFont(nil, nil).bytes())
+	if _, err := f.Regions(1); err == nil {
+		t.Error("expected error for preset out of range")
+	}
+}
+
+func TestSampleData(t *testing.T) {
+	tf := newTestFont(nil, nil)
+
+	f, err := Parse(tf.bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bank := SoundBank[float64](f)
+	if got := bank["s1"].Buffer(0, 0)[1]; got != 100.0/32768.0 {
+		t.Fatalf("16 bit sample %v", got)
+	}
+
+	if bank["s1"].NumFrames() != 100 || bank["s1"].SampleRate() != 22050 {
+		t.Fatalf("%d frames at %v Hz", bank["s1"].NumFrames(), bank["s1"].SampleRate())
+	}
+
+	tf.data24 = make([]byte, len(tf.data))
+	for i := range tf.data24 {
+		tf.data24[i] = 0x80
+	}
+
+	f, err = Parse(tf.bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := SampleData[float64](f, f.Samples[0])[1]; got != float64(100<<8|0x80)/8388608.0 {
+		t.Fatalf("24 bit sample %v", got)
+	}
+
+	// A short sm24 chunk is ignored
+	tf.data24 = tf.data24[:10]
+
+	f, err = Parse(tf.bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := SampleData[float64](f, f.Samples[0])[1]; got != 100.0/32768.0 {
+		t.Fatalf("sample with short sm24 %v", got)
+	}
+}
+
+func TestSampleNames(t *testing.T) {
+	tf := newTestFont(nil, nil)
+	tf.samples = append(tf.samples, tf.samples[0], SampleHeader{Name: "rom", End: 10, SampleType: ROMSample})
+
+	f, err := Parse(tf.bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := f.SampleNames()
+	if len(names) != 3 || names[0] != "s1" || names[1] != "s1#1" || names[2] != "rom" {
+		t.Fatalf("names %v", names)
+	}
+
+	bank := SoundBank[float64](f)
+	if _, ok := bank["rom"]; ok || len(bank) != 2 {
+		t.Fatalf("bank has %d samples", len(bank))
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	valid := newTestFont(nil, nil)
+
+	outOfRange := newTestFont(nil, nil)
+	outOfRange.samples[0].End = 1000
+
+	badSample := newTestFont(nil, nil)
+	badSample.instZones[0][0].Amount = 5
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not riff", chunkBytes("RIFX", []byte("sfbk"))},
+		{"not a soundfont", chunkBytes("RIFF", []byte("WAVE"))},
+		{"truncated", valid.bytes()[:200]},
+		{"no sample data", chunkBytes("RIFF", append([]byte("sfbk"), listBytes("INFO")...))},
+		{"missing hydra", chunkBytes("RIFF", append([]byte("sfbk"), listBytes("sdta", chunkBytes("smpl", le(int16(0))))...))},
+		{"sample out of range", outOfRange.bytes()},
+	}
+
+	for _, tt := range tests {
+		if _, err := Parse(tt.data); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+
+	f, err := Parse(badSample.bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.Regions(0); err == nil {
+		t.Error("expected error for sample index out of range")
+	}
+}
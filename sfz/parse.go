@@ -0,0 +1,373 @@
+package sfz
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/almerlucke/sndfile/player"
+)
+
+type opcode struct {
+	name  string
+	value string
+}
+
+// stripComments removes line and block comments
+func stripComments(s string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if strings.HasPrefix(s[i:], "//") {
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				break
+			}
+			i += end
+		} else if strings.HasPrefix(s[i:], "/*") {
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				break
+			}
+			i += end + 3
+			b.WriteByte(' ')
+			continue
+		}
+
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+func isOpcodeChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// valueEnd returns the end of an opcode value, values like sample paths may contain spaces so a
+// value ends at a newline, a header or the next opcode
+func valueEnd(s string) int {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\n' || c == '<' {
+			return i
+		}
+
+		if isSpace(c) {
+			j := i + 1
+			for j < len(s) && isSpace(s[j]) {
+				j++
+			}
+
+			k := j
+			for k < len(s) && isOpcodeChar(s[k]) {
+				k++
+			}
+
+			if k > j && k < len(s) && s[k] == '=' {
+				return i
+			}
+		}
+	}
+
+	return len(s)
+}
+
+// regionState holds the values that need to be combined after all opcodes are applied
+type regionState struct {
+	region    Region
+	transpose int
+	volume    float64
+	amplitude float64
+	seqLength int
+}
+
+func (st *regionState) apply(op opcode) error {
+	r := &st.region
+	z := &r.Zone
+	v := op.value
+
+	var err error
+
+	integer := func(dst *int) {
+		*dst, err = strconv.Atoi(v)
+	}
+
+	integer64 := func(dst *int64) {
+		*dst, err = strconv.ParseInt(v, 10, 64)
+	}
+
+	number := func(dst *float64) {
+		*dst, err = strconv.ParseFloat(v, 64)
+	}
+
+	key := func(dst *int) {
+		*dst, err = parseKey(v)
+	}
+
+	switch op.name {
+	case "sample":
+		z.Sample = strings.ReplaceAll(v, "\\", "/")
+	case "lokey":
+		key(&z.LowKey)
+	case "hikey":
+		key(&z.HighKey)
+	case "key":
+		key(&z.LowKey)
+		z.HighKey = z.LowKey
+		z.RootKey = z.LowKey
+	case "pitch_keycenter":
+		key(&z.RootKey)
+	case "lovel":
+		integer(&z.LowVelocity)
+	case "hivel":
+		integer(&z.HighVelocity)
+	case "tune":
+		number(&z.Tune)
+	case "transpose":
+		integer(&st.transpose)
+	case "volume":
+		number(&st.volume)
+	case "amplitude":
+		number(&st.amplitude)
+	case "offset":
+		integer64(&r.Offset)
+	case "loop_mode", "loopmode":
+		switch v {
+		case "no_loop", "one_shot":
+			r.Loop = player.LoopOff
+		case "loop_continuous":
+			r.Loop = player.LoopForward
+		case "loop_sustain":
+			r.Loop = player.LoopSustain
+		default:
+			err = fmt.Errorf("unknown loop mode %q", v)
+		}
+	case "loop_start", "loopstart":
+		integer64(&r.LoopStart)
+	case "loop_end", "loopend":
+		integer64(&r.LoopEnd)
+		// SFZ loop end is the last frame of the loop
+		r.LoopEnd++
+	case "ampeg_attack":
+		number(&r.Envelope.Attack)
+	case "ampeg_decay":
+		number(&r.Envelope.Decay)
+	case "ampeg_sustain":
+		number(&r.Envelope.Sustain)
+		r.Envelope.Sustain /= 100.0
+	case "ampeg_release":
+		number(&r.Envelope.Release)
+	case "xfin_lovel":
+		integer(&z.FadeInLow)
+	case "xfin_hivel":
+		integer(&z.FadeInHigh)
+	case "xfout_lovel":
+		integer(&z.FadeOutLow)
+	case "xfout_hivel":
+		integer(&z.FadeOutHigh)
+	case "seq_length":
+		integer(&st.seqLength)
+	case "seq_position":
+		integer(&r.SeqPosition)
+	}
+
+	if err != nil {
+		return fmt.Errorf("opcode %s: %w", op.name, err)
+	}
+
+	return nil
+}
+
+// Parse parses the regions of an SFZ instrument. Opcodes of global, master and group headers are
+// inherited by their regions, sample paths are relative to the SFZ file with the control
+// default_path prepended. Unsupported opcodes are ignored.
+func Parse(r io.Reader) ([]Region, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	s := stripComments(string(data))
+
+	var (
+		regions  []Region
+		control  []opcode
+		global   []opcode
+		master   []opcode
+		group    []opcode
+		current  *[]opcode
+		region   []opcode
+		inRegion bool
+		// Every header gets a scope number, round robin groups are keyed by the scope that sets
+		// seq_length so regions under separate groups with a shared seq_length take turns
+		scopeNumber = 1
+		globalScope = 1
+		masterScope = 1
+		groupScope  = 1
+	)
+
+	finishRegion := func() error {
+		if !inRegion {
+			return nil
+		}
+
+		inRegion = false
+
+		st := regionState{
+			region:    DefaultRegion(),
+			amplitude: 100,
+		}
+
+		defaultPath := ""
+
+		for _, op := range control {
+			if op.name == "default_path" {
+				defaultPath = strings.ReplaceAll(op.value, "\\", "/")
+			}
+		}
+
+		// seq_length on a region applies to the regions of its enclosing header
+		scopes := []struct {
+			ops    []opcode
+			number int
+		}{
+			{global, globalScope},
+			{master, masterScope},
+			{group, groupScope},
+			{region, groupScope},
+		}
+
+		seqScope := 0
+
+		for _, scope := range scopes {
+			for _, op := range scope.ops {
+				if err := st.apply(op); err != nil {
+					return err
+				}
+
+				if op.name == "seq_length" {
+					seqScope = scope.number
+				}
+			}
+		}
+
+		rg := st.region
+		if rg.Zone.Sample == "" {
+			return errors.New("region without sample")
+		}
+
+		rg.Zone.Sample = path.Clean(defaultPath + rg.Zone.Sample)
+		rg.Zone.Tune += float64(st.transpose) * 100.0
+		rg.Zone.Gain = math.Pow(10, st.volume/20.0) * st.amplitude / 100.0
+
+		if st.seqLength > 1 {
+			rg.Zone.Group = seqScope
+		}
+
+		regions = append(regions, rg)
+
+		return nil
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case isSpace(c):
+			i++
+		case c == '#':
+			// Preprocessor directives are not supported
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				end = len(s) - i
+			}
+			i += end
+		case c == '<':
+			end := strings.IndexByte(s[i:], '>')
+			if end < 0 {
+				return nil, errors.New("unterminated header")
+			}
+
+			if err := finishRegion(); err != nil {
+				return nil, err
+			}
+
+			header := s[i+1 : i+end]
+			i += end + 1
+
+			switch header {
+			case "control":
+				control = nil
+				current = &control
+			case "global":
+				global, master, group = nil, nil, nil
+				current = &global
+				scopeNumber++
+				globalScope, masterScope, groupScope = scopeNumber, scopeNumber, scopeNumber
+			case "master":
+				master, group = nil, nil
+				current = &master
+				scopeNumber++
+				masterScope, groupScope = scopeNumber, scopeNumber
+			case "group":
+				group = nil
+				current = &group
+				scopeNumber++
+				groupScope = scopeNumber
+			case "region":
+				region = nil
+				current = &region
+				inRegion = true
+			default:
+				// Opcodes of unsupported headers are ignored
+				current = nil
+			}
+		default:
+			eq := strings.IndexByte(s[i:], '=')
+			if eq < 0 {
+				return nil, fmt.Errorf("invalid opcode %q", strings.TrimSpace(s[i:]))
+			}
+
+			name := strings.TrimSpace(s[i : i+eq])
+			i += eq + 1
+
+			end := valueEnd(s[i:])
+			value := strings.TrimSpace(s[i : i+end])
+			i += end
+
+			if current == nil {
+				continue
+			}
+
+			*current = append(*current, opcode{name: name, value: value})
+		}
+	}
+
+	if err := finishRegion(); err != nil {
+		return nil, err
+	}
+
+	// Number round robin groups in order of appearance
+	numbers := map[int]int{}
+	for i := range regions {
+		if g := regions[i].Zone.Group; g != 0 {
+			if _, ok := numbers[g]; !ok {
+				numbers[g] = len(numbers) + 1
+			}
+			regions[i].Zone.Group = numbers[g]
+		}
+	}
+
+	sortRoundRobin(regions)
+
+	return regions, nil
+}
@@ -0,0 +1,225 @@
+// Package sfz imports and exports SFZ sampler instruments
+package sfz
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/almerlucke/sndfile"
+	"github.com/almerlucke/sndfile/float"
+	"github.com/almerlucke/sndfile/player"
+)
+
+// Region is an SFZ region, the keymap zone plus the playback settings of the region
+type Region struct {
+	Zone sndfile.Zone
+	// Offset is the start offset in frames
+	Offset int64
+	Loop   player.LoopMode
+	// LoopStart and LoopEnd in frames, LoopEnd is exclusive and 0 means the end of the sample
+	LoopStart int64
+	LoopEnd   int64
+	Envelope  player.ADSR
+	// SeqPosition is the round robin position within the group of the region, starting at 1
+	SeqPosition int
+}
+
+// DefaultRegion returns a region with the SFZ defaults
+func DefaultRegion() Region {
+	return Region{
+		Zone: sndfile.Zone{
+			HighKey:      127,
+			LowVelocity:  1,
+			HighVelocity: 127,
+			RootKey:      60,
+			Gain:         1,
+		},
+		Envelope: player.ADSR{
+			Sustain: 1,
+			Release: 0.001,
+		},
+		SeqPosition: 1,
+	}
+}
+
+// Instrument is a loaded SFZ instrument
+type Instrument[T float.Float] struct {
+	Bank    sndfile.SoundBank[T]
+	Keymap  *sndfile.Keymap[T]
+	Regions []Region
+}
+
+// Options for loading an SFZ instrument
+type Options struct {
+	// MipMapDepth loads samples as MipMapSoundFile when larger than 0
+	MipMapDepth int
+}
+
+// Config returns the voice config to play match, which must be resolved from the instrument
+// keymap
+func (inst *Instrument[T]) Config(match sndfile.Match[T]) player.Config[T] {
+	cfg := player.DefaultConfig[T]()
+
+	cfg.Pitch = match.Pitch
+	cfg.Gain = match.Gain
+
+	for i, zone := range inst.Keymap.Zones() {
+		if zone == match.Zone {
+			r := &inst.Regions[i]
+			cfg.Start = r.Offset
+			cfg.Loop = r.Loop
+			cfg.LoopStart = r.LoopStart
+			cfg.LoopEnd = r.LoopEnd
+			cfg.Envelope = r.Envelope
+			break
+		}
+	}
+
+	return cfg
+}
+
+// Load parses an SFZ file, loads its samples relative to the SFZ file into a SoundBank and
+// builds the keymap
+func Load[T float.Float](filePath string, opt Options) (*Instrument[T], error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	regions, err := Parse(f)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(filePath)
+
+	bank := sndfile.SoundBank[T]{}
+
+	for _, r := range regions {
+		name := r.Zone.Sample
+		if _, ok := bank[name]; ok {
+			continue
+		}
+
+		samplePath := filepath.Join(dir, filepath.FromSlash(name))
+
+		var sf sndfile.SoundFiler[T]
+		if opt.MipMapDepth > 0 {
+			sf, err = sndfile.NewMipMapSoundFile[T](samplePath, opt.MipMapDepth)
+		} else {
+			sf, err = sndfile.NewSoundFile[T](samplePath)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("sample %s: %w", name, err)
+		}
+
+		bank[name] = sf
+	}
+
+	return NewInstrument(bank, regions)
+}
+
+// NewInstrument builds an instrument from regions over a SoundBank that holds their samples
+func NewInstrument[T float.Float](bank sndfile.SoundBank[T], regions []Region) (*Instrument[T], error) {
+	inst := &Instrument[T]{
+		Bank:    bank,
+		Keymap:  sndfile.NewKeymap(bank),
+		Regions: regions,
+	}
+
+	for _, r := range regions {
+		if err := inst.Keymap.AddZone(r.Zone); err != nil {
+			return nil, fmt.Errorf("sample %s: %w", r.Zone.Sample, err)
+		}
+	}
+
+	return inst, nil
+}
+
+// Must loads an SFZ instrument and panics on error
+func Must[T float.Float](filePath string, opt Options) *Instrument[T] {
+	inst, err := Load[T](filePath, opt)
+	if err != nil {
+		panic(err)
+	}
+
+	return inst
+}
+
+// parseKey parses a MIDI note number or a note name like c4, c#4 or db4, c4 is key 60
+func parseKey(value string) (int, error) {
+	if n, err := strconv.Atoi(value); err == nil {
+		return n, nil
+	}
+
+	s := strings.ToLower(value)
+	if s == "" {
+		return 0, errors.New("empty key")
+	}
+
+	semis := map[byte]int{'c': 0, 'd': 2, 'e': 4, 'f': 5, 'g': 7, 'a': 9, 'b': 11}
+
+	key, ok := semis[s[0]]
+	if !ok {
+		return 0, fmt.Errorf("invalid key %q", value)
+	}
+
+	s = s[1:]
+	if strings.HasPrefix(s, "#") {
+		key++
+		s = s[1:]
+	} else if strings.HasPrefix(s, "b") && len(s) > 1 {
+		key--
+		s = s[1:]
+	}
+
+	octave, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid key %q", value)
+	}
+
+	return (octave+1)*12 + key, nil
+}
+
+// KeyName returns the SFZ note name of key
+func KeyName(key int) string {
+	names := []string{"c", "c#", "d", "d#", "e", "f", "f#", "g", "g#", "a", "a#", "b"}
+
+	return fmt.Sprintf("%s%d", names[((key%12)+12)%12], key/12-1)
+}
+
+// gainToDB converts a linear gain to a volume in dB
+func gainToDB(gain float64) float64 {
+	return 20.0 * math.Log10(gain)
+}
+
+// sortRoundRobin orders regions by sequence position so the keymap round robin follows the
+// SFZ sequence
+func sortRoundRobin(regions []Region) {
+	var indices []int
+	var grouped []Region
+
+	for i, r := range regions {
+		if r.Zone.Group != 0 {
+			indices = append(indices, i)
+			grouped = append(grouped, r)
+		}
+	}
+
+	slices.SortStableFunc(grouped, func(a, b Region) int {
+		return a.SeqPosition - b.SeqPosition
+	})
+
+	for i, index := range indices {
+		regions[index] = grouped[i]
+	}
+}
@@ -0,0 +1,347 @@
+package sfz
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/almerlucke/sndfile"
+	"github.com/almerlucke/sndfile/player"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		value string
+		key   int
+		ok    bool
+	}{
+		{"60", 60, true},
+		{"c4", 60, true},
+		{"C4", 60, true},
+		{"c#4", 61, true},
+		{"db4", 61, true},
+		{"a-1", 9, true},
+		{"b9", 131, true},
+		{"h4", 0, false},
+		{"c", 0, false},
+	}
+
+	for _, tt := range tests {
+		key, err := parseKey(tt.value)
+		if (err == nil) != tt.ok || (tt.ok && key != tt.key) {
+			t.Errorf("%q: key %d, error %v", tt.value, key, err)
+		}
+	}
+
+	for key := 0; key < 128; key++ {
+		if got, err := parseKey(KeyName(key)); err != nil || got != key {
+			t.Fatalf("key %d: name %s parses to %d", key, KeyName(key), got)
+		}
+	}
+}
+
+func TestParseRegion(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		check func(r Region) bool
+	}{
+		{"defaults", `<region> sample=a.wav`, func(r Region) bool {
+			want := DefaultRegion()
+			want.Zone.Sample = "a.wav"
+			return r == want
+		}},
+		{"key", `<region> sample=a.wav key=c#4`, func(r Region) bool {
+			return r.Zone.LowKey == 61 && r.Zone.HighKey == 61 && r.Zone.RootKey == 61
+		}},
+		{"key range", `<region> sample=a.wav lokey=48 hikey=c5 pitch_keycenter=50`, func(r Region) bool {
+			return r.Zone.LowKey == 48 && r.Zone.HighKey == 72 && r.Zone.RootKey == 50
+		}},
+		{"velocity", `<region> sample=a.wav lovel=10 hivel=20 xfin_lovel=10 xfin_hivel=15`, func(r Region) bool {
+			z := r.Zone
+			return z.LowVelocity == 10 && z.HighVelocity == 20 && z.FadeInLow == 10 && z.FadeInHigh == 15
+		}},
+		{"tune and transpose", `<region> sample=a.wav tune=-10 transpose=2`, func(r Region) bool {
+			return r.Zone.Tune == 190
+		}},
+		{"volume and amplitude", `<region> sample=a.wav volume=-6 amplitude=50`, func(r Region) bool {
+			return math.Abs(r.Zone.Gain-math.Pow(10, -6.0/20)*0.5) < 1e-12
+		}},
+		{"loop", `<region> sample=a.wav loop_mode=loop_sustain loop_start=10 loop_end=99 offset=5`, func(r Region) bool {
+			return r.Loop == player.LoopSustain && r.LoopStart == 10 && r.LoopEnd == 100 && r.Offset == 5
+		}},
+		{"envelope", `<region> sample=a.wav ampeg_attack=0.1 ampeg_decay=0.2 ampeg_sustain=50 ampeg_release=0.3`, func(r Region) bool {
+			return r.Envelope == player.ADSR{Attack: 0.1, Decay: 0.2, Sustain: 0.5, Release: 0.3}
+		}},
+		{"default path", "<control> default_path=samples\\piano\\\n<region> sample=C4 soft.wav", func(r Region) bool {
+			return r.Zone.Sample == "samples/piano/C4 soft.wav"
+		}},
+		{"comments", "// line\n<region> /* block */ sample=a.wav // trailing\nkey=60", func(r Region) bool {
+			return r.Zone.Sample == "a.wav" && r.Zone.LowKey == 60
+		}},
+		{"inheritance", "<global> ampeg_release=1 volume=-6\n<group> ampeg_release=2\n<region> sample=a.wav volume=0", func(r Region) bool {
+			return r.Envelope.Release == 2 && r.Zone.Gain == 1
+		}},
+		{"unsupported", "<curve> v000=0\n<region> sample=a.wav cutoff=100", func(r Region) bool {
+			return r.Zone.Sample == "a.wav"
+		}},
+	}
+
+	for _, tt := range tests {
+		regions, err := Parse(strings.NewReader(tt.src))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		if len(regions) != 1 || !tt.check(regions[0]) {
+			t.Errorf("%s: %+v", tt.name, regions)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"no sample", `<region> key=60`},
+		{"unterminated header", `<region sample=a.wav`},
+		{"invalid opcode", `<region> key`},
+		{"invalid key", `<region> sample=a.wav key=x4`},
+		{"invalid number", `<region> sample=a.wav volume=loud`},
+		{"unknown loop mode", `<region> sample=a.wav loop_mode=backwards`},
+	}
+
+	for _, tt := range tests {
+		if _, err := Parse(strings.NewReader(tt.src)); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func groupsBySample(regions []Region) map[string]int {
+	groups := map[string]int{}
+	for _, r := range regions {
+		groups[r.Zone.Sample] = r.Zone.Group
+	}
+
+	return groups
+}
+
+func TestParseRoundRobin(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		// samples in round robin order and the samples that share a group
+		order  []string
+		groups [][]string
+	}{
+		{
+			"group seq_length",
+			`<group> seq_length=2
+			<region> sample=b.wav seq_position=2
+			<region> sample=a.wav seq_position=1
+			<group>
+			<region> sample=c.wav`,
+			[]string{"a.wav", "b.wav", "c.wav"},
+			[][]string{{"a.wav", "b.wav"}, {"c.wav"}},
+		},
+		{
+			"global seq_length with a group per position",
+			`<global> seq_length=3
+			<group> seq_position=1
+			<region> sample=a1.wav key=60
+			<region> sample=a2.wav key=61
+			<group> seq_position=2
+			<region> sample=b1.wav key=60
+			<region> sample=b2.wav key=61
+			<group> seq_position=3
+			<region> sample=c1.wav key=60
+			<region> sample=c2.wav key=61`,
+			[]string{"a1.wav", "a2.wav", "b1.wav", "b2.wav", "c1.wav", "c2.wav"},
+			[][]string{{"a1.wav", "a2.wav", "b1.wav", "b2.wav", "c1.wav", "c2.wav"}},
+		},
+		{
+			"master seq_length per articulation",
+			`<master> seq_length=2
+			<group> seq_position=1
+			<region> sample=x1.wav
+			<group> seq_position=2
+			<region> sample=x2.wav
+			<master> seq_length=2
+			<group> seq_position=1
+			<region> sample=y1.wav
+			<group> seq_position=2
+			<region> sample=y2.wav`,
+			[]string{"x1.wav", "y1.wav", "x2.wav", "y2.wav"},
+			[][]string{{"x1.wav", "x2.wav"}, {"y1.wav", "y2.wav"}},
+		},
+		{
+			"region seq_length",
+			`<group>
+			<region> sample=p2.wav seq_length=2 seq_position=2
+			<region> sample=p1.wav seq_length=2 seq_position=1`,
+			[]string{"p1.wav", "p2.wav"},
+			[][]string{{"p1.wav", "p2.wav"}},
+		},
+	}
+
+	for _, tt := range tests {
+		regions, err := Parse(strings.NewReader(tt.src))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		for i, want := range tt.order {
+			if regions[i].Zone.Sample != want {
+				t.Fatalf("%s: region %d is %s, want %s", tt.name, i, regions[i].Zone.Sample, want)
+			}
+		}
+
+		groups := groupsBySample(regions)
+		seen := map[int]bool{}
+
+		for _, members := range tt.groups {
+			g := groups[members[0]]
+			if len(members) > 1 && (g == 0 || seen[g]) {
+				t.Fatalf("%s: %s has group %d", tt.name, members[0], g)
+			}
+			seen[g] = true
+
+			for _, m := range members[1:] {
+				if groups[m] != g {
+					t.Fatalf("%s: %s has group %d, want %d", tt.name, m, groups[m], g)
+				}
+			}
+		}
+	}
+}
+
+func TestRoundRobinInstrument(t *testing.T) {
+	regions, err := Parse(strings.NewReader(`<global> seq_length=3
+		<group> seq_position=1
+		<region> sample=a.wav key=60
+		<group> seq_position=2
+		<region> sample=b.wav key=60
+		<group> seq_position=3
+		<region> sample=c.wav key=60`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bank := sndfile.SoundBank[float64]{}
+	for _, r := range regions {
+		bank[r.Zone.Sample] = sndfile.NewSoundFileFromBuffers([][]float64{make([]float64, 10)}, 44100)
+	}
+
+	inst, err := NewInstrument(bank, regions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only one layer plays per note and the layers take turns
+	for i, want := range []string{"a.wav", "b.wav", "c.wav", "a.wav"} {
+		matches := inst.Keymap.Resolve(nil, 60, 100)
+		if len(matches) != 1 || matches[0].Zone.Sample != want {
+			t.Fatalf("note %d: %d matches", i, len(matches))
+		}
+	}
+
+	if _, err := NewInstrument(sndfile.SoundBank[float64]{}, regions); err == nil {
+		t.Fatal("expected error for missing samples")
+	}
+}
+
+func TestInstrumentConfig(t *testing.T) {
+	regions, err := Parse(strings.NewReader(`<region> sample=a.wav key=60 offset=3 loop_mode=loop_continuous loop_start=1 loop_end=8 ampeg_release=0.5 volume=-6`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bank := sndfile.SoundBank[float64]{"a.wav": sndfile.NewSoundFileFromBuffers([][]float64{make([]float64, 10)}, 44100)}
+
+	inst, err := NewInstrument(bank, regions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches := inst.Keymap.Resolve(nil, 60, 100)
+	if len(matches) != 1 {
+		t.Fatalf("%d matches", len(matches))
+	}
+
+	cfg := inst.Config(matches[0])
+	if cfg.Start != 3 || cfg.Loop != player.LoopForward || cfg.LoopStart != 1 || cfg.LoopEnd != 9 ||
+		cfg.Envelope.Release != 0.5 || cfg.Gain != matches[0].Gain || cfg.Pitch != 1 {
+		t.Fatalf("config %+v", cfg)
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	src := `<control> default_path=samples/
+	<global> ampeg_release=0.3
+	<group> seq_length=2 lovel=1 hivel=64
+	<region> sample=soft b.wav key=c4 seq_position=2 volume=-6
+	<region> sample=soft a.wav key=c4 seq_position=1 loop_mode=loop_continuous loop_start=10 loop_end=99
+	<group> xfin_lovel=60 xfin_hivel=70 xfout_lovel=100 xfout_hivel=110
+	<region> sample=hard.wav lokey=c#4 hikey=72 pitch_keycenter=61 tune=-10 transpose=1 ampeg_sustain=50 offset=20
+	<region> sample=sustain.wav loop_mode=loop_sustain loop_start=0 loop_end=9`
+
+	regions, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	if err := Write(&b, regions); err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := Parse(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(again) != len(regions) {
+		t.Fatalf("%d regions after writing, want %d", len(again), len(regions))
+	}
+
+	bySample := map[string]Region{}
+	for _, r := range regions {
+		bySample[r.Zone.Sample] = r
+	}
+
+	for _, got := range again {
+		want, ok := bySample[got.Zone.Sample]
+		if !ok {
+			t.Fatalf("unexpected region %s", got.Zone.Sample)
+		}
+
+		// Group numbers are renumbered on parse, only membership has to survive
+		if (got.Zone.Group == 0) != (want.Zone.Group == 0) {
+			t.Fatalf("%s: group %d, want %d", got.Zone.Sample, got.Zone.Group, want.Zone.Group)
+		}
+		got.Zone.Group, want.Zone.Group = 0, 0
+
+		if math.Abs(got.Zone.Gain-want.Zone.Gain) > 1e-12 {
+			t.Fatalf("%s: gain %f, want %f", got.Zone.Sample, got.Zone.Gain, want.Zone.Gain)
+		}
+		got.Zone.Gain = want.Zone.Gain
+
+		if got != want {
+			t.Fatalf("%s:\n got %+v\nwant %+v", got.Zone.Sample, got, want)
+		}
+	}
+
+	// Regions without round robin are written first, the group keeps its sequence order
+	var order []string
+	for _, r := range again {
+		if r.Zone.Group != 0 {
+			order = append(order, r.Zone.Sample)
+		}
+	}
+
+	if len(order) != 2 || order[0] != "samples/soft a.wav" || order[1] != "samples/soft b.wav" {
+		t.Fatalf("round robin order %v", order)
+	}
+}
@@ -0,0 +1,130 @@
+package sfz
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/almerlucke/sndfile"
+	"github.com/almerlucke/sndfile/float"
+	"github.com/almerlucke/sndfile/player"
+)
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// Write writes regions as an SFZ instrument, the sample names of the zones are written as sample
+// paths. Round robin groups are written as groups with seq_length and seq_position.
+func Write(w io.Writer, regions []Region) error {
+	bw := bufio.NewWriter(w)
+
+	// Regions without round robin are written first, each round robin group is written under its
+	// own group header
+	var groups []int
+	members := map[int][]Region{}
+
+	for _, r := range regions {
+		g := r.Zone.Group
+		if g == 0 {
+			writeRegion(bw, r)
+			continue
+		}
+
+		if _, ok := members[g]; !ok {
+			groups = append(groups, g)
+		}
+		members[g] = append(members[g], r)
+	}
+
+	for _, g := range groups {
+		fmt.Fprintf(bw, "<group> seq_length=%d\n\n", len(members[g]))
+
+		for i, r := range members[g] {
+			r.SeqPosition = i + 1
+			writeRegion(bw, r)
+		}
+	}
+
+	return bw.Flush()
+}
+
+func writeRegion(bw *bufio.Writer, r Region) {
+	z := r.Zone
+
+	fmt.Fprintf(bw, "<region> sample=%s\n", z.Sample)
+	fmt.Fprintf(bw, "lokey=%d hikey=%d pitch_keycenter=%d\n", z.LowKey, z.HighKey, z.RootKey)
+
+	if z.LowVelocity != 0 || z.HighVelocity != 0 {
+		fmt.Fprintf(bw, "lovel=%d hivel=%d\n", z.LowVelocity, z.HighVelocity)
+	}
+
+	if z.Tune != 0 {
+		fmt.Fprintf(bw, "tune=%s\n", formatFloat(z.Tune))
+	}
+
+	if z.Gain != 0 && z.Gain != 1 {
+		fmt.Fprintf(bw, "volume=%s\n", formatFloat(gainToDB(z.Gain)))
+	}
+
+	if z.FadeInHigh > z.FadeInLow {
+		fmt.Fprintf(bw, "xfin_lovel=%d xfin_hivel=%d\n", z.FadeInLow, z.FadeInHigh)
+	}
+
+	if z.FadeOutHigh > z.FadeOutLow {
+		fmt.Fprintf(bw, "xfout_lovel=%d xfout_hivel=%d\n", z.FadeOutLow, z.FadeOutHigh)
+	}
+
+	if z.Group != 0 {
+		fmt.Fprintf(bw, "seq_position=%d\n", r.SeqPosition)
+	}
+
+	if r.Offset != 0 {
+		fmt.Fprintf(bw, "offset=%d\n", r.Offset)
+	}
+
+	switch r.Loop {
+	case player.LoopForward, player.LoopPingPong:
+		// SFZ has no ping pong loop mode
+		bw.WriteString("loop_mode=loop_continuous\n")
+	case player.LoopSustain:
+		bw.WriteString("loop_mode=loop_sustain\n")
+	}
+
+	if r.Loop != player.LoopOff && r.LoopEnd > 0 {
+		fmt.Fprintf(bw, "loop_start=%d loop_end=%d\n", r.LoopStart, r.LoopEnd-1)
+	}
+
+	env := r.Envelope
+	fmt.Fprintf(bw, "ampeg_attack=%s ampeg_decay=%s ampeg_sustain=%s ampeg_release=%s\n\n",
+		formatFloat(env.Attack), formatFloat(env.Decay), formatFloat(env.Sustain*100.0), formatFloat(env.Release))
+}
+
+// Save writes regions as an SFZ file
+func Save(filePath string, regions []Region) error {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+
+	if err := Write(f, regions); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// RegionsFromKeymap returns default regions for the zones of a keymap so it can be written
+func RegionsFromKeymap[T float.Float](km *sndfile.Keymap[T]) []Region {
+	regions := make([]Region, len(km.Zones()))
+
+	for i, zone := range km.Zones() {
+		regions[i] = DefaultRegion()
+		regions[i].Zone = *zone
+	}
+
+	return regions
+}
@@ -79,21 +79,32 @@ func NewSoundFile[T float.Float](filePath string) (*SoundFile[T], error) {
 		frameIndex += framesRead
 	}
 
+	return NewSoundFileFromBuffers(channels, float64(info.Samplerate)), nil
+}
+
+// NewSoundFileFromBuffers create a sound file from deinterleaved channels of equal length,
+// the buffers are not copied
+func NewSoundFileFromBuffers[T float.Float](channels [][]T, sampleRate float64) *SoundFile[T] {
+	var numFrames int64
+	if len(channels) > 0 {
+		numFrames = int64(len(channels[0]))
+	}
+
 	// Find zero crossings
-	zeroCrossings := make([]ZeroCrossings, info.Channels)
+	zeroCrossings := make([]ZeroCrossings, len(channels))
 	for i := range zeroCrossings {
 		zeroCrossings[i] = calculateZeroCrossings(channels[i])
 	}
 
 	sf := SoundFile[T]{}
-	sf.duration = float64(info.Frames) / float64(info.Samplerate)
-	sf.numFrames = info.Frames
+	sf.duration = float64(numFrames) / sampleRate
+	sf.numFrames = numFrames
 	sf.channels = channels
 	sf.zeroCrossings = zeroCrossings
-	sf.sampleRate = float64(info.Samplerate)
-	sf.out = make([]T, info.Channels)
+	sf.sampleRate = sampleRate
+	sf.out = make([]T, len(channels))
 
-	return &sf, nil
+	return &sf
 }
 
 // MustSoundFile must load a sound file
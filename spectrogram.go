@@ -0,0 +1,86 @@
+package sndfile
+
+import (
+	"math"
+
+	"github.com/almerlucke/sndfile/dsp/fft"
+	"github.com/almerlucke/sndfile/dsp/windows"
+	"github.com/almerlucke/sndfile/float"
+)
+
+// MagnitudeScale selects linear or decibel spectrogram magnitudes
+type MagnitudeScale int
+
+const (
+	LinearMagnitude MagnitudeScale = iota
+	DecibelMagnitude
+)
+
+// SpectrogramFloorDB is the lowest value a decibel spectrogram can contain
+const SpectrogramFloorDB = -200.0
+
+// Spectrogram contains STFT magnitudes of a sound file channel, indexed as Frames[frame][bin]
+type Spectrogram struct {
+	Frames     [][]float64
+	FrameSize  int
+	HopSize    int
+	SampleRate float64
+	Scale      MagnitudeScale
+}
+
+// NewSpectrogram computes the spectrogram of a SoundFiler channel
+func NewSpectrogram[T float.Float](sf SoundFiler[T], channel int, frameSize int, hopSize int, window windows.Function, scale MagnitudeScale) (*Spectrogram, error) {
+	stft, err := fft.NewSTFT[T](frameSize, hopSize, window)
+	if err != nil {
+		return nil, err
+	}
+
+	spectrum := stft.Forward(sf.Buffer(channel, 0))
+
+	// Normalize so a full scale sine results in a magnitude of 1
+	var windowSum float64
+	for _, w := range stft.Window() {
+		windowSum += w
+	}
+
+	norm := 2.0 / windowSum
+
+	sg := &Spectrogram{
+		Frames:     make([][]float64, len(spectrum)),
+		FrameSize:  frameSize,
+		HopSize:    hopSize,
+		SampleRate: sf.SampleRate(),
+		Scale:      scale,
+	}
+
+	for f, bins := range spectrum {
+		mags := make([]float64, len(bins))
+
+		for b, c := range bins {
+			mag := math.Hypot(real(c), imag(c)) * norm
+			if scale == DecibelMagnitude {
+				mag = max(20.0*math.Log10(mag), SpectrogramFloorDB)
+			}
+			mags[b] = mag
+		}
+
+		sg.Frames[f] = mags
+	}
+
+	return sg, nil
+}
+
+// NumBins returns the number of frequency bins per frame
+func (sg *Spectrogram) NumBins() int {
+	return sg.FrameSize/2 + 1
+}
+
+// BinFrequency returns the center frequency of a bin in Hz
+func (sg *Spectrogram) BinFrequency(bin int) float64 {
+	return float64(bin) * sg.SampleRate / float64(sg.FrameSize)
+}
+
+// FrameTime returns the time in seconds at the center of a frame
+func (sg *Spectrogram) FrameTime(frame int) float64 {
+	return float64(frame*sg.HopSize) / sg.SampleRate
+}
@@ -0,0 +1,64 @@
+package sndfile
+
+import (
+	"math"
+	"testing"
+
+	"github.com/almerlucke/sndfile/dsp/windows"
+)
+
+func TestSpectrogramSinePeak(t *testing.T) {
+	sampleRate := 8000.0
+	frameSize := 512
+	freq := 1000.0
+
+	buf := make([]float64, 8000)
+	for i := range buf {
+		buf[i] = 0.5 * math.Sin(2*math.Pi*freq*float64(i)/sampleRate)
+	}
+
+	sf := NewSoundFileFromBuffers([][]float64{buf}, sampleRate)
+
+	tests := []struct {
+		name  string
+		scale MagnitudeScale
+		want  float64
+	}{
+		{"linear", LinearMagnitude, 0.5},
+		{"decibel", DecibelMagnitude, 20 * math.Log10(0.5)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sg, err := NewSpectrogram[float64](sf, 0, frameSize, 128, windows.Hann, tt.scale)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 1000 Hz falls exactly on bin 64
+			bin := 64
+			if f := sg.BinFrequency(bin); f != freq {
+				t.Fatalf("bin %d has frequency %f", bin, f)
+			}
+
+			frame := sg.Frames[len(sg.Frames)/2]
+			if len(frame) != sg.NumBins() {
+				t.Fatalf("%d bins, want %d", len(frame), sg.NumBins())
+			}
+
+			for b, mag := range frame {
+				if mag > frame[bin] {
+					t.Fatalf("bin %d is louder than the sine bin", b)
+				}
+			}
+
+			if math.Abs(frame[bin]-tt.want) > 1e-6 {
+				t.Fatalf("sine magnitude %f, want %f", frame[bin], tt.want)
+			}
+
+			if got := sg.FrameTime(2); got != 256/sampleRate {
+				t.Fatalf("frame time %f", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+// Package stretch implements offline time-stretching and pitch-shifting of sound files
+package stretch
+
+import (
+	"errors"
+	"math"
+
+	"github.com/almerlucke/sndfile"
+	"github.com/almerlucke/sndfile/dsp/fft"
+	"github.com/almerlucke/sndfile/dsp/resample"
+	"github.com/almerlucke/sndfile/float"
+)
+
+// Mode selects the stretch algorithm
+type Mode int
+
+const (
+	// WSOLA (waveform similarity overlap-add) keeps transients intact, best for drums and speech
+	WSOLA Mode = iota
+	// PhaseVocoder with identity phase locking gives smooth results for tonal material
+	PhaseVocoder
+)
+
+// Options for stretching, the zero value selects WSOLA with defaults based on the sample rate
+type Options struct {
+	Mode Mode
+	// FrameSize in samples, zero chooses a size based on the sample rate and mode
+	FrameSize int
+	// Tolerance is the WSOLA search range in samples around the nominal frame position,
+	// zero means a quarter of the frame size
+	Tolerance int
+	// Quality of the resampling step of PitchShift
+	Quality resample.Quality
+}
+
+func (opt Options) frameSize(sampleRate float64) int {
+	if opt.FrameSize > 0 {
+		return opt.FrameSize
+	}
+
+	if opt.Mode == PhaseVocoder {
+		return fft.NextPowerOfTwo(int(sampleRate * 0.05))
+	}
+
+	return fft.NextPowerOfTwo(int(sampleRate * 0.025))
+}
+
+// TimeStretch changes the duration of a sound by factor without changing its pitch, a factor of 2
+// makes the sound twice as long
+func TimeStretch[T float.Float](sf sndfile.SoundFiler[T], factor float64, opt Options) (*sndfile.SoundFile[T], error) {
+	if factor <= 0 {
+		return nil, errors.New("stretch factor should be greater than 0")
+	}
+
+	frameSize := opt.frameSize(sf.SampleRate())
+	if frameSize < 4 {
+		return nil, errors.New("frame size should be at least 4")
+	}
+
+	channels := make([][]float64, sf.NumChannels())
+	for c := range channels {
+		buf := sf.Buffer(c, 0)
+		channels[c] = make([]float64, len(buf))
+		for i, v := range buf {
+			channels[c][i] = float64(v)
+		}
+	}
+
+	var (
+		stretched [][]float64
+		err       error
+	)
+
+	switch opt.Mode {
+	case WSOLA:
+		tolerance := opt.Tolerance
+		if tolerance <= 0 {
+			tolerance = frameSize / 4
+		}
+		stretched = wsola(channels, factor, frameSize, tolerance)
+	case PhaseVocoder:
+		stretched, err = phaseVocoder(channels, factor, frameSize)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("unknown stretch mode")
+	}
+
+	output := make([][]T, len(stretched))
+	for c, buf := range stretched {
+		output[c] = make([]T, len(buf))
+		for i, v := range buf {
+			output[c][i] = T(v)
+		}
+	}
+
+	return sndfile.NewSoundFileFromBuffers(output, sf.SampleRate()), nil
+}
+
+// PitchShift changes the pitch of a sound by a number of semitones without changing its duration
+func PitchShift[T float.Float](sf sndfile.SoundFiler[T], semitones float64, opt Options) (*sndfile.SoundFile[T], error) {
+	ratio := math.Pow(2, semitones/12)
+
+	// Stretch by the pitch ratio and resample back to the original duration
+	stretched, err := TimeStretch(sf, ratio, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	numFrames := int(sf.NumFrames())
+	channels := make([][]T, stretched.NumChannels())
+
+	for c := range channels {
+		buf, err := resample.Resample(stretched.Buffer(c, 0), 1/ratio, opt.Quality)
+		if err != nil {
+			return nil, err
+		}
+
+		channels[c] = make([]T, numFrames)
+		copy(channels[c], buf)
+	}
+
+	return sndfile.NewSoundFileFromBuffers(channels, sf.SampleRate()), nil
+}
+
+// hann returns a periodic Hann window, it sums to a constant at hops of a half and a quarter frame
+func hann(n int) []float64 {
+	w := make([]float64, n)
+	for i := range w {
+		w[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n))
+	}
+
+	return w
+}
+
+// normalize divides output by the accumulated window weights
+func normalize(output []float64, weights []float64) {
+	for i, w := range weights {
+		if w > 1e-6 {
+			output[i] /= w
+		}
+	}
+}
+
+func sampleAt(buf []float64, i int) float64 {
+	if i >= 0 && i < len(buf) {
+		return buf[i]
+	}
+
+	return 0
+}
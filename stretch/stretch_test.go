@@ -0,0 +1,125 @@
+package stretch
+
+import (
+	"math"
+	"testing"
+
+	"github.com/almerlucke/sndfile"
+)
+
+const testSampleRate = 44100.0
+
+func sineFile(freq float64, numFrames int, numChannels int) *sndfile.SoundFile[float32] {
+	channels := make([][]float32, numChannels)
+	for c := range channels {
+		channels[c] = make([]float32, numFrames)
+		for i := range channels[c] {
+			channels[c][i] = float32(math.Sin(2 * math.Pi * freq * float64(i) / testSampleRate))
+		}
+	}
+
+	return sndfile.NewSoundFileFromBuffers(channels, testSampleRate)
+}
+
+// analyze returns the frequency from zero crossings and the rms of the middle half of a buffer
+func analyze(x []float32) (float64, float64) {
+	var crossings int
+	var sum float64
+
+	for i := len(x) / 4; i < 3*len(x)/4; i++ {
+		if x[i-1] < 0 && x[i] >= 0 {
+			crossings++
+		}
+		sum += float64(x[i] * x[i])
+	}
+
+	n := float64(len(x) / 2)
+
+	return float64(crossings) / (n / testSampleRate), math.Sqrt(sum / n)
+}
+
+func TestTimeStretch(t *testing.T) {
+	sf := sineFile(440, 44100, 2)
+
+	for _, mode := range []Mode{WSOLA, PhaseVocoder} {
+		for _, factor := range []float64{0.7, 1, 1.5, 2} {
+			out, err := TimeStretch(sf, factor, Options{Mode: mode})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want := int64(math.Round(44100 * factor)); out.NumFrames() != want || out.NumChannels() != 2 {
+				t.Fatalf("mode %d, factor %v: %d frames, want %d", mode, factor, out.NumFrames(), want)
+			}
+
+			for c := 0; c < 2; c++ {
+				freq, rms := analyze(out.Buffer(c, 0))
+
+				if math.Abs(freq-440) > 5 {
+					t.Errorf("mode %d, factor %v: frequency %.1f Hz", mode, factor, freq)
+				}
+
+				if math.Abs(rms-math.Sqrt2/2) > 0.01 {
+					t.Errorf("mode %d, factor %v: rms %.4f", mode, factor, rms)
+				}
+			}
+		}
+	}
+}
+
+func TestPitchShift(t *testing.T) {
+	sf := sineFile(440, 44100, 1)
+
+	tests := []struct {
+		semitones float64
+		freq      float64
+	}{
+		{12, 880},
+		{-12, 220},
+		{7, 440 * math.Pow(2, 7.0/12)},
+	}
+
+	for _, mode := range []Mode{WSOLA, PhaseVocoder} {
+		for _, tt := range tests {
+			out, err := PitchShift(sf, tt.semitones, Options{Mode: mode})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if out.NumFrames() != sf.NumFrames() {
+				t.Fatalf("mode %d, %v semitones: %d frames", mode, tt.semitones, out.NumFrames())
+			}
+
+			freq, rms := analyze(out.Buffer(0, 0))
+
+			if math.Abs(freq-tt.freq) > 5 {
+				t.Errorf("mode %d, %v semitones: frequency %.1f Hz, want %.1f", mode, tt.semitones, freq, tt.freq)
+			}
+
+			if math.Abs(rms-math.Sqrt2/2) > 0.01 {
+				t.Errorf("mode %d, %v semitones: rms %.4f", mode, tt.semitones, rms)
+			}
+		}
+	}
+}
+
+func TestStretchErrors(t *testing.T) {
+	sf := sineFile(440, 1000, 1)
+
+	tests := []struct {
+		name   string
+		factor float64
+		opt    Options
+	}{
+		{"zero factor", 0, Options{}},
+		{"negative factor", -1, Options{}},
+		{"tiny frame", 1, Options{FrameSize: 2}},
+		{"unknown mode", 1, Options{Mode: Mode(5)}},
+	}
+
+	for _, tt := range tests {
+		if _, err := TimeStretch(sf, tt.factor, tt.opt); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package stretch
+
+import (
+	"math"
+	"math/cmplx"
+
+	"github.com/almerlucke/sndfile/dsp/fft"
+)
+
+// phaseVocoder stretches each channel with a phase vocoder using identity phase locking
+// (Laroche and Dolson), bins around a spectral peak keep their phase relation to the peak
+func phaseVocoder(channels [][]float64, factor float64, frameSize int) ([][]float64, error) {
+	rfft, err := fft.NewReal[float64](frameSize)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([][]float64, len(channels))
+	for c, buf := range channels {
+		output[c] = stretchChannel(buf, factor, frameSize, rfft)
+	}
+
+	return output, nil
+}
+
+func stretchChannel(input []float64, factor float64, frameSize int, rfft *fft.Real[float64]) []float64 {
+	outFrames := int(math.Round(float64(len(input)) * factor))
+	synthesisHop := frameSize / 4
+	analysisHop := float64(synthesisHop) / factor
+	window := hann(frameSize)
+	half := frameSize / 2
+	numBins := rfft.NumBins()
+
+	output := make([]float64, outFrames)
+	weights := make([]float64, outFrames)
+
+	frame := make([]float64, frameSize)
+	spectrum := make([]complex128, numBins)
+	magnitudes := make([]float64, numBins)
+	phases := make([]float64, numBins)
+	prevPhases := make([]float64, numBins)
+	synthPhases := make([]float64, numBins)
+	peaks := make([]int, 0, numBins)
+	prevCenter := 0
+
+	for k := 0; k*synthesisHop-half < outFrames; k++ {
+		center := int(math.Round(float64(k) * analysisHop))
+
+		for i, w := range window {
+			frame[i] = w * sampleAt(input, center-half+i)
+		}
+
+		rfft.Forward(spectrum, frame)
+
+		for b, c := range spectrum {
+			magnitudes[b] = cmplx.Abs(c)
+			phases[b] = cmplx.Phase(c)
+		}
+
+		if k == 0 {
+			copy(synthPhases, phases)
+		} else {
+			hop := float64(center - prevCenter)
+			peaks = findPeaks(magnitudes, peaks[:0])
+
+			// Advance the phase of the peaks by their instantaneous frequency
+			for _, p := range peaks {
+				omega := 2 * math.Pi * float64(p) / float64(frameSize)
+				delta := princarg(phases[p] - prevPhases[p] - omega*hop)
+				freq := omega
+				if hop > 0 {
+					freq += delta / hop
+				}
+				synthPhases[p] = princarg(synthPhases[p] + freq*float64(synthesisHop))
+			}
+
+			// Lock the bins in the region of each peak to the peak phase
+			lockPhases(magnitudes, phases, synthPhases, peaks)
+		}
+
+		copy(prevPhases, phases)
+		prevCenter = center
+
+		for b, m := range magnitudes {
+			spectrum[b] = cmplx.Rect(m, synthPhases[b])
+		}
+
+		rfft.Inverse(frame, spectrum)
+
+		outStart := k*synthesisHop - half
+		for i, w := range window {
+			j := outStart + i
+			if j < 0 || j >= outFrames {
+				continue
+			}
+
+			output[j] += w * frame[i]
+			weights[j] += w * w
+		}
+	}
+
+	normalize(output, weights)
+
+	return output
+}
+
+// findPeaks appends the bins that are larger than their two neighbours on each side
+func findPeaks(magnitudes []float64, peaks []int) []int {
+	n := len(magnitudes)
+
+	for b := 0; b < n; b++ {
+		m := magnitudes[b]
+		isPeak := m > 0
+
+		for d := -2; d <= 2 && isPeak; d++ {
+			if d != 0 && b+d >= 0 && b+d < n && magnitudes[b+d] > m {
+				isPeak = false
+			}
+		}
+
+		if isPeak {
+			peaks = append(peaks, b)
+		}
+	}
+
+	return peaks
+}
+
+// lockPhases sets the synthesis phase of non peak bins relative to the peak whose region they are in,
+// region boundaries lie at the lowest bin between two peaks
+func lockPhases(magnitudes []float64, phases []float64, synthPhases []float64, peaks []int) {
+	if len(peaks) == 0 {
+		return
+	}
+
+	start := 0
+
+	for i, p := range peaks {
+		end := len(magnitudes)
+
+		if i+1 < len(peaks) {
+			next := peaks[i+1]
+			end = p + 1
+
+			for b := p + 1; b < next; b++ {
+				if magnitudes[b] < magnitudes[end] {
+					end = b
+				}
+			}
+		}
+
+		for b := start; b < end; b++ {
+			if b != p {
+				synthPhases[b] = synthPhases[p] + phases[b] - phases[p]
+			}
+		}
+
+		start = end
+	}
+}
+
+// princarg wraps a phase to [-pi, pi)
+func princarg(phase float64) float64 {
+	return phase - 2*math.Pi*math.Floor((phase+math.Pi)/(2*math.Pi))
+}
@@ -0,0 +1,86 @@
+package stretch
+
+import "math"
+
+// wsola stretches all channels with waveform similarity overlap-add. The frame alignment is
+// searched on the mix of all channels and applied to every channel to keep the stereo image.
+func wsola(channels [][]float64, factor float64, frameSize int, tolerance int) [][]float64 {
+	inFrames := len(channels[0])
+	outFrames := int(math.Round(float64(inFrames) * factor))
+	synthesisHop := frameSize / 2
+	analysisHop := float64(synthesisHop) / factor
+	window := hann(frameSize)
+	half := frameSize / 2
+
+	mix := make([]float64, inFrames)
+	for _, buf := range channels {
+		for i, v := range buf {
+			mix[i] += v
+		}
+	}
+
+	output := make([][]float64, len(channels))
+	for c := range output {
+		output[c] = make([]float64, outFrames)
+	}
+
+	weights := make([]float64, outFrames)
+	prevStart := 0
+
+	for k := 0; k*synthesisHop-half < outFrames; k++ {
+		outStart := k*synthesisHop - half
+		nominal := int(math.Round(float64(k)*analysisHop)) - half
+		start := nominal
+
+		if k > 0 {
+			start = bestMatch(mix, prevStart+synthesisHop, nominal, tolerance, frameSize)
+		}
+
+		for i, w := range window {
+			j := outStart + i
+			if j < 0 || j >= outFrames {
+				continue
+			}
+
+			for c, buf := range channels {
+				output[c][j] += w * sampleAt(buf, start+i)
+			}
+
+			weights[j] += w
+		}
+
+		prevStart = start
+	}
+
+	for c := range output {
+		normalize(output[c], weights)
+	}
+
+	return output
+}
+
+// bestMatch returns the frame start within tolerance of nominal that is most similar to the
+// natural continuation of the previous frame
+func bestMatch(mix []float64, natural int, nominal int, tolerance int, frameSize int) int {
+	best := nominal
+	bestCorr := math.Inf(-1)
+
+	// Compare the overlapping first half of the frames, skip every other sample for speed
+	length := frameSize / 2
+
+	for offset := -tolerance; offset <= tolerance; offset++ {
+		candidate := nominal + offset
+
+		var corr float64
+		for i := 0; i < length; i += 2 {
+			corr += sampleAt(mix, natural+i) * sampleAt(mix, candidate+i)
+		}
+
+		if corr > bestCorr {
+			bestCorr = corr
+			best = candidate
+		}
+	}
+
+	return best
+}
@@ -1,21 +1,93 @@
 package wav
 
 import (
-	"bytes"
 	"encoding/binary"
 	"errors"
 	"io"
+	"math"
 	"os"
 )
 
+// Format is the sample format of the wav file
+type Format int
+
+const (
+	Float32 Format = iota
+	Float64
+	PCM8
+	PCM16
+	PCM24
+	PCM32
+)
+
+// BitsPerSample returns the number of bits of a sample
+func (f Format) BitsPerSample() int {
+	switch f {
+	case Float64:
+		return 64
+	case PCM8:
+		return 8
+	case PCM16:
+		return 16
+	case PCM24:
+		return 24
+	default:
+		return 32
+	}
+}
+
+// BytesPerSample returns the number of bytes of a sample
+func (f Format) BytesPerSample() int {
+	return f.BitsPerSample() / 8
+}
+
+// IsFloat returns true for IEEE float formats
+func (f Format) IsFloat() bool {
+	return f == Float32 || f == Float64
+}
+
+// extensibleSubFormat is the GUID tail shared by the WAVE_FORMAT_EXTENSIBLE sub formats, the
+// sub format starts with the format tag
+var extensibleSubFormat = []byte{0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71}
+
+// channelMasks are the default speaker positions by number of channels, other channel counts
+// are written without speaker positions
+var channelMasks = map[int]uint32{
+	1: 0x4,   // center
+	2: 0x3,   // left, right
+	3: 0x7,   // left, right, center
+	4: 0x33,  // left, right, back left, back right
+	5: 0x37,  // left, right, center, back left, back right
+	6: 0x3f,  // 5.1
+	7: 0x70f, // 6.1
+	8: 0x63f, // 7.1
+}
+
 type Wav struct {
 	numChannels  int16
 	totalSamples uint32
 	sampleRate   int32
+	format       Format
+	gain         float64
+	factOffset   int64
+	dataOffset   int64
+	buf          []byte
 	file         *os.File
 }
 
+// New creates a 32 bit float wav file
 func New(filePath string, numChannels int, sampleRate float64) (*Wav, error) {
+	return NewWithFormat(filePath, numChannels, sampleRate, Float32)
+}
+
+// NewWithFormat creates a wav file with the given sample format, integer formats clip samples
+// outside -1 to 1. Integer samples are stored as 32 bit float until Close, so Normalize is
+// applied before quantization.
+func NewWithFormat(filePath string, numChannels int, sampleRate float64, format Format) (*Wav, error) {
+	if format < Float32 || format > PCM32 {
+		return nil, errors.New("unknown wav format")
+	}
+
 	file, err := os.Create(filePath)
 	if err != nil {
 		return nil, err
@@ -24,6 +96,8 @@ func New(filePath string, numChannels int, sampleRate float64) (*Wav, error) {
 	wav := &Wav{
 		numChannels: int16(numChannels),
 		sampleRate:  int32(sampleRate),
+		format:      format,
+		gain:        1,
 		file:        file,
 	}
 
@@ -40,6 +114,11 @@ func (wav *Wav) Close() error {
 	var errs []error
 	var err error
 
+	err = wav.convert()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
 	err = wav.updateSizes()
 	if err != nil {
 		errs = append(errs, err)
@@ -57,112 +136,94 @@ func (wav *Wav) Close() error {
 	return nil
 }
 
-func (wav *Wav) writeHeader() error {
-	var long int32
-	var short int16
-
-	_, err := wav.file.Write([]byte("RIFF")) // 0
-	if err != nil {
-		return nil
+// storage returns the format samples are stored in until Close
+func (wav *Wav) storage() Format {
+	if wav.format.IsFloat() {
+		return wav.format
 	}
 
-	// write bogus total file size to be overwritten later
-	err = binary.Write(wav.file, binary.LittleEndian, long) // 4
-	if err != nil {
-		return nil
-	}
-
-	_, err = wav.file.Write([]byte("WAVE")) // 8
-	if err != nil {
-		return nil
-	}
-
-	_, err = wav.file.Write([]byte("fmt ")) // 12
-	if err != nil {
-		return nil
-	}
-
-	long = 18                                               // size of fmt chunk
-	err = binary.Write(wav.file, binary.LittleEndian, long) // 16
-	if err != nil {
-		return nil
-	}
-
-	short = 3                                                // float32 format
-	err = binary.Write(wav.file, binary.LittleEndian, short) // 20
-	if err != nil {
-		return nil
-	}
-
-	short = wav.numChannels
-	err = binary.Write(wav.file, binary.LittleEndian, short) // 22
-	if err != nil {
-		return nil
-	}
-
-	err = binary.Write(wav.file, binary.LittleEndian, wav.sampleRate) // 24
-	if err != nil {
-		return nil
-	}
-
-	long = (wav.sampleRate * 32 * int32(wav.numChannels)) / 8
-	err = binary.Write(wav.file, binary.LittleEndian, long) // 28
-	if err != nil {
-		return nil
-	}
-
-	short = int16((32 * int32(wav.numChannels)) / 8)
-	err = binary.Write(wav.file, binary.LittleEndian, short) // 32
-	if err != nil {
-		return nil
-	}
+	return Float32
+}
 
-	short = 32
-	err = binary.Write(wav.file, binary.LittleEndian, short) // 34
-	if err != nil {
-		return nil
-	}
+// extensible returns true if the header needs WAVE_FORMAT_EXTENSIBLE, which is the case for
+// integer samples of more than 16 bits and for more than 2 channels
+func (wav *Wav) extensible() bool {
+	return (!wav.format.IsFloat() && wav.format.BitsPerSample() > 16) || wav.numChannels > 2
+}
 
-	short = 0                                                // size of extension
-	err = binary.Write(wav.file, binary.LittleEndian, short) // 36
-	if err != nil {
-		return nil
-	}
+func (wav *Wav) writeHeader() error {
+	le := binary.LittleEndian
+	bits := wav.format.BitsPerSample()
+	blockAlign := bits / 8 * int(wav.numChannels)
+
+	// Format tag 1 is integer PCM and 3 is IEEE float
+	var tag uint16 = 1
+	if wav.format.IsFloat() {
+		tag = 3
+	}
+
+	var fmtChunk []byte
+	fmtChunk = le.AppendUint16(fmtChunk, tag)
+	fmtChunk = le.AppendUint16(fmtChunk, uint16(wav.numChannels))
+	fmtChunk = le.AppendUint32(fmtChunk, uint32(wav.sampleRate))
+	fmtChunk = le.AppendUint32(fmtChunk, uint32(int(wav.sampleRate)*blockAlign))
+	fmtChunk = le.AppendUint16(fmtChunk, uint16(blockAlign))
+	fmtChunk = le.AppendUint16(fmtChunk, uint16(bits))
+
+	if wav.extensible() {
+		// Replace the format tag by WAVE_FORMAT_EXTENSIBLE, the tag moves to the sub format
+		le.PutUint16(fmtChunk, 0xfffe)
+		fmtChunk = le.AppendUint16(fmtChunk, 22) // size of extension
+		fmtChunk = le.AppendUint16(fmtChunk, uint16(bits))
+		fmtChunk = le.AppendUint32(fmtChunk, channelMasks[int(wav.numChannels)])
+		fmtChunk = le.AppendUint16(fmtChunk, tag)
+		fmtChunk = append(fmtChunk, extensibleSubFormat...)
+	} else if wav.format.IsFloat() {
+		fmtChunk = le.AppendUint16(fmtChunk, 0) // size of extension
+	}
+
+	header := []byte("RIFF")
+	header = le.AppendUint32(header, 0) // total file size to be overwritten later
+	header = append(header, "WAVEfmt "...)
+	header = le.AppendUint32(header, uint32(len(fmtChunk)))
+	header = append(header, fmtChunk...)
+
+	// Non PCM formats need a fact chunk with the number of sample frames
+	if wav.format.IsFloat() {
+		header = append(header, "fact"...)
+		header = le.AppendUint32(header, 4)
+		wav.factOffset = int64(len(header))
+		header = le.AppendUint32(header, 0) // num sample frames to be overwritten later
+	}
+
+	header = append(header, "data"...)
+	header = le.AppendUint32(header, 0) // data section size to be overwritten later
+	wav.dataOffset = int64(len(header))
+
+	_, err := wav.file.Write(header)
 
-	_, err = wav.file.Write([]byte("fact")) // 38
-	if err != nil {
-		return nil
-	}
+	return err
+}
 
-	long = 4
-	err = binary.Write(wav.file, binary.LittleEndian, long) // 42
-	if err != nil {
-		return nil
-	}
+func (wav *Wav) updateSizes() error {
+	var size uint32
 
-	long = 0                                                // num sample frames to be overwritten later
-	err = binary.Write(wav.file, binary.LittleEndian, long) // 46
-	if err != nil {
-		return nil
-	}
+	dataSize := wav.totalSamples * uint32(wav.format.BytesPerSample())
+	padding := dataSize & 1
 
-	_, err = wav.file.Write([]byte("data")) // 50
-	if err != nil {
-		return nil
-	}
+	// Chunks must have an even size, pad the data chunk
+	if padding != 0 {
+		_, err := wav.file.Seek(0, io.SeekEnd)
+		if err != nil {
+			return err
+		}
 
-	long = 0                                                // data section size to be overwritten later
-	err = binary.Write(wav.file, binary.LittleEndian, long) // 54
-	if err != nil {
-		return nil
+		_, err = wav.file.Write([]byte{0})
+		if err != nil {
+			return err
+		}
 	}
 
-	return nil
-}
-
-func (wav *Wav) updateSizes() error {
-	var size uint32
-
 	// Seek total size
 	_, err := wav.file.Seek(4, io.SeekStart)
 	if err != nil {
@@ -170,33 +231,35 @@ func (wav *Wav) updateSizes() error {
 	}
 
 	// Update total size
-	size = 50 + wav.totalSamples*4
+	size = uint32(wav.dataOffset) - 8 + dataSize + padding
 	err = binary.Write(wav.file, binary.LittleEndian, size)
 	if err != nil {
 		return err
 	}
 
-	// Seek fact section size
-	_, err = wav.file.Seek(46, io.SeekStart)
-	if err != nil {
-		return err
-	}
+	if wav.factOffset != 0 {
+		// Seek fact section size
+		_, err = wav.file.Seek(wav.factOffset, io.SeekStart)
+		if err != nil {
+			return err
+		}
 
-	// Update fact section size
-	size = wav.totalSamples / uint32(wav.numChannels)
-	err = binary.Write(wav.file, binary.LittleEndian, size)
-	if err != nil {
-		return err
+		// Update fact section size
+		size = wav.totalSamples / uint32(wav.numChannels)
+		err = binary.Write(wav.file, binary.LittleEndian, size)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Seek data section size
-	_, err = wav.file.Seek(54, io.SeekStart)
+	_, err = wav.file.Seek(wav.dataOffset-4, io.SeekStart)
 	if err != nil {
 		return err
 	}
 
 	// Update data section size
-	size = wav.totalSamples * 4
+	size = dataSize
 	err = binary.Write(wav.file, binary.LittleEndian, size)
 	if err != nil {
 		return err
@@ -205,94 +268,121 @@ func (wav *Wav) updateSizes() error {
 	return nil
 }
 
-func (wav *Wav) Normalize(max float32) error {
-	var err error
+// quantize scales and clips a sample to a signed integer range
+func quantize(v float64, scale float64) int64 {
+	if math.IsNaN(v) {
+		return 0
+	}
 
-	// Read and write buffers
-	readerBuffer := make([]byte, 8192)
-	writerBuffer := make([]byte, 0, 8192)
+	return int64(min(max(math.Round(v*scale), -scale), scale-1))
+}
 
-	// Seek to start of sound data
-	_, err = wav.file.Seek(58, io.SeekStart)
-	if err != nil {
-		return err
+// encode appends a sample in format f to b
+func (f Format) encode(b []byte, v float64) []byte {
+	le := binary.LittleEndian
+
+	switch f {
+	case Float64:
+		return le.AppendUint64(b, math.Float64bits(v))
+	case PCM8:
+		// 8 bit samples are unsigned
+		return append(b, byte(quantize(v, 128.0)+128))
+	case PCM16:
+		return le.AppendUint16(b, uint16(quantize(v, 32768.0)))
+	case PCM24:
+		q := quantize(v, 8388608.0)
+		return append(b, byte(q), byte(q>>8), byte(q>>16))
+	case PCM32:
+		return le.AppendUint32(b, uint32(quantize(v, 2147483648.0)))
+	default:
+		return le.AppendUint32(b, math.Float32bits(float32(v)))
 	}
+}
 
-	if max <= 0.0 {
+// decode returns the sample in format f at the start of b
+func (f Format) decode(b []byte) float64 {
+	le := binary.LittleEndian
+
+	switch f {
+	case Float64:
+		return math.Float64frombits(le.Uint64(b))
+	case PCM8:
+		return float64(int(b[0])-128) / 128.0
+	case PCM16:
+		return float64(int16(le.Uint16(b))) / 32768.0
+	case PCM24:
+		return float64(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24)>>8) / 8388608.0
+	case PCM32:
+		return float64(int32(le.Uint32(b))) / 2147483648.0
+	default:
+		return float64(math.Float32frombits(le.Uint32(b)))
+	}
+}
+
+// Normalize scales all samples by 1 / max when the file is closed, a max of 0 or less is ignored
+func (wav *Wav) Normalize(max float32) error {
+	if max > 0.0 {
+		wav.gain = 1.0 / float64(max)
+	}
+
+	return nil
+}
+
+// convert applies the normalization gain and converts the stored samples to the file format.
+// Samples are converted in place front to back, which is safe because the file format never
+// takes more bytes than the storage format.
+func (wav *Wav) convert() error {
+	from := wav.storage()
+	if from == wav.format && wav.gain == 1 {
 		return nil
 	}
 
-	var (
-		oom = 1.0 / max
-		pos int64
-		n   int
-	)
+	// Read and write buffers, a whole number of samples
+	readerBuffer := make([]byte, 2048*from.BytesPerSample())
+	writerBuffer := make([]byte, 0, 2048*wav.format.BytesPerSample())
+
+	readPos := wav.dataOffset
+	writePos := wav.dataOffset
+	remaining := int64(wav.totalSamples) * int64(from.BytesPerSample())
 
-	// Loop through all samples
-	for {
-		pos, err = wav.file.Seek(0, io.SeekCurrent)
+	for remaining > 0 {
+		n, err := wav.file.ReadAt(readerBuffer[:min(int64(len(readerBuffer)), remaining)], readPos)
 		if err != nil {
 			return err
 		}
 
-		// Read 8192 bytes if possible
-		n, err = wav.file.Read(readerBuffer)
-		if err != nil {
-			if err != io.EOF {
-				return err
-			}
-			// Break if EOF
-			break
-		}
+		readPos += int64(n)
+		remaining -= int64(n)
 
-		// Create reader and writer objects for conversion
-		byteReader := bytes.NewReader(readerBuffer[:n])
-		writer := bytes.NewBuffer(writerBuffer[:0])
-
-		// Normalize read bytes and convert back
-		for {
-			var f float32
-
-			err = binary.Read(byteReader, binary.LittleEndian, &f)
-			if err != nil {
-				if err != io.EOF {
-					return err
-				}
-				break
-			}
-
-			err = binary.Write(writer, binary.LittleEndian, f*oom)
-			if err != nil {
-				return err
-			}
+		writer := writerBuffer[:0]
+		for i := 0; i < n; i += from.BytesPerSample() {
+			writer = wav.format.encode(writer, from.decode(readerBuffer[i:])*wav.gain)
 		}
 
-		// Seek last pos
-		_, err = wav.file.Seek(pos, io.SeekStart)
+		_, err = wav.file.WriteAt(writer, writePos)
 		if err != nil {
 			return err
 		}
 
-		// Overwrite with normalized samples
-		_, err = wav.file.Write(writer.Bytes())
-		if err != nil {
-			return err
-		}
+		writePos += int64(len(writer))
 	}
 
-	return nil
+	// Drop the samples left over from the larger storage format
+	return wav.file.Truncate(writePos)
 }
 
 func (wav *Wav) Write(items []float32) error {
-	var buf bytes.Buffer
+	buf := wav.buf[:0]
+	storage := wav.storage()
 
 	for _, item := range items {
-		_ = binary.Write(&buf, binary.LittleEndian, item)
+		buf = storage.encode(buf, float64(item))
 	}
 
+	wav.buf = buf
 	wav.totalSamples += uint32(len(items))
 
-	_, err := wav.file.Write(buf.Bytes())
+	_, err := wav.file.Write(buf)
 
 	return err
 }
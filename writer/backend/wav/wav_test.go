@@ -0,0 +1,218 @@
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// chunks returns the chunks of a RIFF WAVE file by id
+func chunks(t *testing.T, data []byte) map[string][]byte {
+	le := binary.LittleEndian
+
+	if string(data[:4]) != "RIFF" || string(data[8:12]) != "WAVE" || int(le.Uint32(data[4:])) != len(data)-8 {
+		t.Fatalf("invalid RIFF header, %d bytes", len(data))
+	}
+
+	cs := map[string][]byte{}
+
+	for pos := 12; pos < len(data); {
+		size := int(le.Uint32(data[pos+4:]))
+		if pos+8+size > len(data) {
+			t.Fatalf("chunk %s exceeds the file", data[pos:pos+4])
+		}
+
+		cs[string(data[pos:pos+4])] = data[pos+8 : pos+8+size]
+		pos += 8 + size + size%2
+	}
+
+	return cs
+}
+
+// writeFile writes samples to a wav file and returns its contents
+func writeFile(t *testing.T, format Format, numChannels int, samples []float32, max float32) []byte {
+	path := filepath.Join(t.TempDir(), "test.wav")
+
+	w, err := NewWithFormat(path, numChannels, 44100, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Write(samples); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Normalize(max); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestRoundTrip(t *testing.T) {
+	le := binary.LittleEndian
+	subFormatTail := []byte{0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71}
+
+	tests := []struct {
+		format      Format
+		numChannels int
+		tag         uint16
+		extensible  bool
+		mask        uint32
+	}{
+		{Float32, 1, 3, false, 0},
+		{Float64, 2, 3, false, 0},
+		{Float32, 6, 3, true, 0x3f},
+		{PCM8, 1, 1, false, 0},
+		{PCM16, 2, 1, false, 0},
+		{PCM16, 3, 1, true, 0x7},
+		{PCM24, 1, 1, true, 0x4},
+		{PCM24, 2, 1, true, 0x3},
+		{PCM32, 2, 1, true, 0x3},
+		{PCM32, 9, 1, true, 0},
+	}
+
+	for _, tt := range tests {
+		in := make([]float32, 9*tt.numChannels)
+		for i := range in {
+			in[i] = float32(math.Sin(float64(i)*0.7)) * 1.2
+		}
+
+		cs := chunks(t, writeFile(t, tt.format, tt.numChannels, in, 0))
+		fmtChunk, data := cs["fmt "], cs["data"]
+		bytesPerSample := tt.format.BytesPerSample()
+		blockAlign := bytesPerSample * tt.numChannels
+
+		if int(le.Uint16(fmtChunk[2:])) != tt.numChannels || le.Uint32(fmtChunk[4:]) != 44100 ||
+			int(le.Uint32(fmtChunk[8:])) != 44100*blockAlign || int(le.Uint16(fmtChunk[12:])) != blockAlign ||
+			int(le.Uint16(fmtChunk[14:])) != tt.format.BitsPerSample() {
+			t.Errorf("format %d, %d channels: invalid fmt chunk", tt.format, tt.numChannels)
+		}
+
+		switch {
+		case tt.extensible:
+			if le.Uint16(fmtChunk) != 0xfffe || len(fmtChunk) != 40 || le.Uint16(fmtChunk[16:]) != 22 ||
+				int(le.Uint16(fmtChunk[18:])) != tt.format.BitsPerSample() || le.Uint32(fmtChunk[20:]) != tt.mask ||
+				le.Uint16(fmtChunk[24:]) != tt.tag || !bytes.Equal(fmtChunk[26:], subFormatTail) {
+				t.Errorf("format %d, %d channels: invalid extensible fmt chunk", tt.format, tt.numChannels)
+			}
+		case tt.format.IsFloat():
+			if le.Uint16(fmtChunk) != tt.tag || len(fmtChunk) != 18 || le.Uint16(fmtChunk[16:]) != 0 {
+				t.Errorf("format %d, %d channels: invalid float fmt chunk", tt.format, tt.numChannels)
+			}
+		default:
+			if le.Uint16(fmtChunk) != tt.tag || len(fmtChunk) != 16 {
+				t.Errorf("format %d, %d channels: invalid PCM fmt chunk", tt.format, tt.numChannels)
+			}
+		}
+
+		// Only non PCM data has a fact chunk
+		fact, ok := cs["fact"]
+		if ok != tt.format.IsFloat() || (ok && le.Uint32(fact) != 9) {
+			t.Errorf("format %d, %d channels: fact chunk %v", tt.format, tt.numChannels, fact)
+		}
+
+		if len(data) != len(in)*bytesPerSample {
+			t.Fatalf("format %d, %d channels: %d data bytes", tt.format, tt.numChannels, len(data))
+		}
+
+		tol := 0.0
+		if !tt.format.IsFloat() {
+			tol = 1 / math.Pow(2, float64(tt.format.BitsPerSample()-1))
+		}
+
+		for i, v := range in {
+			want := float64(v)
+			if !tt.format.IsFloat() {
+				want = min(max(want, -1), 1)
+			}
+
+			if got := tt.format.decode(data[i*bytesPerSample:]); math.Abs(got-want) > tol {
+				t.Errorf("format %d, %d channels: sample %d is %v, want %v", tt.format, tt.numChannels, i, got, want)
+				break
+			}
+		}
+	}
+}
+
+func TestQuantize(t *testing.T) {
+	le := binary.LittleEndian
+	in := []float32{0, 0.5, -0.5, 1, -1, 1.5, -1.5}
+
+	tests := []struct {
+		format Format
+		want   []int64
+	}{
+		{PCM8, []int64{128, 192, 64, 255, 0, 255, 0}},
+		{PCM16, []int64{0, 16384, -16384, 32767, -32768, 32767, -32768}},
+		{PCM24, []int64{0, 4194304, -4194304, 8388607, -8388608, 8388607, -8388608}},
+		{PCM32, []int64{0, 1073741824, -1073741824, 2147483647, -2147483648, 2147483647, -2147483648}},
+	}
+
+	for _, tt := range tests {
+		data := chunks(t, writeFile(t, tt.format, 1, in, 0))["data"]
+
+		for i, want := range tt.want {
+			var got int64
+
+			switch tt.format {
+			case PCM8:
+				got = int64(data[i])
+			case PCM16:
+				got = int64(int16(le.Uint16(data[i*2:])))
+			case PCM24:
+				got = int64(int32(uint32(data[i*3])<<8|uint32(data[i*3+1])<<16|uint32(data[i*3+2])<<24) >> 8)
+			case PCM32:
+				got = int64(int32(le.Uint32(data[i*4:])))
+			}
+
+			if got != want {
+				t.Errorf("format %d: sample %d is %d, want %d", tt.format, i, got, want)
+			}
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	// Peaks above 1 are normalized before integer samples are quantized
+	in := []float32{2, -2, 1, 0.5}
+	want := []float64{1, -1, 0.5, 0.25}
+
+	for _, format := range []Format{Float32, Float64, PCM8, PCM16, PCM24, PCM32} {
+		data := chunks(t, writeFile(t, format, 1, in, 2))["data"]
+		tol := 1 / math.Pow(2, float64(format.BitsPerSample()-1))
+
+		for i, w := range want {
+			if got := format.decode(data[i*format.BytesPerSample():]); math.Abs(got-w) > tol {
+				t.Errorf("format %d: sample %d is %v, want %v", format, i, got, w)
+			}
+		}
+	}
+
+	// A max of 0 leaves the samples untouched
+	data := chunks(t, writeFile(t, Float32, 1, in, 0))["data"]
+	if Float32.decode(data) != 2 {
+		t.Fatal("samples changed by a max of 0")
+	}
+}
+
+func TestPadding(t *testing.T) {
+	// An odd data size is padded to keep chunks aligned
+	data := writeFile(t, PCM8, 1, []float32{0, 0.5, -0.5}, 0)
+
+	if len(data)%2 != 0 || len(chunks(t, data)["data"]) != 3 {
+		t.Fatalf("%d bytes", len(data))
+	}
+}
@@ -2,10 +2,10 @@ package writer
 
 import (
 	"errors"
+	"github.com/almerlucke/sndfile/dsp/resample"
 	"github.com/almerlucke/sndfile/writer/backend"
 	"github.com/almerlucke/sndfile/writer/backend/aifc"
 	"github.com/almerlucke/sndfile/writer/backend/wav"
-	"github.com/dh1tw/gosamplerate"
 	"path"
 )
 
@@ -20,19 +20,37 @@ const (
 	DefaultFrameSize = 8192
 )
 
+// Monitor observes the interleaved output of the writer just before it is written to the backend,
+// i.e. loudness.Meter
+type Monitor interface {
+	Process([]float32)
+}
+
+// Processor processes the converted interleaved input in place before sample rate conversion,
+// i.e. filters.FIRProcessor. With a NoConverter the buffer passed to Write is modified.
+type Processor interface {
+	ProcessInterleaved([]float32)
+}
+
 type Options struct {
 	InputConverter    InputConverter
 	ConvertSampleRate bool
-	SrConvQuality     int
-	InputSampleRate   float64
-	Normalize         bool
+	// SrConvQuality is a libsamplerate converter type, see resample.Quality
+	SrConvQuality   int
+	InputSampleRate float64
+	Normalize       bool
+	Processors      []Processor
+	Monitors        []Monitor
+	// WavFormat is the sample format of WAV output, the default is 32 bit float
+	WavFormat wav.Format
 }
 
 type Writer struct {
 	opt         Options
-	srConv      gosamplerate.Src
+	srConv      *resample.Resampler[float32]
 	backend     backend.Backend
 	numChannels int
+	sampleRate  float64
 	srRatio     float64
 	max         float32
 }
@@ -62,7 +80,7 @@ func NewWithOptions(filePath string, fileFormat FileFormat, numChannels int, sam
 		if ext == "" {
 			filePath += ".wav"
 		}
-		be, err = wav.New(filePath, numChannels, sampleRate)
+		be, err = wav.NewWithFormat(filePath, numChannels, sampleRate, opt.WavFormat)
 		if err != nil {
 			return nil, err
 		}
@@ -75,6 +93,7 @@ func NewWithBackend(be backend.Backend, numChannels int, sampleRate float64, opt
 	w := &Writer{
 		opt:         opt,
 		numChannels: numChannels,
+		sampleRate:  sampleRate,
 		backend:     be,
 	}
 
@@ -83,24 +102,14 @@ func NewWithBackend(be backend.Backend, numChannels int, sampleRate float64, opt
 	}
 
 	if opt.ConvertSampleRate {
-		frameSize := DefaultFrameSize
-
-		if opt.InputConverter.FrameSize() != 0 {
-			frameSize = opt.InputConverter.FrameSize()
-		}
+		w.srRatio = sampleRate / opt.InputSampleRate
 
-		srConv, err := gosamplerate.New(opt.SrConvQuality, numChannels, frameSize*numChannels)
+		srConv, err := resample.New[float32](numChannels, w.srRatio, resample.Quality(opt.SrConvQuality))
 		if err != nil {
 			return nil, err
 		}
 
 		w.srConv = srConv
-		w.srRatio = sampleRate / opt.InputSampleRate
-
-		err = w.srConv.SetRatio(w.srRatio)
-		if err != nil {
-			return nil, err
-		}
 	}
 
 	return w, nil
@@ -115,6 +124,10 @@ func (wr *Writer) Write(input any, endOfInput bool) error {
 
 	output := wr.opt.InputConverter.Convert(input)
 
+	for _, processor := range wr.opt.Processors {
+		processor.ProcessInterleaved(output)
+	}
+
 	for _, samp := range output {
 		if samp > wr.max {
 			wr.max = samp
@@ -122,13 +135,14 @@ func (wr *Writer) Write(input any, endOfInput bool) error {
 	}
 
 	if wr.opt.ConvertSampleRate {
-		output, err = wr.srConv.Process(output, wr.srRatio, endOfInput)
-		if err != nil {
-			return err
-		}
+		output = wr.srConv.Process(output, endOfInput)
 	}
 
 	if len(output) > 0 {
+		for _, monitor := range wr.opt.Monitors {
+			monitor.Process(output)
+		}
+
 		err = wr.backend.Write(output)
 		if err != nil {
 			return err
@@ -138,6 +152,24 @@ func (wr *Writer) Write(input any, endOfInput bool) error {
 	return nil
 }
 
+// SetInputSampleRate changes the input sample rate while writing, this only has effect when
+// ConvertSampleRate is set
+func (wr *Writer) SetInputSampleRate(sampleRate float64) error {
+	if wr.srConv == nil {
+		return errors.New("sample rate conversion is not enabled")
+	}
+
+	err := wr.srConv.SetRatio(wr.sampleRate / sampleRate)
+	if err != nil {
+		return err
+	}
+
+	wr.opt.InputSampleRate = sampleRate
+	wr.srRatio = wr.srConv.Ratio()
+
+	return nil
+}
+
 func (wr *Writer) Close() error {
 	var errs []error
 	var err error
@@ -149,13 +181,6 @@ func (wr *Writer) Close() error {
 		}
 	}
 
-	if wr.opt.ConvertSampleRate {
-		err = gosamplerate.Delete(wr.srConv)
-		if err != nil {
-			errs = append(errs, err)
-		}
-	}
-
 	err = wr.backend.Close()
 	if err != nil {
 		errs = append(errs, err)